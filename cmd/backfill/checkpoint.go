@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// checkpoint is the resumable state of a backfill, saved as JSON.
+type checkpoint struct {
+	// Source identifies the input so a checkpoint is never applied to a
+	// different one.
+	Source string `json:"source"`
+	// Offsets is the next offset to process per source partition. Every
+	// offset before it has been loaded, dead-lettered, or dropped.
+	Offsets   map[int]int64 `json:"offsets"`
+	Complete  bool          `json:"complete"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// loadCheckpoint reads the checkpoint at path. A missing file, or restart,
+// starts a fresh checkpoint for source.
+func loadCheckpoint(path, source string, restart bool) (*checkpoint, error) {
+	fresh := &checkpoint{Source: source, Offsets: map[int]int64{}}
+	if restart {
+		return fresh, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var c checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+	if c.Source != source {
+		return nil, fmt.Errorf("checkpoint %s is for %s, not %s: pass -restart or a different -checkpoint", path, c.Source, source)
+	}
+	if c.Offsets == nil {
+		c.Offsets = map[int]int64{}
+	}
+	return &c, nil
+}
+
+// save replaces the file at path through a rename so a crash never leaves it
+// half-written.
+func (c *checkpoint) save(path string) error {
+	c.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace checkpoint: %w", err)
+	}
+	return nil
+}
+
+// tracker wraps the source extractor to count progress and advance the
+// checkpoint as the pipeline commits. With workers, batches may commit out of
+// order, so each partition's checkpoint only moves past offsets once every
+// earlier extracted offset has committed too.
+//
+// Sources that only have partition 0 are spread: each batch is relabelled to
+// a partition of its own, round-robin over spread, so the pipeline's
+// partition workers process batches in parallel.
+// It implements pipeline.BatchExtractor and pipeline.BatchCommitter.
+type tracker struct {
+	src    pipeline.BatchExtractor
+	path   string
+	spread int
+
+	read      atomic.Int64
+	done      atomic.Int64
+	exhausted atomic.Bool
+
+	mu      sync.Mutex
+	ckpt    *checkpoint
+	batches int
+	pending map[int][]int64        // extracted offsets not yet checkpointed, in order
+	acked   map[int]map[int64]bool // committed offsets still behind an uncommitted one
+}
+
+func newTracker(src pipeline.BatchExtractor, ckpt *checkpoint, path string, spread int) *tracker {
+	return &tracker{
+		src:     src,
+		path:    path,
+		spread:  spread,
+		ckpt:    ckpt,
+		pending: map[int][]int64{},
+		acked:   map[int]map[int64]bool{},
+	}
+}
+
+func (t *tracker) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	batch, err := t.src.ExtractBatch(ctx, batchSize)
+	if errors.Is(err, io.EOF) {
+		t.exhausted.Store(true)
+	}
+	if len(batch) == 0 {
+		return batch, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range batch {
+		t.pending[batch[i].Partition] = append(t.pending[batch[i].Partition], batch[i].Offset)
+	}
+	if t.spread > 1 {
+		for i := range batch {
+			batch[i].Partition = t.batches % t.spread
+		}
+	}
+	t.batches++
+	t.read.Add(int64(len(batch)))
+	return batch, err
+}
+
+// CommitBatch marks events as done and saves the checkpoint if it advanced.
+// Events are not committed to the source; the checkpoint is the only record
+// of progress.
+func (t *tracker) CommitBatch(_ context.Context, events []domain.RawEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, raw := range events {
+		partition := raw.Partition
+		if t.spread > 1 {
+			partition = 0
+		}
+		if t.acked[partition] == nil {
+			t.acked[partition] = map[int64]bool{}
+		}
+		t.acked[partition][raw.Offset] = true
+	}
+	t.done.Add(int64(len(events)))
+
+	advanced := false
+	for partition, offsets := range t.pending {
+		acked := t.acked[partition]
+		n := 0
+		for n < len(offsets) && acked[offsets[n]] {
+			delete(acked, offsets[n])
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		t.ckpt.Offsets[partition] = offsets[n-1] + 1
+		t.pending[partition] = offsets[n:]
+		advanced = true
+	}
+	if !advanced {
+		return nil
+	}
+	return t.ckpt.save(t.path)
+}
+
+// finish marks the checkpoint complete if the source was read to the end and
+// everything extracted was committed.
+func (t *tracker) finish() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.exhausted.Load() {
+		return nil
+	}
+	for _, offsets := range t.pending {
+		if len(offsets) > 0 {
+			return nil
+		}
+	}
+	t.ckpt.Complete = true
+	return t.ckpt.save(t.path)
+}
+
+// complete reports whether finish found the backfill complete.
+func (t *tracker) complete() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ckpt.Complete
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCheckpoint(t *testing.T) {
+	const source = "file://reports.ndjson"
+	saved := &checkpoint{Source: source, Offsets: map[int]int64{0: 5, 3: 7}, Complete: true}
+
+	tests := []struct {
+		name     string
+		existing *checkpoint // written before loading; nil for none
+		source   string
+		restart  bool
+		expected *checkpoint
+		errMsg   string
+	}{
+		{
+			name:     "no checkpoint starts fresh",
+			source:   source,
+			expected: &checkpoint{Source: source, Offsets: map[int]int64{}},
+		},
+		{
+			name:     "round trip resumes",
+			existing: saved,
+			source:   source,
+			expected: saved,
+		},
+		{
+			name:     "restart ignores the checkpoint",
+			existing: saved,
+			source:   source,
+			restart:  true,
+			expected: &checkpoint{Source: source, Offsets: map[int]int64{}},
+		},
+		{
+			name:     "restart ignores a checkpoint for another input",
+			existing: saved,
+			source:   "file://other.ndjson",
+			restart:  true,
+			expected: &checkpoint{Source: "file://other.ndjson", Offsets: map[int]int64{}},
+		},
+		{
+			name:     "another input is rejected",
+			existing: saved,
+			source:   "file://other.ndjson",
+			errMsg:   "pass -restart",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "backfill.checkpoint.json")
+			if tt.existing != nil {
+				c := *tt.existing
+				require.NoError(t, c.save(path))
+			}
+
+			c, err := loadCheckpoint(path, tt.source, tt.restart)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.Source, c.Source)
+			assert.Equal(t, tt.expected.Offsets, c.Offsets)
+			assert.Equal(t, tt.expected.Complete, c.Complete)
+		})
+	}
+}
+
+func TestTracker_CheckpointsInOrder(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "backfill.checkpoint.json")
+	ckpt := &checkpoint{Source: "test", Offsets: map[int]int64{}}
+	src := &sliceExtractor{batches: [][]domain.RawEvent{
+		{{Offset: 0}, {Offset: 1}},
+		{{Offset: 2}},
+	}}
+	tr := newTracker(src, ckpt, path, 2)
+
+	first, err := tr.ExtractBatch(ctx, 2)
+	require.NoError(t, err)
+	second, err := tr.ExtractBatch(ctx, 2)
+	require.NoError(t, err)
+	assert.NotEqual(t, first[0].Partition, second[0].Partition, "batches are spread across workers")
+	_, err = tr.ExtractBatch(ctx, 2)
+	require.ErrorIs(t, err, io.EOF)
+
+	// The later batch commits first; the checkpoint cannot pass the earlier one.
+	require.NoError(t, tr.CommitBatch(ctx, second))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "nothing is saved until the first batch commits")
+
+	require.NoError(t, tr.CommitBatch(ctx, first))
+	require.NoError(t, tr.finish())
+
+	loaded, err := loadCheckpoint(path, "test", false)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 3}, loaded.Offsets)
+	assert.True(t, loaded.Complete)
+}
+
+func TestInputOpen_FileStartsAtCheckpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		offsets map[int]int64
+		first   int64
+	}{
+		{name: "restart", offsets: map[int]int64{}, first: 0},
+		{name: "resume", offsets: map[int]int64{0: 1}, first: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "reports.ndjson")
+			require.NoError(t, os.WriteFile(path,
+				[]byte("{\"EventType\":\"hail\"}\n{\"EventType\":\"wind\"}\n{\"EventType\":\"tornado\"}\n"), 0o644))
+			// A sidecar left by the service reading the same file.
+			require.NoError(t, os.WriteFile(path+".offset", []byte("2\n"), 0o644))
+
+			in := input{file: path, baseDate: "2024-04-26", fromOffset: -1, toOffset: -1}
+			ckpt := &checkpoint{Source: in.String(), Offsets: tt.offsets}
+			src, err := in.open(context.Background(), &config.Config{}, ckpt, slog.Default())
+			require.NoError(t, err)
+			defer src.Close()
+
+			batch, err := src.ExtractBatch(context.Background(), 10)
+			require.NoError(t, err)
+			require.NotEmpty(t, batch)
+			assert.Equal(t, tt.first, batch[0].Offset)
+			assert.Len(t, batch, 3-int(tt.first))
+			assert.Equal(t, tt.first, src.resumed)
+		})
+	}
+}
+
+// sliceExtractor returns the given batches, then io.EOF.
+type sliceExtractor struct {
+	batches [][]domain.RawEvent
+}
+
+func (s *sliceExtractor) ExtractBatch(context.Context, int) ([]domain.RawEvent, error) {
+	if len(s.batches) == 0 {
+		return nil, io.EOF
+	}
+	batch := s.batches[0]
+	s.batches = s.batches[1:]
+	return batch, nil
+}
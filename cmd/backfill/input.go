@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	fileadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/file"
+	kafkaadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/spccsv"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// input is the bounded set of records to backfill, from the command line.
+type input struct {
+	spccsv string
+	file   string
+	topic  string
+
+	baseDate   string
+	fromOffset int64
+	toOffset   int64
+	since      string
+	until      string
+}
+
+func (in input) validate() error {
+	n := 0
+	for _, v := range []string{in.spccsv, in.file, in.topic} {
+		if v != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return errors.New("exactly one of -spccsv, -file, or -topic is required")
+	}
+	if in.topic == "" && (in.fromOffset >= 0 || in.toOffset >= 0 || in.since != "" || in.until != "") {
+		return errors.New("-from-offset, -to-offset, -since, and -until only apply to -topic")
+	}
+	if in.file == "" && in.baseDate != "" {
+		return errors.New("-base-date only applies to -file")
+	}
+	_, err := in.kafkaRange()
+	return err
+}
+
+// String identifies the input in the checkpoint, so a checkpoint is only
+// resumed by a run over the same records.
+func (in input) String() string {
+	switch {
+	case in.spccsv != "":
+		return "spccsv://" + in.spccsv
+	case in.file != "":
+		return "file://" + in.file
+	}
+	s := "kafka://" + in.topic
+	if in.fromOffset >= 0 || in.toOffset >= 0 {
+		s += fmt.Sprintf(" offsets %d..%d", in.fromOffset, in.toOffset)
+	}
+	if in.since != "" || in.until != "" {
+		s += fmt.Sprintf(" time %s..%s", in.since, in.until)
+	}
+	return s
+}
+
+func (in input) kafkaRange() (kafkaadapter.Range, error) {
+	r := kafkaadapter.Range{FromOffset: in.fromOffset, ToOffset: in.toOffset}
+	var err error
+	if in.since != "" {
+		if r.Since, err = time.Parse(time.RFC3339, in.since); err != nil {
+			return r, fmt.Errorf("invalid -since: %w", err)
+		}
+	}
+	if in.until != "" {
+		if r.Until, err = time.Parse(time.RFC3339, in.until); err != nil {
+			return r, fmt.Errorf("invalid -until: %w", err)
+		}
+	}
+	return r, r.Validate()
+}
+
+type extractor interface {
+	pipeline.BatchExtractor
+	io.Closer
+}
+
+// openedSource is an input opened and positioned at its checkpoint.
+type openedSource struct {
+	extractor
+	// singlePartition sources put every record in partition 0.
+	singlePartition bool
+	// total is the number of records left to read, or 0 if unknown.
+	total int64
+	// resumed is the number of records skipped because the checkpoint
+	// already covers them.
+	resumed int64
+}
+
+// open opens the input and skips the records ckpt already covers.
+func (in input) open(ctx context.Context, cfg *config.Config, ckpt *checkpoint, logger *slog.Logger) (*openedSource, error) {
+	switch {
+	case in.spccsv != "":
+		e, err := spccsv.Open(in.spccsv)
+		if err != nil {
+			return nil, err
+		}
+		total, err := e.CountRows()
+		if err == nil {
+			err = e.SkipTo(ckpt.Offsets[0])
+		}
+		if err != nil {
+			_ = e.Close()
+			return nil, err
+		}
+		resumed := min(ckpt.Offsets[0], total)
+		return &openedSource{extractor: e, singlePartition: true, total: total - resumed, resumed: resumed}, nil
+
+	case in.file != "":
+		baseDate, err := fileadapter.BaseDate(in.file, in.baseDate, "-base-date")
+		if err != nil {
+			return nil, err
+		}
+		// The backfill checkpoint is the only record of progress; a sidecar
+		// left by the service reading the same file must not move the start.
+		e, err := fileadapter.OpenExtractorAt(in.file, baseDate, ckpt.Offsets[0])
+		if err != nil {
+			return nil, err
+		}
+		return &openedSource{extractor: e, singlePartition: true, resumed: ckpt.Offsets[0]}, nil
+	}
+
+	r, err := in.kafkaRange()
+	if err != nil {
+		return nil, err
+	}
+	rr, err := kafkaadapter.OpenRange(ctx, cfg.KafkaBrokers, in.topic, r, logger)
+	if err != nil {
+		return nil, err
+	}
+	before := rr.Remaining()
+	for partition, offset := range ckpt.Offsets {
+		rr.SkipTo(partition, offset)
+	}
+	total := rr.Remaining()
+	return &openedSource{extractor: rr, total: total, resumed: before - total}, nil
+}
+
+type sink interface {
+	pipeline.BatchLoader
+	io.Closer
+}
+
+// openSink returns the loader selected by SINK or -out.
+func openSink(cfg *config.Config, logger *slog.Logger) (sink, error) {
+	if cfg.Sink.Scheme != "file" {
+		return kafkaadapter.NewWriter(cfg, logger), nil
+	}
+	return fileadapter.OpenLoader(cfg.Sink.Path)
+}
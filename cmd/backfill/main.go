@@ -0,0 +1,185 @@
+// Command backfill runs a bounded set of raw storm reports through the ETL
+// pipeline, for rebuilding the sink from archived data. The input is a
+// directory or glob of NOAA SPC report CSVs, a RawCSVRecord NDJSON or JSON
+// array file, or an offset or time range of a Kafka topic.
+//
+// Usage:
+//
+//	go run ./cmd/backfill -spccsv 'archive/*/*_rpts_*.csv' -workers 8
+//	go run ./cmd/backfill -file data/mock/storm_reports_240426_combined.json -out events.ndjson
+//	go run ./cmd/backfill -topic raw-weather-reports -since 2024-04-01T00:00:00Z -until 2024-05-01T00:00:00Z
+//
+// Progress is saved to -checkpoint as batches commit, and a rerun with the
+// same input resumes from it. Throughput and an ETA are printed to stderr
+// every -progress, and a summary of processed, skipped, and failed records,
+// with failures broken down by type, is printed when the run ends.
+//
+// Configuration (brokers, sink topic, enrichment, validation, dedup) comes from
+// the same environment variables as the service.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/dedupstore"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/geo"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	sharedcfg "github.com/couchcryptid/storm-data-shared/config"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "backfill: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var in input
+	flag.StringVar(&in.spccsv, "spccsv", "", "directory or glob of NOAA SPC report CSVs to backfill from")
+	flag.StringVar(&in.file, "file", "", "NDJSON or JSON array file of RawCSVRecord objects to backfill from")
+	flag.StringVar(&in.topic, "topic", "", "Kafka topic to backfill from")
+	flag.StringVar(&in.baseDate, "base-date", "", "event date (YYYY-MM-DD) for -file records with HHMM times (default: YYMMDD in the filename)")
+	flag.Int64Var(&in.fromOffset, "from-offset", -1, "first -topic offset to backfill, inclusive, in every partition")
+	flag.Int64Var(&in.toOffset, "to-offset", -1, "last -topic offset to backfill, inclusive, in every partition")
+	flag.StringVar(&in.since, "since", "", "only backfill -topic messages with a timestamp at or after this RFC 3339 time")
+	flag.StringVar(&in.until, "until", "", "only backfill -topic messages with a timestamp before this RFC 3339 time")
+	brokers := flag.String("brokers", "", "comma-separated Kafka brokers (default: KAFKA_BROKERS)")
+	sinkTopic := flag.String("sink-topic", "", "topic to write enriched events to (default: KAFKA_SINK_TOPIC)")
+	out := flag.String("out", "", "append enriched events to this NDJSON file instead of producing them")
+	workers := flag.Int("workers", 4, "number of batches processed in parallel")
+	batchSize := flag.Int("batch-size", 0, "records per batch (default: BATCH_SIZE)")
+	checkpointPath := flag.String("checkpoint", "backfill.checkpoint.json", "file recording progress, for resuming")
+	restart := flag.Bool("restart", false, "ignore an existing checkpoint and start from the beginning")
+	progressInterval := flag.Duration("progress", 10*time.Second, "how often to print progress")
+	flag.Parse()
+
+	if err := in.validate(); err != nil {
+		flag.Usage()
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if *brokers != "" {
+		cfg.KafkaBrokers = sharedcfg.ParseBrokers(*brokers)
+	}
+	if *sinkTopic != "" {
+		cfg.KafkaSinkTopic = *sinkTopic
+	}
+	if *batchSize > 0 {
+		cfg.BatchSize = *batchSize
+	}
+	if *out != "" {
+		cfg.Sink = config.Endpoint{Scheme: "file", Path: *out}
+	}
+
+	ckpt, err := loadCheckpoint(*checkpointPath, in.String(), *restart)
+	if err != nil {
+		return err
+	}
+	if ckpt.Complete {
+		fmt.Fprintf(os.Stderr, "backfill: %s already complete according to %s; pass -restart to run it again\n", ckpt.Source, *checkpointPath)
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	src, err := in.open(ctx, cfg, ckpt, logger)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	snk, err := openSink(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer snk.Close()
+
+	deps, err := geo.LoadEnricherDeps(cfg)
+	if err != nil {
+		return err
+	}
+	enrichers, err := domain.Enrichers(cfg.EnrichmentSteps, deps)
+	if err != nil {
+		return err
+	}
+	metrics := observability.NewMetrics()
+	transformer := pipeline.NewTransformer(logger,
+		pipeline.WithEnrichers(enrichers),
+		pipeline.WithTransformMetrics(metrics),
+		pipeline.WithValidationMode(pipeline.ValidationMode(cfg.ValidationMode)),
+	)
+
+	spread := 1
+	if src.singlePartition {
+		spread = *workers
+	}
+	t := newTracker(src.extractor, ckpt, *checkpointPath, spread)
+	loader := &countingLoader{BatchLoader: snk}
+	failures := newFailureTally()
+
+	opts := []pipeline.Option{
+		pipeline.WithWorkers(*workers),
+		pipeline.WithLoadAttempts(cfg.LoadMaxAttempts),
+		pipeline.WithDeadLetter(failures),
+	}
+	seen, err := dedupstore.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer seen.Close()
+	opts = append(opts, pipeline.WithDedup(seen, pipeline.DedupMode(cfg.DedupMode)))
+	p := pipeline.New(t, transformer, loader, logger, metrics, cfg.BatchSize, opts...)
+
+	progressCtx, stopProgress := context.WithCancel(ctx)
+	go progress(progressCtx, os.Stderr, *progressInterval, t, src.total)
+
+	start := time.Now()
+	runErr := p.Run(ctx)
+	stopProgress()
+	if err := t.finish(); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+
+	failures.mu.Lock()
+	s := summary{
+		source:     ckpt.Source,
+		elapsed:    time.Since(start),
+		resumed:    src.resumed,
+		read:       t.read.Load(),
+		loaded:     loader.loaded.Load(),
+		failed:     failures.total,
+		failures:   failures.byType,
+		examples:   failures.examples,
+		checkpoint: *checkpointPath,
+		complete:   t.complete(),
+	}
+	failures.mu.Unlock()
+	s.print(os.Stderr)
+
+	if runErr != nil {
+		return runErr
+	}
+	if !s.complete {
+		return errors.New("interrupted before the input was exhausted")
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// failureTally counts events the pipeline gave up on, by error type. It is
+// the pipeline's dead-letter loader, so failed events are recorded and their
+// offsets committed rather than retried forever.
+// It implements pipeline.DeadLetterLoader.
+type failureTally struct {
+	mu       sync.Mutex
+	total    int64
+	byType   map[string]int64
+	examples map[string]string
+}
+
+func newFailureTally() *failureTally {
+	return &failureTally{byType: map[string]int64{}, examples: map[string]string{}}
+}
+
+func (f *failureTally) LoadDeadLetter(_ context.Context, _ domain.RawEvent, cause error) error {
+	kind := errorType(cause)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.total++
+	f.byType[kind]++
+	if _, ok := f.examples[kind]; !ok {
+		f.examples[kind] = cause.Error()
+	}
+	return nil
+}
+
+// errorType groups failures: validation failures by the fields that failed,
+// anything else by the stage prefix of its message, such as "load",
+// "parse raw event", or "enrich geocode".
+func errorType(err error) string {
+	var verrs domain.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make([]string, len(verrs))
+		for i, ve := range verrs {
+			fields[i] = ve.Field
+		}
+		sort.Strings(fields)
+		return "invalid " + strings.Join(fields, ", ")
+	}
+	if pipeline.IsPermanent(err) {
+		return "load (permanent)"
+	}
+	if kind, _, ok := strings.Cut(err.Error(), ": "); ok {
+		return kind
+	}
+	return err.Error()
+}
+
+// countingLoader counts the events its loader wrote.
+// It implements pipeline.BatchLoader.
+type countingLoader struct {
+	pipeline.BatchLoader
+	loaded atomic.Int64
+}
+
+func (c *countingLoader) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	err := c.BatchLoader.LoadBatch(ctx, events)
+	if err == nil {
+		c.loaded.Add(int64(len(events)))
+		return nil
+	}
+	var ble *pipeline.BatchLoadError
+	if errors.As(err, &ble) {
+		for _, e := range ble.Errs {
+			if e == nil {
+				c.loaded.Add(1)
+			}
+		}
+	}
+	return err
+}
+
+// progress prints throughput and, when the total is known, an ETA every
+// interval until ctx is cancelled.
+func progress(ctx context.Context, w io.Writer, interval time.Duration, t *tracker, total int64) {
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			done := t.done.Load()
+			elapsed := time.Since(start)
+			rate := float64(done) / elapsed.Seconds()
+			line := fmt.Sprintf("backfill: %d done, %.0f/s", done, rate)
+			if total > 0 {
+				line = fmt.Sprintf("backfill: %d/%d (%.1f%%), %.0f/s", done, total, 100*float64(done)/float64(total), rate)
+				if rate > 0 && done < total {
+					eta := time.Duration(float64(total-done) / rate * float64(time.Second))
+					line += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+				}
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// summary is the outcome of a backfill run.
+type summary struct {
+	source     string
+	elapsed    time.Duration
+	resumed    int64 // source records already done by earlier runs
+	read       int64
+	loaded     int64
+	failed     int64
+	failures   map[string]int64
+	examples   map[string]string
+	checkpoint string
+	complete   bool
+}
+
+func (s summary) print(w io.Writer) {
+	// Everything read was loaded, failed, or dropped as a duplicate, unless
+	// the run was interrupted with batches in flight.
+	skipped := max(s.read-s.loaded-s.failed, 0)
+
+	fmt.Fprintf(w, "\n=== Backfill summary ===\n")
+	fmt.Fprintf(w, "Source:      %s\n", s.source)
+	fmt.Fprintf(w, "Elapsed:     %s", s.elapsed.Round(time.Millisecond))
+	if secs := s.elapsed.Seconds(); secs > 0 {
+		fmt.Fprintf(w, " (%.0f/s)", float64(s.read)/secs)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Read:        %d\n", s.read)
+	fmt.Fprintf(w, "Processed:   %d\n", s.loaded)
+	fmt.Fprintf(w, "Skipped:     %d", skipped+s.resumed)
+	if s.resumed > 0 {
+		fmt.Fprintf(w, " (%d done by an earlier run)", s.resumed)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Failed:      %d\n", s.failed)
+	state := "incomplete, rerun to resume"
+	if s.complete {
+		state = "complete"
+	}
+	fmt.Fprintf(w, "Checkpoint:  %s (%s)\n", s.checkpoint, state)
+
+	if len(s.failures) == 0 {
+		return
+	}
+	kinds := make([]string, 0, len(s.failures))
+	for k := range s.failures {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if s.failures[kinds[i]] != s.failures[kinds[j]] {
+			return s.failures[kinds[i]] > s.failures[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	fmt.Fprintf(w, "\nFailures by type:\n")
+	for _, k := range kinds {
+		fmt.Fprintf(w, "  %6d  %s\n", s.failures[k], k)
+		fmt.Fprintf(w, "          e.g. %s\n", s.examples[k])
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+
+	fileadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/file"
+	kafkaadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/spccsv"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+type source interface {
+	pipeline.BatchExtractor
+	io.Closer
+}
+
+type sink interface {
+	pipeline.BatchLoader
+	io.Closer
+}
+
+// openSource returns the extractor selected by SOURCE. With
+// DELIVERY_GUARANTEE=exactly-once a Kafka source tracks its group generation
+// for the transactional sink.
+func openSource(cfg *config.Config, logger *slog.Logger) (source, error) {
+	switch {
+	case cfg.Source.Scheme == "file":
+		baseDate, err := fileadapter.BaseDate(cfg.Source.Path, cfg.Source.Params.Get("date"), "SOURCE ?date=YYYY-MM-DD")
+		if err != nil {
+			return nil, err
+		}
+		return fileadapter.OpenExtractor(cfg.Source.Path, baseDate)
+	case cfg.Source.Scheme == "spccsv":
+		return spccsv.Open(cfg.Source.Path)
+	case cfg.DeliveryGuarantee == "exactly-once":
+		return kafkaadapter.NewGroupReader(cfg, logger)
+	default:
+		return kafkaadapter.NewReader(cfg, logger), nil
+	}
+}
+
+// openSink returns the loader selected by SINK, transactional with
+// DELIVERY_GUARANTEE=exactly-once.
+func openSink(cfg *config.Config, logger *slog.Logger) (sink, error) {
+	switch {
+	case cfg.Sink.Scheme == "file":
+		return fileadapter.OpenLoader(cfg.Sink.Path)
+	case cfg.DeliveryGuarantee == "exactly-once":
+		return kafkaadapter.NewTransactionalWriter(cfg, logger), nil
+	default:
+		return kafkaadapter.NewWriter(cfg, logger), nil
+	}
+}
+
+// statsReporters returns the Kafka clients among the given adapters.
+func statsReporters(adapters ...any) []kafkaadapter.StatsReporter {
+	var reporters []kafkaadapter.StatsReporter
+	for _, a := range adapters {
+		if r, ok := a.(kafkaadapter.StatsReporter); ok {
+			reporters = append(reporters, r)
+		}
+	}
+	return reporters
+}
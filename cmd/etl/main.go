@@ -8,14 +8,21 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/dedupstore"
 	"github.com/couchcryptid/storm-data-etl/internal/adapter/httpadapter"
 	kafkaadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
 	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/geo"
 	"github.com/couchcryptid/storm-data-etl/internal/observability"
 	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
 )
 
+// kafkaStatsInterval is how often kafka-go client statistics are exported.
+const kafkaStatsInterval = 15 * time.Second
+
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
@@ -25,14 +32,65 @@ func main() {
 
 	logger := observability.NewLogger(cfg)
 	metrics := observability.NewMetrics()
+	shutdownTracing, err := observability.SetupTracing(context.Background(), cfg)
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	reader, err := openSource(cfg, logger)
+	if err != nil {
+		logger.Error("failed to open source", "source", cfg.Source.String(), "error", err)
+		os.Exit(1)
+	}
+	writer, err := openSink(cfg, logger)
+	if err != nil {
+		logger.Error("failed to open sink", "sink", cfg.Sink.String(), "error", err)
+		os.Exit(1)
+	}
+	deps, err := geo.LoadEnricherDeps(cfg)
+	if err != nil {
+		logger.Error("failed to load enrichment data", "error", err)
+		os.Exit(1)
+	}
+	enrichers, err := domain.Enrichers(cfg.EnrichmentSteps, deps)
+	if err != nil {
+		logger.Error("invalid enrichment steps", "error", err)
+		os.Exit(1)
+	}
+	transformer := pipeline.NewTransformer(logger,
+		pipeline.WithEnrichers(enrichers),
+		pipeline.WithTransformMetrics(metrics),
+		pipeline.WithValidationMode(pipeline.ValidationMode(cfg.ValidationMode)),
+	)
 
-	reader := kafkaadapter.NewReader(cfg, logger)
-	writer := kafkaadapter.NewWriter(cfg, logger)
-	transformer := pipeline.NewTransformer(logger)
+	opts := []pipeline.Option{
+		pipeline.WithWorkers(cfg.PipelineWorkers),
+		pipeline.WithLoadAttempts(cfg.LoadMaxAttempts),
+		pipeline.WithLoadBreaker(cfg.LoadBreakerThreshold, cfg.LoadBreakerOpenDuration),
+		pipeline.WithReadiness(cfg.ReadinessStaleAfter, cfg.ReadinessMaxLag),
+	}
+	if tw, ok := writer.(pipeline.TransactionalLoader); ok {
+		opts = append(opts, pipeline.WithTransactions(tw))
+	}
+	var dlqWriter *kafkaadapter.DeadLetterWriter
+	if cfg.KafkaDLQTopic != "" {
+		dlqWriter = kafkaadapter.NewDeadLetterWriter(cfg, logger)
+		opts = append(opts, pipeline.WithDeadLetter(dlqWriter))
+	}
+	// The seen store is always open: it numbers revisions even with
+	// DEDUP_MODE=off, which only stops repeats from being dropped or tagged.
+	seen, err := dedupstore.Open(cfg)
+	if err != nil {
+		logger.Error("failed to open dedup store", "error", err)
+		os.Exit(1)
+	}
+	opts = append(opts, pipeline.WithDedup(seen, pipeline.DedupMode(cfg.DedupMode)))
 
-	p := pipeline.New(reader, transformer, writer, logger, metrics, cfg.BatchSize)
+	p := pipeline.New(reader, transformer, writer, logger, metrics, cfg.BatchSize, opts...)
 
-	srv := httpadapter.NewServer(cfg.HTTPAddr, p, logger)
+	srv := httpadapter.NewServer(cfg.HTTPAddr, p, logger,
+		httpadapter.WithAdmin(cfg.AdminToken, p, cfg.Snapshot()))
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -44,27 +102,57 @@ func main() {
 		}
 	}()
 
-	// Start ETL pipeline.
+	if reporters := statsReporters(reader, writer); len(reporters) > 0 {
+		go kafkaadapter.ExportStats(ctx, kafkaStatsInterval, metrics, reporters...)
+	}
+
+	// Start ETL pipeline. A file source ends the run once it is exhausted.
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		if err := p.Run(ctx); err != nil {
 			logger.Error("pipeline error", "error", err)
 		}
 	}()
 
-	<-ctx.Done()
+	select {
+	case <-ctx.Done():
+	case <-done:
+	}
 	logger.Info("shutting down")
+	stop()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
+	// Let the pipeline finish its in-flight batches before closing anything it
+	// still uses.
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		logger.Error("pipeline did not stop before the shutdown timeout", "timeout", cfg.ShutdownTimeout)
+	}
+
+	// Close in reverse order of creation.
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("http server shutdown error", "error", err)
 	}
-	if err := reader.Close(); err != nil {
-		logger.Error("kafka reader close error", "error", err)
+	if err := seen.Close(); err != nil {
+		logger.Error("dedup store close error", "error", err)
+	}
+	if dlqWriter != nil {
+		if err := dlqWriter.Close(); err != nil {
+			logger.Error("kafka dead-letter writer close error", "error", err)
+		}
 	}
 	if err := writer.Close(); err != nil {
-		logger.Error("kafka writer close error", "error", err)
+		logger.Error("sink close error", "error", err)
+	}
+	if err := reader.Close(); err != nil {
+		logger.Error("source close error", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("tracing shutdown error", "error", err)
 	}
 
 	logger.Info("shutdown complete")
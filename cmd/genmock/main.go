@@ -2,6 +2,13 @@
 // for both the ETL and API test suites. It uses the actual ETL domain package
 // to ensure the transformed output matches real pipeline behavior.
 //
+// CSVs are read with internal/adapter/spccsv, the parser behind
+// SOURCE=spccsv://. Rows shorter than the header are kept with the missing
+// columns empty (the previous reader rejected the whole file), header names
+// are trimmed as well as values, and each event is timestamped with the report
+// date from its filename. For well-formed files such as the 240426 set the
+// fixtures are unchanged.
+//
 // Usage:
 //
 //	go run ./cmd/genmock \
@@ -11,7 +18,6 @@
 package main
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -22,18 +28,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/spccsv"
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
 	"github.com/jonboulle/clockwork"
 )
 
-var baseDate = time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
-
-type csvDef struct {
-	file      string
-	eventType string
-	magCol    string // column name for magnitude (Size, F_Scale, Speed)
-}
-
 func main() {
 	if err := run(); err != nil {
 		log.Fatal(err)
@@ -51,11 +50,7 @@ func run() error {
 		return fmt.Errorf("missing required flags: -csv-dir, -etl-out, -api-out")
 	}
 
-	defs := []csvDef{
-		{file: "240426_rpts_hail.csv", eventType: "hail", magCol: "Size"},
-		{file: "240426_rpts_torn.csv", eventType: "tornado", magCol: "F_Scale"},
-		{file: "240426_rpts_wind.csv", eventType: "wind", magCol: "Speed"},
-	}
+	files := []string{"240426_rpts_hail.csv", "240426_rpts_torn.csv", "240426_rpts_wind.csv"}
 
 	// Set a fixed clock for reproducible ProcessedAt timestamps.
 	domain.SetClock(clockwork.NewFakeClockAt(
@@ -66,15 +61,14 @@ func run() error {
 	var rawRecords []domain.RawCSVRecord //nolint:prealloc // size depends on CSV file contents
 	var transformed []domain.StormEvent  //nolint:prealloc // size depends on CSV file contents
 
-	for _, d := range defs {
-		path := filepath.Join(*csvDir, d.file)
-		recs, events, err := processCSV(path, d.eventType, d.magCol)
+	for _, file := range files {
+		recs, events, err := processCSV(filepath.Join(*csvDir, file))
 		if err != nil {
-			return fmt.Errorf("processing %s: %w", d.file, err)
+			return fmt.Errorf("processing %s: %w", file, err)
 		}
 		rawRecords = append(rawRecords, recs...)
 		transformed = append(transformed, events...)
-		log.Printf("%s: %d records", d.eventType, len(recs))
+		log.Printf("%s: %d records", file, len(recs))
 	}
 
 	log.Printf("total: %d records", len(rawRecords))
@@ -93,62 +87,23 @@ func run() error {
 	return nil
 }
 
-func processCSV(path, eventType, magCol string) ([]domain.RawCSVRecord, []domain.StormEvent, error) {
-	f, err := os.Open(path)
+// processCSV reads an SPC report CSV and runs each record through the actual
+// ETL transformation.
+func processCSV(path string) ([]domain.RawCSVRecord, []domain.StormEvent, error) {
+	info, err := spccsv.ParseFilename(path)
 	if err != nil {
-		return nil, nil, fmt.Errorf("open: %w", err)
+		return nil, nil, err
 	}
-	defer f.Close()
-
-	reader := csv.NewReader(f)
-	rows, err := reader.ReadAll()
+	recs, err := spccsv.ReadFile(path)
 	if err != nil {
-		return nil, nil, fmt.Errorf("read csv: %w", err)
+		return nil, nil, err
 	}
-
-	if len(rows) < 2 {
+	if len(recs) == 0 {
 		return nil, nil, fmt.Errorf("no data rows")
 	}
 
-	header := rows[0]
-	colIdx := map[string]int{}
-	for i, h := range header {
-		colIdx[h] = i
-	}
-
-	var recs []domain.RawCSVRecord
-	var events []domain.StormEvent
-
-	for _, row := range rows[1:] {
-		if len(row) < len(header) {
-			continue
-		}
-
-		rec := domain.RawCSVRecord{
-			Time:      get(row, colIdx, "Time"),
-			Location:  get(row, colIdx, "Location"),
-			County:    get(row, colIdx, "County"),
-			State:     get(row, colIdx, "State"),
-			Lat:       get(row, colIdx, "Lat"),
-			Lon:       get(row, colIdx, "Lon"),
-			Comments:  get(row, colIdx, "Comments"),
-			EventType: eventType,
-		}
-
-		// Set the type-specific magnitude field.
-		mag := get(row, colIdx, magCol)
-		switch eventType {
-		case "hail":
-			rec.Size = mag
-		case "tornado":
-			rec.FScale = mag
-		case "wind":
-			rec.Speed = mag
-		}
-
-		recs = append(recs, rec)
-
-		// Run the actual ETL transformation.
+	events := make([]domain.StormEvent, 0, len(recs))
+	for _, rec := range recs {
 		rawJSON, err := json.Marshal(rec)
 		if err != nil {
 			return nil, nil, fmt.Errorf("marshal record: %w", err)
@@ -156,7 +111,7 @@ func processCSV(path, eventType, magCol string) ([]domain.RawCSVRecord, []domain
 
 		rawEvent := domain.RawEvent{
 			Value:     rawJSON,
-			Timestamp: baseDate,
+			Timestamp: info.Date,
 		}
 
 		parsed, err := domain.ParseRawEvent(rawEvent)
@@ -171,14 +126,6 @@ func processCSV(path, eventType, magCol string) ([]domain.RawCSVRecord, []domain
 	return recs, events, nil
 }
 
-func get(row []string, idx map[string]int, col string) string {
-	i, ok := idx[col]
-	if !ok || i >= len(row) {
-		return ""
-	}
-	return strings.TrimSpace(row[i])
-}
-
 func writeJSON(path string, v any) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
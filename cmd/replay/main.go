@@ -0,0 +1,250 @@
+// Command replay re-drives raw storm reports through the ETL transform and
+// writes the results to the sink topic. It reads either a Kafka topic (such as
+// the dead-letter topic) or a JSON file in the RawCSVRecord array format used
+// by data/mock, optionally filtered by header, offset range, or time range.
+//
+// Usage:
+//
+//	go run ./cmd/replay -topic raw-weather-reports-dlq -header dlq_source_topic=raw-weather-reports
+//	go run ./cmd/replay -file data/mock/storm_reports_240426_combined.json -dry-run
+//
+// Brokers and the sink topic default to KAFKA_BROKERS and KAFKA_SINK_TOPIC.
+// With -dry-run, enriched events are printed to stdout as JSON lines and
+// nothing is produced.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	fileadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/file"
+	kafkaadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/geo"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	sharedcfg "github.com/couchcryptid/storm-data-shared/config"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var headers headerFilter
+	topic := flag.String("topic", "", "Kafka topic to replay from (e.g. the dead-letter topic)")
+	file := flag.String("file", "", "JSON file of RawCSVRecord objects to replay from")
+	brokers := flag.String("brokers", "", "comma-separated Kafka brokers (default: KAFKA_BROKERS)")
+	sinkTopic := flag.String("sink-topic", "", "topic to write enriched events to (default: KAFKA_SINK_TOPIC)")
+	baseDateStr := flag.String("base-date", "", "event date (YYYY-MM-DD) for -file records with HHMM times (default: YYMMDD in the filename)")
+	fromOffset := flag.Int64("from-offset", -1, "first offset to replay, inclusive")
+	toOffset := flag.Int64("to-offset", -1, "last offset to replay, inclusive")
+	sinceStr := flag.String("since", "", "only replay messages with a timestamp at or after this RFC 3339 time")
+	untilStr := flag.String("until", "", "only replay messages with a timestamp before this RFC 3339 time")
+	idle := flag.Duration("idle-timeout", 10*time.Second, "stop reading a partition after waiting this long for the next message")
+	dryRun := flag.Bool("dry-run", false, "print enriched events instead of producing them")
+	flag.Var(&headers, "header", "only replay messages with header key=value (or key present); repeatable")
+	flag.Parse()
+
+	if (*topic == "") == (*file == "") {
+		flag.Usage()
+		return errors.New("exactly one of -topic or -file is required")
+	}
+
+	f, err := newFilter(headers, *fromOffset, *toOffset, *sinceStr, *untilStr)
+	if err != nil {
+		return err
+	}
+	var baseDate time.Time
+	if *file != "" {
+		if baseDate, err = fileadapter.BaseDate(*file, *baseDateStr, "-base-date"); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if *brokers != "" {
+		cfg.KafkaBrokers = sharedcfg.ParseBrokers(*brokers)
+	}
+	if *sinkTopic != "" {
+		cfg.KafkaSinkTopic = *sinkTopic
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	var loader pipeline.BatchLoader
+	if *dryRun {
+		loader = &printLoader{w: os.Stdout}
+	} else {
+		writer := kafkaadapter.NewWriter(cfg, logger)
+		defer writer.Close()
+		loader = writer
+	}
+
+	deps, err := geo.LoadEnricherDeps(cfg)
+	if err != nil {
+		return err
+	}
+	enrichers, err := domain.Enrichers(cfg.EnrichmentSteps, deps)
+	if err != nil {
+		return err
+	}
+	transformer := pipeline.NewTransformer(logger,
+		pipeline.WithEnrichers(enrichers),
+		pipeline.WithValidationMode(pipeline.ValidationMode(cfg.ValidationMode)),
+	)
+
+	rp := newReplayer(ctx, f, transformer, loader, cfg.BatchSize)
+	if *file != "" {
+		err = readFile(*file, baseDate, rp.add)
+	} else {
+		err = readTopic(ctx, cfg.KafkaBrokers, *topic, f, *idle, rp.add)
+	}
+	s := rp.finish()
+	s.print(os.Stderr, *dryRun)
+	if s.loadErr != nil {
+		return s.loadErr
+	}
+	return err
+}
+
+// summary tallies the outcome of a replay run.
+type summary struct {
+	read      int
+	filtered  int
+	succeeded int
+	failed    int
+	written   int
+	failures  map[string]int
+	loadErr   error
+}
+
+// replayer transforms matching raw events as they are read and loads them in
+// batches, so a replay never holds more than one batch in memory. Transform
+// failures are counted and reported rather than aborting the run.
+type replayer struct {
+	ctx       context.Context
+	f         *filter
+	t         pipeline.Transformer
+	l         pipeline.BatchLoader
+	batchSize int
+	batch     []domain.StormEvent
+	s         summary
+}
+
+func newReplayer(ctx context.Context, f *filter, t pipeline.Transformer, l pipeline.BatchLoader, batchSize int) *replayer {
+	return &replayer{
+		ctx:       ctx,
+		f:         f,
+		t:         t,
+		l:         l,
+		batchSize: batchSize,
+		batch:     make([]domain.StormEvent, 0, batchSize),
+		s:         summary{failures: map[string]int{}},
+	}
+}
+
+// add replays one raw event. It returns the load error once a batch fails to
+// load, which stops the read.
+func (r *replayer) add(raw domain.RawEvent) error {
+	if r.s.loadErr != nil {
+		return r.s.loadErr
+	}
+	r.s.read++
+	if !r.f.match(raw) {
+		r.s.filtered++
+		return nil
+	}
+	event, err := r.t.Transform(r.ctx, raw)
+	if err != nil {
+		r.s.failed++
+		r.s.failures[err.Error()]++
+		return nil
+	}
+	r.s.succeeded++
+	r.batch = append(r.batch, event)
+	if len(r.batch) == r.batchSize {
+		r.flush()
+	}
+	return r.s.loadErr
+}
+
+// finish loads the last partial batch and returns the tally.
+func (r *replayer) finish() summary {
+	if r.s.loadErr == nil {
+		r.flush()
+	}
+	return r.s
+}
+
+func (r *replayer) flush() {
+	if len(r.batch) == 0 {
+		return
+	}
+	if err := r.l.LoadBatch(r.ctx, r.batch); err != nil {
+		r.s.loadErr = fmt.Errorf("load batch: %w", err)
+		return
+	}
+	r.s.written += len(r.batch)
+	r.batch = r.batch[:0]
+}
+
+func (s summary) print(w io.Writer, dryRun bool) {
+	fmt.Fprintf(w, "\n=== Replay summary ===\n")
+	fmt.Fprintf(w, "Read:        %d\n", s.read)
+	fmt.Fprintf(w, "Filtered:    %d\n", s.filtered)
+	fmt.Fprintf(w, "Transformed: %d\n", s.succeeded)
+	fmt.Fprintf(w, "Failed:      %d\n", s.failed)
+	if dryRun {
+		fmt.Fprintf(w, "Printed:     %d (dry run, nothing produced)\n", s.written)
+	} else {
+		fmt.Fprintf(w, "Written:     %d\n", s.written)
+	}
+
+	if len(s.failures) == 0 {
+		return
+	}
+	reasons := make([]string, 0, len(s.failures))
+	for r := range s.failures {
+		reasons = append(reasons, r)
+	}
+	sort.Slice(reasons, func(i, j int) bool { return s.failures[reasons[i]] > s.failures[reasons[j]] })
+	fmt.Fprintf(w, "\nFailures by reason:\n")
+	for _, r := range reasons {
+		fmt.Fprintf(w, "  %4d  %s\n", s.failures[r], r)
+	}
+}
+
+// printLoader writes enriched events to w as JSON lines. Used by -dry-run.
+type printLoader struct {
+	w io.Writer
+}
+
+func (p *printLoader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	enc := json.NewEncoder(p.w)
+	for i := range events {
+		if err := enc.Encode(events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// offsetTransformer fails on odd offsets and otherwise returns an event whose
+// ID is the raw value.
+type offsetTransformer struct{}
+
+func (offsetTransformer) Transform(_ context.Context, raw domain.RawEvent) (domain.StormEvent, error) {
+	if raw.Offset%2 == 1 {
+		return domain.StormEvent{}, errors.New("odd offset")
+	}
+	return domain.StormEvent{ID: string(raw.Value)}, nil
+}
+
+// recordingLoader records each batch; the load numbered failOn fails.
+type recordingLoader struct {
+	batches [][]string
+	failOn  int
+}
+
+func (l *recordingLoader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	if len(l.batches)+1 == l.failOn {
+		return errors.New("broker unavailable")
+	}
+	ids := make([]string, len(events))
+	for i := range events {
+		ids[i] = events[i].ID
+	}
+	l.batches = append(l.batches, ids)
+	return nil
+}
+
+func TestReplayer_LoadsBatchesAsTheyFill(t *testing.T) {
+	f, err := newFilter(nil, -1, 7, "", "")
+	require.NoError(t, err)
+	l := &recordingLoader{}
+	rp := newReplayer(context.Background(), f, offsetTransformer{}, l, 2)
+
+	for i := range int64(10) {
+		require.NoError(t, rp.add(domain.RawEvent{Offset: i, Value: []byte{byte('a' + i)}}))
+		if i == 2 {
+			assert.Equal(t, [][]string{{"a", "c"}}, l.batches, "a full batch is loaded before the read continues")
+		}
+	}
+	s := rp.finish()
+
+	assert.Equal(t, [][]string{{"a", "c"}, {"e", "g"}}, l.batches)
+	assert.Equal(t, 10, s.read)
+	assert.Equal(t, 2, s.filtered)
+	assert.Equal(t, 4, s.succeeded)
+	assert.Equal(t, 4, s.failed)
+	assert.Equal(t, map[string]int{"odd offset": 4}, s.failures)
+	assert.Equal(t, 4, s.written)
+	assert.NoError(t, s.loadErr)
+}
+
+func TestReplayer_LoadFailureStopsTheRead(t *testing.T) {
+	f, err := newFilter(nil, -1, -1, "", "")
+	require.NoError(t, err)
+	l := &recordingLoader{failOn: 2}
+	rp := newReplayer(context.Background(), f, offsetTransformer{}, l, 1)
+
+	require.NoError(t, rp.add(domain.RawEvent{Offset: 0, Value: []byte("a")}))
+	require.Error(t, rp.add(domain.RawEvent{Offset: 2, Value: []byte("c")}))
+	require.Error(t, rp.add(domain.RawEvent{Offset: 4, Value: []byte("e")}), "nothing more is replayed")
+	s := rp.finish()
+
+	assert.Equal(t, [][]string{{"a"}}, l.batches)
+	assert.Equal(t, 2, s.read)
+	assert.Equal(t, 1, s.written)
+	require.Error(t, s.loadErr)
+	assert.Contains(t, s.loadErr.Error(), "broker unavailable")
+}
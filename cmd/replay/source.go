@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// headerFilter collects repeated -header flags.
+type headerFilter []string
+
+func (h *headerFilter) String() string { return strings.Join(*h, ",") }
+
+func (h *headerFilter) Set(v string) error {
+	if strings.TrimSpace(v) == "" {
+		return fmt.Errorf("empty -header value")
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+// filter selects which raw events are replayed.
+type filter struct {
+	headers    map[string]*string // nil value means "header present"
+	fromOffset int64
+	toOffset   int64
+	since      time.Time
+	until      time.Time
+}
+
+func newFilter(headers headerFilter, fromOffset, toOffset int64, since, until string) (*filter, error) {
+	f := &filter{headers: map[string]*string{}, fromOffset: fromOffset, toOffset: toOffset}
+	for _, h := range headers {
+		key, value, ok := strings.Cut(h, "=")
+		if !ok {
+			f.headers[key] = nil
+			continue
+		}
+		f.headers[key] = &value
+	}
+	if fromOffset >= 0 && toOffset >= 0 && toOffset < fromOffset {
+		return nil, fmt.Errorf("-to-offset %d is before -from-offset %d", toOffset, fromOffset)
+	}
+
+	var err error
+	if since != "" {
+		if f.since, err = time.Parse(time.RFC3339, since); err != nil {
+			return nil, fmt.Errorf("invalid -since: %w", err)
+		}
+	}
+	if until != "" {
+		if f.until, err = time.Parse(time.RFC3339, until); err != nil {
+			return nil, fmt.Errorf("invalid -until: %w", err)
+		}
+	}
+	return f, nil
+}
+
+func (f *filter) match(raw domain.RawEvent) bool {
+	if f.fromOffset >= 0 && raw.Offset < f.fromOffset {
+		return false
+	}
+	if f.toOffset >= 0 && raw.Offset > f.toOffset {
+		return false
+	}
+	if !f.since.IsZero() && raw.Timestamp.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !raw.Timestamp.Before(f.until) {
+		return false
+	}
+	for key, want := range f.headers {
+		got, ok := raw.Headers[key]
+		if !ok || (want != nil && got != *want) {
+			return false
+		}
+	}
+	return true
+}
+
+// readFile streams a JSON array of RawCSVRecord objects to emit. Each element
+// becomes a RawEvent whose offset is its array index and whose timestamp is
+// baseDate, mirroring what the collector publishes.
+func readFile(path string, baseDate time.Time, emit func(domain.RawEvent) error) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	dec := json.NewDecoder(fh)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return fmt.Errorf("parse %s: expected a JSON array", path)
+	}
+	for offset := int64(0); dec.More(); offset++ {
+		var rec json.RawMessage
+		if err := dec.Decode(&rec); err != nil {
+			return fmt.Errorf("parse %s: record %d: %w", path, offset, err)
+		}
+		err := emit(domain.RawEvent{
+			Value:     rec,
+			Topic:     filepath.Base(path),
+			Offset:    offset,
+			Timestamp: baseDate,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTopic streams every partition of topic to emit, from the start of the
+// requested offset range up to the high watermark observed when each partition
+// is opened. The offset bounds in f are pushed down to the fetch so large
+// topics are not scanned unnecessarily; all other filtering happens in replay.
+// A partition is also considered drained once no message arrives for idle,
+// since the tail of the range may hold only transaction markers.
+func readTopic(ctx context.Context, brokers []string, topic string, f *filter, idle time.Duration, emit func(domain.RawEvent) error) error {
+	if len(brokers) == 0 {
+		return fmt.Errorf("no Kafka brokers configured")
+	}
+	conn, err := kafkago.DialContext(ctx, "tcp", brokers[0])
+	if err != nil {
+		return fmt.Errorf("dial kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(topic)
+	_ = conn.Close()
+	if err != nil {
+		return fmt.Errorf("read partitions for %s: %w", topic, err)
+	}
+
+	for _, p := range partitions {
+		if err := readPartition(ctx, brokers, topic, p.ID, f, idle, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readPartition(ctx context.Context, brokers []string, topic string, partition int, f *filter, idle time.Duration, emit func(domain.RawEvent) error) error {
+	leader, err := kafkago.DialLeader(ctx, "tcp", brokers[0], topic, partition)
+	if err != nil {
+		return fmt.Errorf("dial leader for %s/%d: %w", topic, partition, err)
+	}
+	first, last, err := leader.ReadOffsets()
+	_ = leader.Close()
+	if err != nil {
+		return fmt.Errorf("read offsets for %s/%d: %w", topic, partition, err)
+	}
+
+	start, end := offsetRange(first, last, f)
+	if start >= end {
+		return nil
+	}
+
+	r := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:   brokers,
+		Topic:     topic,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10e6, // 10 MB
+	})
+	defer r.Close()
+	if err := r.SetOffset(start); err != nil {
+		return fmt.Errorf("seek %s/%d to %d: %w", topic, partition, start, err)
+	}
+	if err := drain(ctx, r, end, idle, emit); err != nil {
+		return fmt.Errorf("read %s/%d: %w", topic, partition, err)
+	}
+	return nil
+}
+
+// offsetRange returns the half-open range [start, end) of a partition whose
+// log spans [first, last) that falls within the offset bounds of f.
+func offsetRange(first, last int64, f *filter) (start, end int64) {
+	start = max(first, f.fromOffset)
+	end = last
+	if f.toOffset >= 0 {
+		end = min(end, f.toOffset+1)
+	}
+	return start, end
+}
+
+// messageReader is the part of *kafkago.Reader that drain uses.
+type messageReader interface {
+	ReadMessage(ctx context.Context) (kafkago.Message, error)
+}
+
+// drain passes messages from r to emit until it reaches end (exclusive).
+// Offsets can have gaps, so any message at or past end-1 finishes the range,
+// and so does waiting longer than idle for the next message.
+func drain(ctx context.Context, r messageReader, end int64, idle time.Duration, emit func(domain.RawEvent) error) error {
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, idle)
+		msg, err := r.ReadMessage(readCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				return nil
+			}
+			return err
+		}
+		if msg.Offset >= end {
+			return nil
+		}
+		headers := make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		err = emit(domain.RawEvent{
+			Key:       msg.Key,
+			Value:     msg.Value,
+			Headers:   headers,
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Timestamp: msg.Time,
+		})
+		if err != nil {
+			return err
+		}
+		if msg.Offset >= end-1 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFilter_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int64
+		since    string
+		until    string
+		errMsg   string
+	}{
+		{name: "to before from", from: 5, to: 4, errMsg: "-to-offset 4 is before -from-offset 5"},
+		{name: "bad since", from: -1, to: -1, since: "yesterday", errMsg: "invalid -since"},
+		{name: "bad until", from: -1, to: -1, until: "2024-04-26", errMsg: "invalid -until"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newFilter(nil, tt.from, tt.to, tt.since, tt.until)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	ts := time.Date(2024, 4, 26, 12, 0, 0, 0, time.UTC)
+	raw := domain.RawEvent{
+		Offset:    10,
+		Timestamp: ts,
+		Headers:   map[string]string{"dlq_source_topic": "raw-weather-reports"},
+	}
+
+	tests := []struct {
+		name     string
+		headers  headerFilter
+		from, to int64
+		since    string
+		until    string
+		expected bool
+	}{
+		{name: "no filter", from: -1, to: -1, expected: true},
+		{name: "inside offset range", from: 10, to: 10, expected: true},
+		{name: "before from-offset", from: 11, to: -1, expected: false},
+		{name: "after to-offset", from: -1, to: 9, expected: false},
+		{name: "since is inclusive", from: -1, to: -1, since: "2024-04-26T12:00:00Z", expected: true},
+		{name: "before since", from: -1, to: -1, since: "2024-04-26T12:00:01Z", expected: false},
+		{name: "until is exclusive", from: -1, to: -1, until: "2024-04-26T12:00:00Z", expected: false},
+		{name: "before until", from: -1, to: -1, until: "2024-04-26T12:00:01Z", expected: true},
+		{name: "header value matches", headers: headerFilter{"dlq_source_topic=raw-weather-reports"}, from: -1, to: -1, expected: true},
+		{name: "header value differs", headers: headerFilter{"dlq_source_topic=other"}, from: -1, to: -1, expected: false},
+		{name: "header present", headers: headerFilter{"dlq_source_topic"}, from: -1, to: -1, expected: true},
+		{name: "header missing", headers: headerFilter{"dlq_error"}, from: -1, to: -1, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newFilter(tt.headers, tt.from, tt.to, tt.since, tt.until)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f.match(raw))
+		})
+	}
+}
+
+func TestOffsetRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		first, last int64
+		from, to    int64
+		start, end  int64
+	}{
+		{name: "whole log", first: 3, last: 10, from: -1, to: -1, start: 3, end: 10},
+		{name: "from inside the log", first: 3, last: 10, from: 5, to: -1, start: 5, end: 10},
+		{name: "from before the log start", first: 3, last: 10, from: 0, to: -1, start: 3, end: 10},
+		{name: "to is inclusive", first: 3, last: 10, from: -1, to: 6, start: 3, end: 7},
+		{name: "to past the high watermark", first: 3, last: 10, from: -1, to: 50, start: 3, end: 10},
+		{name: "range past the log is empty", first: 3, last: 10, from: 20, to: -1, start: 20, end: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := offsetRange(tt.first, tt.last, &filter{fromOffset: tt.from, toOffset: tt.to})
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+}
+
+// fakeReader returns msgs in order, then blocks until the read context ends,
+// like a reader waiting at the head of a partition.
+type fakeReader struct {
+	msgs  []kafkago.Message
+	reads int
+}
+
+func (r *fakeReader) ReadMessage(ctx context.Context) (kafkago.Message, error) {
+	r.reads++
+	if len(r.msgs) == 0 {
+		<-ctx.Done()
+		return kafkago.Message{}, ctx.Err()
+	}
+	msg := r.msgs[0]
+	r.msgs = r.msgs[1:]
+	return msg, nil
+}
+
+func TestDrain(t *testing.T) {
+	msgsAt := func(offsets ...int64) []kafkago.Message {
+		msgs := make([]kafkago.Message, len(offsets))
+		for i, o := range offsets {
+			msgs[i] = kafkago.Message{Offset: o}
+		}
+		return msgs
+	}
+
+	tests := []struct {
+		name    string
+		msgs    []kafkago.Message
+		end     int64
+		emitted []int64
+		reads   int
+	}{
+		{name: "stops at the last offset", msgs: msgsAt(0, 1, 2, 3), end: 3, emitted: []int64{0, 1, 2}, reads: 3},
+		{name: "gap past the last offset", msgs: msgsAt(0, 1, 4), end: 3, emitted: []int64{0, 1}, reads: 3},
+		{name: "gap onto the last offset", msgs: msgsAt(0, 2, 5), end: 3, emitted: []int64{0, 2}, reads: 2},
+		{name: "idle before the end", msgs: msgsAt(0, 1), end: 3, emitted: []int64{0, 1}, reads: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeReader{msgs: tt.msgs}
+			var emitted []int64
+			err := drain(context.Background(), r, tt.end, 10*time.Millisecond, func(raw domain.RawEvent) error {
+				emitted = append(emitted, raw.Offset)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.emitted, emitted)
+			assert.Equal(t, tt.reads, r.reads)
+		})
+	}
+}
+
+func TestDrain_Errors(t *testing.T) {
+	t.Run("emit error stops the read", func(t *testing.T) {
+		r := &fakeReader{msgs: []kafkago.Message{{Offset: 0}, {Offset: 1}}}
+		errLoad := errors.New("load failed")
+		err := drain(context.Background(), r, 2, time.Second, func(domain.RawEvent) error { return errLoad })
+		require.ErrorIs(t, err, errLoad)
+		assert.Equal(t, 1, r.reads)
+	})
+
+	t.Run("cancellation is not mistaken for idle", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := drain(ctx, &fakeReader{}, 2, time.Second, func(domain.RawEvent) error { return nil })
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"EventType":"hail"},{"EventType":"wind"}]`), 0o644))
+	baseDate := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+
+	var raws []domain.RawEvent
+	require.NoError(t, readFile(path, baseDate, func(raw domain.RawEvent) error {
+		raws = append(raws, raw)
+		return nil
+	}))
+
+	require.Len(t, raws, 2)
+	assert.Equal(t, int64(1), raws[1].Offset)
+	assert.JSONEq(t, `{"EventType":"wind"}`, string(raws[1].Value))
+	assert.Equal(t, "reports.json", raws[1].Topic)
+	assert.Equal(t, baseDate, raws[1].Timestamp)
+}
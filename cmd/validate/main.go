@@ -13,8 +13,8 @@
 package main
 
 import (
-	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"math"
@@ -23,24 +23,25 @@ import (
 	"strings"
 	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/spccsv"
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/schema"
 	"github.com/jonboulle/clockwork"
 )
 
 var baseDate = time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
 
-// csvSpec maps event types to their CSV file patterns and magnitude columns.
+// csvSpec maps event types to their CSV file names.
 type csvSpec struct {
 	sourceFile    string // filename in mock-server/data/
 	collectorFile string // filename in collector/data/mock/
 	eventType     string
-	magCol        string
 }
 
 var specs = []csvSpec{
-	{sourceFile: "240426_rpts_hail.csv", collectorFile: "240426_rpts_hail.csv", eventType: "hail", magCol: "Size"},
-	{sourceFile: "240426_rpts_torn.csv", collectorFile: "240426_rpts_torn.csv", eventType: "tornado", magCol: "F_Scale"},
-	{sourceFile: "240426_rpts_wind.csv", collectorFile: "240426_rpts_wind.csv", eventType: "wind", magCol: "Speed"},
+	{sourceFile: "240426_rpts_hail.csv", collectorFile: "240426_rpts_hail.csv", eventType: "hail"},
+	{sourceFile: "240426_rpts_torn.csv", collectorFile: "240426_rpts_torn.csv", eventType: "tornado"},
+	{sourceFile: "240426_rpts_wind.csv", collectorFile: "240426_rpts_wind.csv", eventType: "wind"},
 }
 
 // phase tracks pass/fail for a validation phase.
@@ -152,10 +153,26 @@ func run(sourceDir, collectorDir, etlJSONPath, apiJSONPath string) int {
 
 // ── Data loading ──
 
-// csvRow is a parsed CSV row with field values keyed by header name.
+// csvRow is an SPC report row read with the same parser the ETL uses.
 type csvRow struct {
 	lineNum int
-	fields  map[string]string
+	rec     domain.RawCSVRecord
+}
+
+// columns returns the row's values keyed by SPC column name.
+func (r csvRow) columns() map[string]string {
+	return map[string]string{
+		"Time":     r.rec.Time,
+		"Size":     r.rec.Size,
+		"F_Scale":  r.rec.FScale,
+		"Speed":    r.rec.Speed,
+		"Location": r.rec.Location,
+		"County":   r.rec.County,
+		"State":    r.rec.State,
+		"Lat":      r.rec.Lat,
+		"Lon":      r.rec.Lon,
+		"Comments": r.rec.Comments,
+	}
 }
 
 // loadAllCSVs loads CSVs for all three event types from a directory.
@@ -172,32 +189,20 @@ func loadAllCSVs(dir string, fileNameFn func(csvSpec) string) (map[string][]csvR
 	return result, nil
 }
 
+// loadCSV reads an SPC report CSV with spccsv, so short rows and stray
+// whitespace are handled exactly as the ETL and genmock handle them.
 func loadCSV(path string) ([]csvRow, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	r := csv.NewReader(f)
-	all, err := r.ReadAll()
+	recs, err := spccsv.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	if len(all) < 2 {
+	if len(recs) == 0 {
 		return nil, fmt.Errorf("no data rows in %s", path)
 	}
 
-	header := all[0]
-	var rows []csvRow
-	for i, row := range all[1:] {
-		fields := make(map[string]string, len(header))
-		for j, h := range header {
-			if j < len(row) {
-				fields[h] = strings.TrimSpace(row[j])
-			}
-		}
-		rows = append(rows, csvRow{lineNum: i + 2, fields: fields})
+	rows := make([]csvRow, len(recs))
+	for i, rec := range recs {
+		rows[i] = csvRow{lineNum: i + 2, rec: rec}
 	}
 	return rows, nil
 }
@@ -238,11 +243,9 @@ func validateSourceParity(source, collector map[string][]csvRow) *phase {
 		}
 
 		for i := range srcRows {
-			for key, srcVal := range srcRows[i].fields {
-				colVal, ok := colRows[i].fields[key]
-				if !ok {
-					p.errorf("%s line %d: collector missing column %q", s.eventType, srcRows[i].lineNum, key)
-				} else if srcVal != colVal {
+			colFields := colRows[i].columns()
+			for key, srcVal := range srcRows[i].columns() {
+				if colVal := colFields[key]; srcVal != colVal {
 					p.errorf("%s line %d: column %q: source=%q, collector=%q", s.eventType, srcRows[i].lineNum, key, srcVal, colVal)
 				}
 			}
@@ -304,7 +307,7 @@ func checkETLCrossRef(p *phase, etl []domain.RawCSVRecord, source map[string][]c
 
 	for _, s := range specs {
 		for _, row := range source[s.eventType] {
-			key := s.eventType + "|" + row.fields["State"] + "|" + row.fields["Lat"] + "|" + row.fields["Lon"] + "|" + row.fields["Time"]
+			key := s.eventType + "|" + row.rec.State + "|" + row.rec.Lat + "|" + row.rec.Lon + "|" + row.rec.Time
 			if etlIndex[key] == 0 {
 				p.errorf("%s line %d: CSV row not found in ETL JSON (key=%s)", s.eventType, row.lineNum, key)
 			}
@@ -472,12 +475,6 @@ func validateSchemaAlignment(api []domain.StormEvent) *phase {
 	return p
 }
 
-var (
-	schemaTypes      = map[string]bool{"hail": true, "tornado": true, "wind": true}
-	schemaUnits      = map[string]bool{"in": true, "mph": true, "f_scale": true}
-	schemaSeverities = map[string]bool{"minor": true, "moderate": true, "severe": true, "extreme": true}
-)
-
 func checkSchemaRecord(p *phase, i int, e *domain.StormEvent) {
 	pf := func(format string, args ...any) {
 		p.errorf("record %d (ID %s): "+format, append([]any{i, e.ID}, args...)...)
@@ -487,25 +484,22 @@ func checkSchemaRecord(p *phase, i int, e *domain.StormEvent) {
 	checkSchemaRequiredFields(pf, e)
 }
 
+// checkSchemaEnums checks the event against the sink message JSON Schema,
+// which carries the enum and ID rules, plus the cross-field rules the schema
+// cannot express.
 func checkSchemaEnums(pf func(string, ...any), e *domain.StormEvent) {
-	if e.EventType == "" {
-		pf("eventType is empty (schema requires String!)")
-	} else if !schemaTypes[e.EventType] {
-		pf("eventType %q not in enum {hail, tornado, wind}", e.EventType)
+	var violations schema.Violations
+	if err := schema.ValidateEvent(*e); errors.As(err, &violations) {
+		for _, v := range violations {
+			pf("%s (schema %s)", v.Error(), schema.Version)
+		}
+	} else if err != nil {
+		pf("%v", err)
 	}
 
-	if e.ID == "" {
-		pf("id is empty")
-	} else if !strings.HasPrefix(e.ID, e.EventType+"-") {
+	if e.ID != "" && !strings.HasPrefix(e.ID, e.EventType+"-") {
 		pf("id %q doesn't start with type prefix %q-", e.ID, e.EventType)
 	}
-
-	if !schemaUnits[e.Measurement.Unit] {
-		pf("unit %q not in {in, mph, f_scale}", e.Measurement.Unit)
-	}
-	if e.Measurement.Severity != nil && !schemaSeverities[*e.Measurement.Severity] {
-		pf("severity %q not in {minor, moderate, severe, extreme}", *e.Measurement.Severity)
-	}
 	if e.Measurement.Magnitude > 0 && e.Measurement.Severity == nil {
 		pf("magnitude %g > 0 but severity is nil", e.Measurement.Magnitude)
 	}
@@ -0,0 +1,167 @@
+package dedupstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2024, 4, 26, 12, 0, 0, 0, time.UTC)
+
+func TestMemory_ExpiresAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(time.Hour, 10)
+	now := t0
+	m.now = func() time.Time { return now }
+
+	require.NoError(t, m.Put(ctx, "a", pipeline.SeenEntry{SeenAt: t0}))
+	_, ok, err := m.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	now = t0.Add(time.Hour)
+	_, ok, err = m.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(time.Hour, 2)
+	m.now = func() time.Time { return t0 }
+
+	require.NoError(t, m.Put(ctx, "a", pipeline.SeenEntry{SeenAt: t0}))
+	require.NoError(t, m.Put(ctx, "b", pipeline.SeenEntry{SeenAt: t0}))
+	_, _, _ = m.Get(ctx, "a") // a is now more recent than b
+	require.NoError(t, m.Put(ctx, "c", pipeline.SeenEntry{SeenAt: t0}))
+
+	_, okA, _ := m.Get(ctx, "a")
+	_, okB, _ := m.Get(ctx, "b")
+	_, okC, _ := m.Get(ctx, "c")
+	assert.True(t, okA)
+	assert.False(t, okB)
+	assert.True(t, okC)
+}
+
+func TestFile_SurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seen.log")
+
+	s, err := OpenFile(path, 24*time.Hour, 100)
+	require.NoError(t, err)
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, s.Put(ctx, "a", pipeline.SeenEntry{SeenAt: now}))
+	require.NoError(t, s.Put(ctx, "old", pipeline.SeenEntry{SeenAt: now.Add(-48 * time.Hour)}))
+	require.NoError(t, s.Close())
+
+	s, err = OpenFile(path, 24*time.Hour, 100)
+	require.NoError(t, err)
+	defer s.Close()
+
+	entry, ok, err := s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, now.Equal(entry.SeenAt))
+
+	_, ok, _ = s.Get(ctx, "old")
+	assert.False(t, ok)
+	assert.Len(t, s.entries, 1, "expired entries are compacted away on open")
+}
+
+func TestFile_IgnoresTornTrailingLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.log")
+	now := time.Now().UTC().Format(time.RFC3339)
+	data := `{"id":"a","entry":{"seen_at":"` + now + `"}}` + "\n" + `{"id":"b","ent`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	s, err := OpenFile(path, time.Hour, 100)
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, ok, _ := s.Get(context.Background(), "a")
+	assert.True(t, ok)
+	_, ok, _ = s.Get(context.Background(), "b")
+	assert.False(t, ok)
+}
+
+func TestFile_EvictsOldestBeyondCapacity(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seen.log")
+
+	s, err := OpenFile(path, time.Hour, 2)
+	require.NoError(t, err)
+	s.now = func() time.Time { return t0 }
+	require.NoError(t, s.Put(ctx, "a", pipeline.SeenEntry{SeenAt: t0}))
+	require.NoError(t, s.Put(ctx, "b", pipeline.SeenEntry{SeenAt: t0}))
+	require.NoError(t, s.Put(ctx, "c", pipeline.SeenEntry{SeenAt: t0}))
+	assert.Len(t, s.entries, 2)
+	_, ok, _ := s.Get(ctx, "a")
+	assert.False(t, ok, "the entry written longest ago is evicted")
+	require.NoError(t, s.Close())
+
+	s, err = OpenFile(path, 100*365*24*time.Hour, 2)
+	require.NoError(t, err)
+	defer s.Close()
+	_, okA, _ := s.Get(ctx, "a")
+	_, okB, _ := s.Get(ctx, "b")
+	_, okC, _ := s.Get(ctx, "c")
+	assert.False(t, okA, "capacity is enforced again on reopen")
+	assert.True(t, okB)
+	assert.True(t, okC)
+}
+
+func TestFile_ExpiresUniqueIDsAndCompacts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seen.log")
+
+	s, err := OpenFile(path, time.Minute, compactSlack*2)
+	require.NoError(t, err)
+	defer s.Close()
+	now := t0
+	s.now = func() time.Time { return now }
+
+	// Every ID is new, so nothing is superseded; only expiry keeps the store
+	// and its log bounded.
+	for i := range compactSlack + 100 {
+		now = t0.Add(time.Duration(i) * time.Second)
+		require.NoError(t, s.Put(ctx, fmt.Sprintf("evt-%d", i), pipeline.SeenEntry{SeenAt: now}))
+	}
+	assert.Len(t, s.entries, 60, "only the last minute of IDs is held")
+	assert.Less(t, s.records, compactSlack, "the log was compacted")
+}
+
+func TestFile_CompactFailureKeepsLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "seen.log")
+
+	s, err := OpenFile(path, 24*time.Hour, 100)
+	require.NoError(t, err)
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, s.Put(ctx, "a", pipeline.SeenEntry{SeenAt: now}))
+
+	// Point compaction at a directory that does not exist so it cannot
+	// create its temporary file.
+	s.path = filepath.Join(dir, "missing", "seen.log")
+	require.Error(t, s.compact())
+	s.path = path
+
+	require.NoError(t, s.Put(ctx, "b", pipeline.SeenEntry{SeenAt: now}), "the current log stays open")
+	require.NoError(t, s.Close())
+
+	s, err = OpenFile(path, 24*time.Hour, 100)
+	require.NoError(t, err)
+	defer s.Close()
+	_, okA, _ := s.Get(ctx, "a")
+	_, okB, _ := s.Get(ctx, "b")
+	assert.True(t, okA)
+	assert.True(t, okB, "writes after the failed compaction reach the log")
+}
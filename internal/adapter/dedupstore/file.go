@@ -0,0 +1,228 @@
+package dedupstore
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// compactSlack is the number of superseded, expired, or evicted log records
+// tolerated before Put rewrites the file.
+const compactSlack = 10000
+
+type fileRecord struct {
+	ID    string             `json:"id"`
+	Entry pipeline.SeenEntry `json:"entry"`
+}
+
+// File is a SeenStore persisted to an append-only JSON-lines log so seen IDs
+// survive restarts. The live set is held in memory and bounded like Memory's:
+// entries older than ttl expire, and once capacity is reached the entry
+// written longest ago is evicted. The log is compacted on open and whenever
+// superseded, expired, or evicted records pile up. Writes are not fsynced
+// individually, so a crash can lose the most recent entries and let those
+// duplicates through once.
+// It implements pipeline.SeenStore.
+type File struct {
+	mu       sync.Mutex
+	path     string
+	ttl      time.Duration
+	capacity int
+	f        *os.File
+	w        *bufio.Writer
+	order    *list.List // front is most recently written
+	entries  map[string]*list.Element
+	records  int
+	now      func() time.Time
+}
+
+// OpenFile opens or creates the log at path, holding at most capacity IDs
+// for up to ttl.
+func OpenFile(path string, ttl time.Duration, capacity int) (*File, error) {
+	s := &File{
+		path:     path,
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the existing log, keeping the last record per ID. A torn final
+// line from a crash is ignored.
+func (s *File) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open dedup store: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec fileRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue
+		}
+		s.set(rec.ID, rec.Entry)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read dedup store: %w", err)
+	}
+	return nil
+}
+
+// set records entry for id as the most recently written.
+func (s *File) set(id string, entry pipeline.SeenEntry) {
+	if el, ok := s.entries[id]; ok {
+		el.Value.(*memoryItem).entry = entry
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[id] = s.order.PushFront(&memoryItem{id: id, entry: entry})
+}
+
+// evict drops the oldest entries while the store is over capacity or they
+// have expired. Their records stay in the log until the next compaction.
+func (s *File) evict() {
+	now := s.now()
+	for el := s.order.Back(); el != nil; el = s.order.Back() {
+		item := el.Value.(*memoryItem)
+		if s.order.Len() <= s.capacity && now.Sub(item.entry.SeenAt) < s.ttl {
+			return
+		}
+		s.order.Remove(el)
+		delete(s.entries, item.id)
+	}
+}
+
+// compact writes the live entries, oldest first, to a new log and swaps it in
+// for the current one. On failure the current log is left in place and stays
+// open for appends.
+func (s *File) compact() error {
+	if s.w != nil {
+		if err := s.w.Flush(); err != nil {
+			return fmt.Errorf("flush dedup store: %w", err)
+		}
+	}
+
+	s.evict()
+	now := s.now()
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("compact dedup store: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for el := s.order.Back(); el != nil; {
+		item := el.Value.(*memoryItem)
+		prev := el.Prev()
+		if now.Sub(item.entry.SeenAt) >= s.ttl {
+			s.order.Remove(el)
+			delete(s.entries, item.id)
+		} else if err = enc.Encode(fileRecord{ID: item.id, Entry: item.entry}); err != nil {
+			break
+		}
+		el = prev
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("compact dedup store: %w", err)
+	}
+
+	// The temporary file is now the log, and this process is its only
+	// writer, so keep appending through the same handle.
+	if s.f != nil {
+		s.f.Close()
+	}
+	s.f = tmp
+	s.w = bufio.NewWriter(tmp)
+	s.records = len(s.entries)
+	return nil
+}
+
+// Get returns the entry for id if it is present and not expired.
+func (s *File) Get(_ context.Context, id string) (pipeline.SeenEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[id]
+	if !ok {
+		return pipeline.SeenEntry{}, false, nil
+	}
+	entry := el.Value.(*memoryItem).entry
+	if s.now().Sub(entry.SeenAt) >= s.ttl {
+		return pipeline.SeenEntry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+// Put appends entry for id to the log and flushes it to the OS, evicting
+// expired entries and, if full, the entry written longest ago.
+func (s *File) Put(_ context.Context, id string, entry pipeline.SeenEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(fileRecord{ID: id, Entry: entry})
+	if err != nil {
+		return fmt.Errorf("encode dedup entry: %w", err)
+	}
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write dedup entry: %w", err)
+	}
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("write dedup entry: %w", err)
+	}
+	s.set(id, entry)
+	s.records++
+	s.evict()
+
+	if s.records-len(s.entries) > compactSlack {
+		return s.compact()
+	}
+	return nil
+}
+
+// Close flushes and syncs the log.
+func (s *File) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.w.Flush(); err != nil {
+		s.f.Close()
+		return fmt.Errorf("flush dedup store: %w", err)
+	}
+	if err := s.f.Sync(); err != nil {
+		s.f.Close()
+		return fmt.Errorf("sync dedup store: %w", err)
+	}
+	return s.f.Close()
+}
@@ -0,0 +1,90 @@
+// Package dedupstore provides pipeline.SeenStore implementations for
+// cross-batch duplicate suppression.
+package dedupstore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+type memoryItem struct {
+	id    string
+	entry pipeline.SeenEntry
+}
+
+// Memory is an in-process SeenStore bounded by both age and size: entries
+// older than ttl are expired on access, and the least recently used entry is
+// evicted once capacity is reached. Contents are lost on restart.
+// It implements pipeline.SeenStore.
+type Memory struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	order    *list.List // front is most recently used
+	items    map[string]*list.Element
+	now      func() time.Time
+}
+
+// NewMemory creates a Memory store holding at most capacity IDs for up to ttl.
+func NewMemory(ttl time.Duration, capacity int) *Memory {
+	return &Memory{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get returns the entry for id if it is present and not expired.
+func (m *Memory) Get(_ context.Context, id string) (pipeline.SeenEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[id]
+	if !ok {
+		return pipeline.SeenEntry{}, false, nil
+	}
+	item := el.Value.(*memoryItem)
+	if m.now().Sub(item.entry.SeenAt) >= m.ttl {
+		m.order.Remove(el)
+		delete(m.items, id)
+		return pipeline.SeenEntry{}, false, nil
+	}
+	m.order.MoveToFront(el)
+	return item.entry, true, nil
+}
+
+// Put records entry for id, evicting the least recently used ID if full.
+func (m *Memory) Put(_ context.Context, id string, entry pipeline.SeenEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[id]; ok {
+		el.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(el)
+		return nil
+	}
+	m.items[id] = m.order.PushFront(&memoryItem{id: id, entry: entry})
+	if m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).id)
+	}
+	return nil
+}
+
+// Len returns the number of IDs currently held, including expired ones not
+// yet accessed.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// Close is a no-op; it lets Memory and File be closed interchangeably.
+func (m *Memory) Close() error { return nil }
@@ -0,0 +1,22 @@
+package dedupstore
+
+import (
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// Store is a pipeline.SeenStore that holds resources until closed.
+type Store interface {
+	pipeline.SeenStore
+	Close() error
+}
+
+// Open returns the store selected by cfg: a File at DedupStorePath when set,
+// otherwise a Memory store. Both hold at most DedupCacheSize IDs and expire
+// entries after DedupWindow.
+func Open(cfg *config.Config) (Store, error) {
+	if cfg.DedupStorePath != "" {
+		return OpenFile(cfg.DedupStorePath, cfg.DedupWindow, cfg.DedupCacheSize)
+	}
+	return NewMemory(cfg.DedupWindow, cfg.DedupCacheSize), nil
+}
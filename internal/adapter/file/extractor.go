@@ -0,0 +1,294 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// checkpointSuffix is appended to the input path to name the sidecar file
+// holding the offset of the next record to extract.
+const checkpointSuffix = ".offset"
+
+// yymmddRe finds the SPC report date in filenames like "240426_rpts_hail.csv"
+// or "storm_reports_240426_combined.json".
+var yymmddRe = regexp.MustCompile(`(?:^|_)(\d{6})(?:_|\.)`)
+
+// DateFromFilename returns the YYMMDD date embedded in SPC-style filenames.
+func DateFromFilename(path string) (time.Time, bool) {
+	m := yymmddRe.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return time.Time{}, false
+	}
+	d, err := time.Parse("060102", m[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// BaseDate returns the report date for the input at path: date, a
+// YYYY-MM-DD value the caller took from setting, or else the date in the
+// filename. setting names where date comes from, e.g. "-base-date", and is
+// what the errors tell the operator to fix.
+func BaseDate(path, date, setting string) (time.Time, error) {
+	if date != "" {
+		d, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s: %w", setting, err)
+		}
+		return d, nil
+	}
+	if d, ok := DateFromFilename(path); ok {
+		return d, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot infer event date from %s: set %s", path, setting)
+}
+
+// Extractor reads RawCSVRecord objects from a newline-delimited JSON file or
+// a JSON array, such as the files in data/mock. Records become RawEvents on
+// partition 0 whose offset is the record's index in the file and whose
+// timestamp is the report date, mirroring what the collector publishes.
+//
+// Committed progress is checkpointed to a sidecar file next to the input, so
+// a restarted run skips the records it already processed, unless the
+// extractor was opened with OpenExtractorAt. Once the input is
+// exhausted ExtractBatch returns io.EOF.
+// It implements pipeline.BatchExtractor and pipeline.BatchCommitter.
+type Extractor struct {
+	mu       sync.Mutex
+	path     string
+	topic    string
+	baseDate time.Time
+	f        *os.File
+	dec      *json.Decoder
+	array    bool
+	next     int64 // offset of the next record to read
+	done     bool
+
+	checkpointMu sync.Mutex
+	committed    int64 // offset of the next record not yet committed
+	sidecar      bool  // whether commits are written to the sidecar file
+}
+
+// OpenExtractor opens the file at path and positions it after the last
+// checkpointed record. baseDate is the report date used to resolve the
+// records' HHMM times.
+func OpenExtractor(path string, baseDate time.Time) (*Extractor, error) {
+	committed, err := readCheckpoint(path + checkpointSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return openExtractor(path, baseDate, committed, true)
+}
+
+// OpenExtractorAt opens the file at path positioned at offset, for callers
+// that keep their own checkpoint. The sidecar file is neither read nor
+// written.
+func OpenExtractorAt(path string, baseDate time.Time, offset int64) (*Extractor, error) {
+	return openExtractor(path, baseDate, offset, false)
+}
+
+func openExtractor(path string, baseDate time.Time, committed int64, sidecar bool) (*Extractor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open source file: %w", err)
+	}
+
+	e := &Extractor{
+		path:      path,
+		topic:     filepath.Base(path),
+		baseDate:  baseDate,
+		f:         f,
+		committed: committed,
+		sidecar:   sidecar,
+	}
+	if err := e.start(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	for e.next < committed && !e.done {
+		if _, err := e.read(); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// start detects the input format from its first non-space byte and, for a
+// JSON array, consumes the opening bracket.
+func (e *Extractor) start() error {
+	br := bufio.NewReader(e.f)
+	for {
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			e.done = true
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read source file: %w", err)
+		}
+		if strings.IndexByte(" \t\r\n", b) < 0 {
+			_ = br.UnreadByte()
+			e.array = b == '['
+			break
+		}
+	}
+
+	e.dec = json.NewDecoder(br)
+	if e.array {
+		if _, err := e.dec.Token(); err != nil {
+			return fmt.Errorf("parse %s: %w", e.path, err)
+		}
+	}
+	return nil
+}
+
+// read returns the next record, or nil once the input is exhausted.
+func (e *Extractor) read() (json.RawMessage, error) {
+	if e.done {
+		return nil, nil
+	}
+	if e.array && !e.dec.More() {
+		e.done = true
+		return nil, nil
+	}
+	var rec json.RawMessage
+	err := e.dec.Decode(&rec)
+	if errors.Is(err, io.EOF) && !e.array {
+		e.done = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s record %d: %w", e.path, e.next, err)
+	}
+	e.next++
+	return rec, nil
+}
+
+// SkipTo skips records until the next record extracted has the given offset.
+// It only moves forward and does not touch the checkpoint.
+func (e *Extractor) SkipTo(offset int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for e.next < offset && !e.done {
+		if _, err := e.read(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractBatch reads up to batchSize records. It returns io.EOF once every
+// record has been extracted.
+func (e *Extractor) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.done {
+		return nil, io.EOF
+	}
+	batch := make([]domain.RawEvent, 0, batchSize)
+	for len(batch) < batchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rec, err := e.read()
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			break
+		}
+		offset := e.next - 1
+		batch = append(batch, domain.RawEvent{
+			Value:     rec,
+			Topic:     e.topic,
+			Offset:    offset,
+			Timestamp: e.baseDate,
+			Commit: func(context.Context) error {
+				return e.checkpoint(offset + 1)
+			},
+		})
+	}
+	if len(batch) == 0 {
+		return nil, io.EOF
+	}
+	return batch, nil
+}
+
+// CommitBatch checkpoints past the highest offset in events.
+func (e *Extractor) CommitBatch(_ context.Context, events []domain.RawEvent) error {
+	var next int64
+	for _, raw := range events {
+		next = max(next, raw.Offset+1)
+	}
+	return e.checkpoint(next)
+}
+
+// checkpoint records next as the offset to resume from. Checkpoints never move
+// backwards.
+func (e *Extractor) checkpoint(next int64) error {
+	e.checkpointMu.Lock()
+	defer e.checkpointMu.Unlock()
+	if next <= e.committed {
+		return nil
+	}
+	if !e.sidecar {
+		e.committed = next
+		return nil
+	}
+	if err := writeCheckpoint(e.path+checkpointSuffix, next); err != nil {
+		return err
+	}
+	e.committed = next
+	return nil
+}
+
+// Close closes the input file.
+func (e *Extractor) Close() error {
+	return e.f.Close()
+}
+
+// readCheckpoint returns the offset stored in the sidecar file, or 0 if there
+// is none yet.
+func readCheckpoint(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read checkpoint: %w", err)
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid checkpoint in %s: %q", path, data)
+	}
+	return offset, nil
+}
+
+// writeCheckpoint replaces the sidecar file through a rename so a crash never
+// leaves it half-written.
+func writeCheckpoint(path string, offset int64) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(offset, 10)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace checkpoint: %w", err)
+	}
+	return nil
+}
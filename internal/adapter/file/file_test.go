@@ -0,0 +1,238 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var reportDate = time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+
+func writeInput(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func extractAll(t *testing.T, e *Extractor, batchSize int) []domain.RawEvent {
+	t.Helper()
+	var all []domain.RawEvent
+	for {
+		batch, err := e.ExtractBatch(context.Background(), batchSize)
+		if err == io.EOF {
+			return all
+		}
+		require.NoError(t, err)
+		all = append(all, batch...)
+	}
+}
+
+func eventTypes(t *testing.T, raws []domain.RawEvent) []string {
+	t.Helper()
+	types := make([]string, len(raws))
+	for i, raw := range raws {
+		var rec domain.RawCSVRecord
+		require.NoError(t, json.Unmarshal(raw.Value, &rec))
+		types[i] = rec.EventType
+	}
+	return types
+}
+
+func TestDateFromFilename(t *testing.T) {
+	d, ok := DateFromFilename("data/mock/storm_reports_240426_combined.json")
+	require.True(t, ok)
+	assert.Equal(t, reportDate, d)
+
+	_, ok = DateFromFilename("reports.json")
+	assert.False(t, ok)
+}
+
+func TestBaseDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		date     string
+		expected time.Time
+		errMsg   string
+	}{
+		{name: "explicit date wins", path: "storm_reports_240426_combined.json", date: "2024-05-01", expected: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "filename date", path: "data/mock/storm_reports_240426_combined.json", expected: reportDate},
+		{name: "invalid explicit date", path: "storm_reports_240426_combined.json", date: "04/26/2024", errMsg: "invalid -base-date"},
+		{name: "no date anywhere", path: "reports.json", errMsg: "cannot infer event date from reports.json: set -base-date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := BaseDate(tt.path, tt.date, "-base-date")
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
+
+func TestExtractor_Formats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"array", `[{"EventType":"hail"}, {"EventType":"wind"},
+			{"EventType":"tornado"}]`},
+		{"ndjson", "{\"EventType\":\"hail\"}\n{\"EventType\":\"wind\"}\n\n{\"EventType\":\"tornado\"}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := OpenExtractor(writeInput(t, "reports.json", tt.content), reportDate)
+			require.NoError(t, err)
+			defer e.Close()
+
+			raws := extractAll(t, e, 2)
+			assert.Equal(t, []string{"hail", "wind", "tornado"}, eventTypes(t, raws))
+			for i, raw := range raws {
+				assert.Equal(t, int64(i), raw.Offset)
+				assert.Equal(t, 0, raw.Partition)
+				assert.Equal(t, "reports.json", raw.Topic)
+				assert.Equal(t, reportDate, raw.Timestamp)
+			}
+		})
+	}
+}
+
+func TestExtractor_EmptyFile(t *testing.T) {
+	for _, content := range []string{"", "  \n", "[]"} {
+		e, err := OpenExtractor(writeInput(t, "reports.json", content), reportDate)
+		require.NoError(t, err)
+		assert.Empty(t, extractAll(t, e, 10), "%q", content)
+		require.NoError(t, e.Close())
+	}
+}
+
+func TestExtractor_ResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	path := writeInput(t, "reports.ndjson",
+		"{\"EventType\":\"hail\"}\n{\"EventType\":\"wind\"}\n{\"EventType\":\"tornado\"}\n")
+
+	e, err := OpenExtractor(path, reportDate)
+	require.NoError(t, err)
+	batch, err := e.ExtractBatch(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	// Only the first record is committed before the "crash".
+	require.NoError(t, batch[0].Commit(ctx))
+	require.NoError(t, e.Close())
+
+	data, err := os.ReadFile(path + checkpointSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n", string(data))
+
+	e, err = OpenExtractor(path, reportDate)
+	require.NoError(t, err)
+	raws := extractAll(t, e, 10)
+	assert.Equal(t, []string{"wind", "tornado"}, eventTypes(t, raws))
+	assert.Equal(t, int64(1), raws[0].Offset)
+
+	require.NoError(t, e.CommitBatch(ctx, raws))
+	// An older commit arriving late does not move the checkpoint back.
+	require.NoError(t, batch[0].Commit(ctx))
+	require.NoError(t, e.Close())
+
+	e, err = OpenExtractor(path, reportDate)
+	require.NoError(t, err)
+	defer e.Close()
+	assert.Empty(t, extractAll(t, e, 10))
+}
+
+func TestExtractor_OpenAtIgnoresSidecar(t *testing.T) {
+	ctx := context.Background()
+	path := writeInput(t, "reports.ndjson",
+		"{\"EventType\":\"hail\"}\n{\"EventType\":\"wind\"}\n{\"EventType\":\"tornado\"}\n")
+	require.NoError(t, os.WriteFile(path+checkpointSuffix, []byte("2\n"), 0o644))
+
+	e, err := OpenExtractorAt(path, reportDate, 1)
+	require.NoError(t, err)
+	defer e.Close()
+
+	raws := extractAll(t, e, 10)
+	assert.Equal(t, []string{"wind", "tornado"}, eventTypes(t, raws), "starts at the given offset, not the sidecar's")
+	require.NoError(t, e.CommitBatch(ctx, raws))
+
+	data, err := os.ReadFile(path + checkpointSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, "2\n", string(data), "the sidecar is not written")
+}
+
+func TestExtractor_SkipTo(t *testing.T) {
+	path := writeInput(t, "reports.json", `[{"EventType":"hail"},{"EventType":"wind"},{"EventType":"tornado"}]`)
+
+	e, err := OpenExtractor(path, reportDate)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.SkipTo(2))
+	raws := extractAll(t, e, 10)
+	assert.Equal(t, []string{"tornado"}, eventTypes(t, raws))
+	assert.Equal(t, int64(2), raws[0].Offset)
+	require.NoError(t, e.SkipTo(10))
+
+	// Skipping does not checkpoint.
+	_, err = os.Stat(path + checkpointSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExtractor_InvalidInput(t *testing.T) {
+	_, err := OpenExtractor(filepath.Join(t.TempDir(), "missing.json"), reportDate)
+	assert.Error(t, err)
+
+	e, err := OpenExtractor(writeInput(t, "reports.ndjson", "{\"EventType\":\"hail\"}\nnot json\n"), reportDate)
+	require.NoError(t, err)
+	defer e.Close()
+	_, err = e.ExtractBatch(context.Background(), 10)
+	assert.ErrorContains(t, err, "record 1")
+
+	path := writeInput(t, "reports.json", "[]")
+	require.NoError(t, os.WriteFile(path+checkpointSuffix, []byte("abc"), 0o644))
+	_, err = OpenExtractor(path, reportDate)
+	assert.ErrorContains(t, err, "invalid checkpoint")
+}
+
+func TestLoader_AppendsJSONLines(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+
+	l, err := OpenLoader(path)
+	require.NoError(t, err)
+	require.NoError(t, l.LoadBatch(ctx, []domain.StormEvent{{ID: "a"}, {ID: "b"}}))
+	require.NoError(t, l.Close())
+
+	// Reopening appends rather than truncating.
+	l, err = OpenLoader(path)
+	require.NoError(t, err)
+	require.NoError(t, l.LoadBatch(ctx, []domain.StormEvent{{ID: "c"}}))
+	require.NoError(t, l.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var ids []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var event domain.StormEvent
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &event))
+		ids = append(ids, event.ID)
+	}
+	require.NoError(t, sc.Err())
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+}
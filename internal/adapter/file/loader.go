@@ -0,0 +1,74 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// Loader appends enriched storm events to a newline-delimited JSON file, one
+// event per line in the same encoding the Kafka writer produces. Each batch is
+// flushed before LoadBatch returns but not fsynced, so a crash can lose the
+// last batches written; their source records are then processed again.
+// It implements pipeline.BatchLoader.
+type Loader struct {
+	mu sync.Mutex
+	f  *os.File
+	w  *bufio.Writer
+}
+
+// OpenLoader opens or creates the file at path for append.
+func OpenLoader(path string) (*Loader, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open sink file: %w", err)
+	}
+	return &Loader{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// LoadBatch writes events as JSON lines. An event that cannot be serialized
+// fails permanently without blocking the rest of the batch.
+func (l *Loader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	errs := make([]error, len(events))
+	failed := false
+	for i := range events {
+		data, err := json.Marshal(events[i])
+		if err != nil {
+			errs[i] = pipeline.Permanent(fmt.Errorf("serialize storm event: %w", err))
+			failed = true
+			continue
+		}
+		_, _ = l.w.Write(data)
+		_ = l.w.WriteByte('\n')
+	}
+	if err := l.w.Flush(); err != nil {
+		// Drop what was buffered so the retried batch starts clean; lines
+		// already written before the failure are written again.
+		l.w.Reset(l.f)
+		return fmt.Errorf("write sink file: %w", err)
+	}
+	if failed {
+		return &pipeline.BatchLoadError{Errs: errs}
+	}
+	return nil
+}
+
+// Close flushes and closes the file.
+func (l *Loader) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.w.Flush(); err != nil {
+		_ = l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,129 @@
+package httpadapter
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	sharedobs "github.com/couchcryptid/storm-data-shared/observability"
+)
+
+// Controller is the pipeline control surface behind the admin API.
+// pipeline.Pipeline implements it.
+type Controller interface {
+	Pause()
+	Resume()
+	Status() pipeline.Status
+	SetBatching(batchSize int, flushInterval time.Duration) error
+}
+
+// batchingRequest is the body of PUT /admin/batching. Omitted fields are
+// left unchanged.
+type batchingRequest struct {
+	BatchSize     int    `json:"batch_size"`
+	FlushInterval string `json:"flush_interval"`
+}
+
+// adminStatus is the body of every admin response.
+type adminStatus struct {
+	Pipeline pipeline.Status   `json:"pipeline"`
+	Config   map[string]string `json:"config,omitempty"`
+}
+
+// WithAdmin adds POST /admin/pause, POST /admin/resume, PUT /admin/batching,
+// and GET /admin/status, authenticated with "Authorization: Bearer <token>". config is the settings
+// snapshot included in the status, with secrets already redacted; its
+// BATCH_SIZE and BATCH_FLUSH_INTERVAL are replaced with the pipeline's
+// current values, which PUT /admin/batching may have changed since startup.
+// An empty token leaves the admin API disabled.
+func WithAdmin(token string, ctl Controller, config map[string]string) Option {
+	return func(s *Server) {
+		if token == "" {
+			return
+		}
+		auth := requireToken(token)
+		s.mux.Handle("POST /admin/pause", auth(func(w http.ResponseWriter, _ *http.Request) {
+			ctl.Pause()
+			s.logger.Warn("pipeline paused via admin API")
+			sharedobs.WriteJSON(w, http.StatusOK, adminStatus{Pipeline: ctl.Status()})
+		}))
+		s.mux.Handle("POST /admin/resume", auth(func(w http.ResponseWriter, _ *http.Request) {
+			ctl.Resume()
+			s.logger.Warn("pipeline resumed via admin API")
+			sharedobs.WriteJSON(w, http.StatusOK, adminStatus{Pipeline: ctl.Status()})
+		}))
+		s.mux.Handle("PUT /admin/batching", auth(func(w http.ResponseWriter, r *http.Request) {
+			batchSize, flushInterval, err := decodeBatching(w, r)
+			if err == nil {
+				err = ctl.SetBatching(batchSize, flushInterval)
+			}
+			if err != nil {
+				sharedobs.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			sharedobs.WriteJSON(w, http.StatusOK, adminStatus{Pipeline: ctl.Status()})
+		}))
+		s.mux.Handle("GET /admin/status", auth(func(w http.ResponseWriter, _ *http.Request) {
+			st := ctl.Status()
+			sharedobs.WriteJSON(w, http.StatusOK, adminStatus{Pipeline: st, Config: liveConfig(config, st)})
+		}))
+	}
+}
+
+// liveConfig returns a copy of config with the batching settings taken from
+// the pipeline status.
+func liveConfig(config map[string]string, st pipeline.Status) map[string]string {
+	live := maps.Clone(config)
+	if live == nil {
+		live = make(map[string]string, 2)
+	}
+	live["BATCH_SIZE"] = strconv.Itoa(st.BatchSize)
+	if st.FlushInterval != "" {
+		live["BATCH_FLUSH_INTERVAL"] = st.FlushInterval
+	}
+	return live
+}
+
+// decodeBatching reads a batchingRequest, returning zero for omitted fields.
+func decodeBatching(w http.ResponseWriter, r *http.Request) (int, time.Duration, error) {
+	var req batchingRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return 0, 0, fmt.Errorf("invalid request body: %w", err)
+	}
+	if req.BatchSize == 0 && req.FlushInterval == "" {
+		return 0, 0, errors.New("set batch_size, flush_interval, or both")
+	}
+	var flushInterval time.Duration
+	if req.FlushInterval != "" {
+		d, err := time.ParseDuration(req.FlushInterval)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid flush_interval: %w", err)
+		}
+		flushInterval = d
+	}
+	return req.BatchSize, flushInterval, nil
+}
+
+// requireToken rejects requests whose bearer token does not match token.
+func requireToken(token string) func(http.HandlerFunc) http.Handler {
+	return func(next http.HandlerFunc) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				sharedobs.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+			next(w, r)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package httpadapter_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/httpadapter"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockController struct {
+	paused        bool
+	batchSize     int
+	flushInterval time.Duration
+}
+
+func (m *mockController) SetBatching(batchSize int, flushInterval time.Duration) error {
+	if batchSize > 1000 {
+		return errors.New("batch size must be 1-1000, got 5000")
+	}
+	if batchSize != 0 {
+		m.batchSize = batchSize
+	}
+	if flushInterval != 0 {
+		m.flushInterval = flushInterval
+	}
+	return nil
+}
+
+func (m *mockController) Pause()  { m.paused = true }
+func (m *mockController) Resume() { m.paused = false }
+
+func (m *mockController) Status() pipeline.Status {
+	state := pipeline.StateRunning
+	if m.paused {
+		state = pipeline.StatePaused
+	}
+	st := pipeline.Status{State: state, BatchSize: 50, FlushInterval: "1s", CommittedOffsets: map[int]int64{0: 41}}
+	if m.batchSize != 0 {
+		st.BatchSize = m.batchSize
+	}
+	if m.flushInterval != 0 {
+		st.FlushInterval = m.flushInterval.String()
+	}
+	return st
+}
+
+func newAdminServer(ctl httpadapter.Controller, token string) *httpadapter.Server {
+	return httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(),
+		httpadapter.WithAdmin(token, ctl, map[string]string{"ADMIN_TOKEN": "[redacted]", "BATCH_SIZE": "50", "BATCH_FLUSH_INTERVAL": "1s"}))
+}
+
+// statusBody is the decoded body of GET /admin/status.
+type statusBody struct {
+	Pipeline pipeline.Status   `json:"pipeline"`
+	Config   map[string]string `json:"config"`
+}
+
+func adminRequest(method, path, token string) *http.Request {
+	return adminRequestWithBody(method, path, token, "")
+}
+
+func adminRequestWithBody(method, path, token, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	ctl := &mockController{}
+	srv := newAdminServer(ctl, "s3cret")
+
+	for _, token := range []string{"", "wrong"} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/pause", token))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "token %q", token)
+	}
+	assert.False(t, ctl.paused)
+}
+
+func TestAdmin_PauseAndResume(t *testing.T) {
+	ctl := &mockController{}
+	srv := newAdminServer(ctl, "s3cret")
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/pause", "s3cret"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, ctl.paused)
+	assert.Contains(t, rec.Body.String(), `"state":"paused"`)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/resume", "s3cret"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, ctl.paused)
+	assert.Contains(t, rec.Body.String(), `"state":"running"`)
+}
+
+func TestAdmin_Status(t *testing.T) {
+	srv := newAdminServer(&mockController{}, "s3cret")
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/status", "s3cret"))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body statusBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, pipeline.StateRunning, body.Pipeline.State)
+	assert.Equal(t, 50, body.Pipeline.BatchSize)
+	assert.Equal(t, map[int]int64{0: 41}, body.Pipeline.CommittedOffsets)
+	assert.Equal(t, "[redacted]", body.Config["ADMIN_TOKEN"])
+}
+
+func TestAdmin_DisabledWithoutToken(t *testing.T) {
+	ctl := &mockController{}
+	srv := newAdminServer(ctl, "")
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/pause", ""))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.False(t, ctl.paused)
+}
+
+func TestAdmin_SetBatching(t *testing.T) {
+	ctl := &mockController{}
+	srv := newAdminServer(ctl, "s3cret")
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, adminRequestWithBody(http.MethodPut, "/admin/batching", "s3cret",
+		`{"batch_size":200,"flush_interval":"2s"}`))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 200, ctl.batchSize)
+	assert.Equal(t, 2*time.Second, ctl.flushInterval)
+}
+
+func TestAdmin_StatusConfigFollowsBatchingChanges(t *testing.T) {
+	srv := newAdminServer(&mockController{}, "s3cret")
+	readConfig := func() map[string]string {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/status", "s3cret"))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body statusBody
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body.Config
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, adminRequestWithBody(http.MethodPut, "/admin/batching", "s3cret", `{"batch_size":200}`))
+	require.Equal(t, http.StatusOK, rec.Code)
+	config := readConfig()
+	assert.Equal(t, "200", config["BATCH_SIZE"])
+	assert.Equal(t, "1s", config["BATCH_FLUSH_INTERVAL"], "an omitted setting is unchanged")
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, adminRequestWithBody(http.MethodPut, "/admin/batching", "s3cret", `{"flush_interval":"2s"}`))
+	require.Equal(t, http.StatusOK, rec.Code)
+	config = readConfig()
+	assert.Equal(t, "200", config["BATCH_SIZE"])
+	assert.Equal(t, "2s", config["BATCH_FLUSH_INTERVAL"])
+	assert.Equal(t, "[redacted]", config["ADMIN_TOKEN"])
+}
+
+func TestAdmin_SetBatching_Invalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"empty":         `{}`,
+		"unknown field": `{"batch":10}`,
+		"bad duration":  `{"flush_interval":"soon"}`,
+		"out of bounds": `{"batch_size":5000}`,
+		"not json":      `batch_size=10`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctl := &mockController{}
+			srv := newAdminServer(ctl, "s3cret")
+			rec := httptest.NewRecorder()
+
+			srv.ServeHTTP(rec, adminRequestWithBody(http.MethodPut, "/admin/batching", "s3cret", body))
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"error"`)
+			assert.Zero(t, ctl.batchSize)
+		})
+	}
+}
@@ -4,20 +4,34 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
 	sharedobs "github.com/couchcryptid/storm-data-shared/observability"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// Server exposes health, readiness, and metrics HTTP endpoints.
+// Server exposes health, readiness, and metrics HTTP endpoints, and
+// optionally the admin API.
 type Server struct {
 	httpServer *http.Server
+	mux        *http.ServeMux
 	logger     *slog.Logger
 }
 
+// Option configures optional Server routes.
+type Option func(*Server)
+
+// ReadinessReporter is implemented by readiness checkers that can report each
+// check individually, such as pipeline.Pipeline.
+type ReadinessReporter interface {
+	Readiness(ctx context.Context) []pipeline.ReadinessCheck
+}
+
 // NewServer creates an HTTP server with /healthz, /readyz, and /metrics routes.
-func NewServer(addr string, ready sharedobs.ReadinessChecker, logger *slog.Logger) *Server {
+// When ready also implements ReadinessReporter, /readyz lists every check.
+func NewServer(addr string, ready sharedobs.ReadinessChecker, logger *slog.Logger, opts ...Option) *Server {
 	mux := http.NewServeMux()
 
 	s := &Server{
@@ -28,16 +42,55 @@ func NewServer(addr string, ready sharedobs.ReadinessChecker, logger *slog.Logge
 			WriteTimeout: 10 * time.Second,
 			IdleTimeout:  60 * time.Second,
 		},
+		mux:    mux,
 		logger: logger,
 	}
 
 	mux.HandleFunc("GET /healthz", sharedobs.LivenessHandler())
-	mux.HandleFunc("GET /readyz", sharedobs.ReadinessHandler(ready))
+	if reporter, ok := ready.(ReadinessReporter); ok {
+		mux.HandleFunc("GET /readyz", readinessReportHandler(reporter))
+	} else {
+		mux.HandleFunc("GET /readyz", sharedobs.ReadinessHandler(ready))
+	}
 	mux.Handle("GET /metrics", promhttp.Handler())
 
+	for _, opt := range opts {
+		opt(s)
+	}
 	return s
 }
 
+// readinessReportHandler returns 200 when every check passes and 503
+// otherwise, with a JSON body listing each check. The status and error fields
+// match sharedobs.ReadinessHandler.
+func readinessReportHandler(reporter ReadinessReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		checks := reporter.Readiness(ctx)
+		var reasons []string
+		for _, c := range checks {
+			if !c.OK {
+				reasons = append(reasons, c.Name+": "+c.Reason)
+			}
+		}
+
+		body := struct {
+			Status string                    `json:"status"`
+			Error  string                    `json:"error,omitempty"`
+			Checks []pipeline.ReadinessCheck `json:"checks"`
+		}{Status: "ready", Checks: checks}
+		status := http.StatusOK
+		if len(reasons) > 0 {
+			body.Status = "not ready"
+			body.Error = strings.Join(reasons, "; ")
+			status = http.StatusServiceUnavailable
+		}
+		sharedobs.WriteJSON(w, status, body)
+	}
+}
+
 // Start begins listening. Returns http.ErrServerClosed on graceful shutdown.
 func (s *Server) Start() error {
 	s.logger.Info("http server starting", "addr", s.httpServer.Addr)
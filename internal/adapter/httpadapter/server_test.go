@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/couchcryptid/storm-data-etl/internal/adapter/httpadapter"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -67,6 +68,50 @@ func TestReadyzReturns503WhenNotReady(t *testing.T) {
 	assert.Equal(t, "not ready yet", body["error"])
 }
 
+type mockReporter struct {
+	mockReadiness
+	checks []pipeline.ReadinessCheck
+}
+
+func (m *mockReporter) Readiness(_ context.Context) []pipeline.ReadinessCheck { return m.checks }
+
+func TestReadyzListsChecks(t *testing.T) {
+	reporter := &mockReporter{checks: []pipeline.ReadinessCheck{
+		{Name: "extractor", OK: true},
+		{Name: "progress", Reason: "no batch completed in 6m0s with 1 in flight"},
+		{Name: "load_breaker", Reason: "load circuit breaker is open"},
+	}}
+	srv := httpadapter.NewServer(":0", reporter, slog.Default())
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	srv.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var body struct {
+		Status string                    `json:"status"`
+		Error  string                    `json:"error"`
+		Checks []pipeline.ReadinessCheck `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "not ready", body.Status)
+	assert.Equal(t, "progress: no batch completed in 6m0s with 1 in flight; load_breaker: load circuit breaker is open", body.Error)
+	assert.Equal(t, reporter.checks, body.Checks)
+}
+
+func TestReadyzReportReturns200WhenAllChecksPass(t *testing.T) {
+	reporter := &mockReporter{checks: []pipeline.ReadinessCheck{{Name: "extractor", OK: true}}}
+	srv := httpadapter.NewServer(":0", reporter, slog.Default())
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	srv.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ready","checks":[{"name":"extractor","ok":true}]}`, rec.Body.String())
+}
+
 func TestMetricsEndpoint(t *testing.T) {
 	srv := newTestServer(nil)
 	rec := httptest.NewRecorder()
@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// avroSchema is the Avro schema of the sink message. appendAvroEvent writes
+// its fields in order; TestAvroSchema_MatchesStormEvent catches drift.
+//
+//go:embed schemas/storm_event.avsc
+var avroSchema string
+
+// appendAvroEvent appends event in Avro binary encoding.
+func appendAvroEvent(b []byte, e domain.StormEvent) []byte {
+	b = appendAvroString(b, e.ID)
+	b = appendAvroString(b, e.EventType)
+	b = appendAvroGeo(b, e.Geo)
+
+	b = appendAvroDouble(b, e.Measurement.Magnitude)
+	b = appendAvroString(b, e.Measurement.Unit)
+	b = appendAvroOptionalString(b, e.Measurement.Severity)
+
+	b = appendAvroTime(b, e.EventTime)
+
+	loc := e.Location
+	b = appendAvroString(b, loc.Raw)
+	b = appendAvroString(b, loc.Name)
+	b = appendAvroOptionalDouble(b, loc.Distance)
+	b = appendAvroOptionalString(b, loc.Direction)
+	b = appendAvroString(b, loc.State)
+	b = appendAvroString(b, loc.County)
+	b = appendAvroString(b, loc.CountyFIPS)
+	b = appendAvroString(b, loc.CanonicalCounty)
+	b = appendAvroString(b, loc.StateFIPS)
+	b = appendAvroString(b, loc.ForecastZone)
+	b = appendAvroBool(b, loc.CountyMismatch)
+	if loc.ProjectedGeo == nil {
+		b = appendAvroLong(b, 0)
+	} else {
+		b = appendAvroLong(b, 1)
+		b = appendAvroGeo(b, *loc.ProjectedGeo)
+	}
+	b = appendAvroOptionalDouble(b, loc.GeoDiscrepancyKm)
+	b = appendAvroString(b, loc.GeoIssue)
+
+	b = appendAvroString(b, e.Comments)
+	b = appendAvroString(b, e.SourceOffice)
+	b = appendAvroTime(b, e.TimeBucket)
+
+	q := e.Quality
+	for _, paths := range [][]string{q.Defaulted, q.Unknown, q.Inferred, q.Corrected, q.Unparseable} {
+		b = appendAvroStrings(b, paths)
+	}
+
+	b = appendAvroString(b, e.SeverityRulesVersion)
+	b = appendAvroBool(b, e.Duplicate)
+	b = appendAvroLong(b, int64(e.Revision))
+	return appendAvroTime(b, e.ProcessedAt)
+}
+
+func appendAvroGeo(b []byte, g domain.Geo) []byte {
+	b = appendAvroDouble(b, g.Lat)
+	return appendAvroDouble(b, g.Lon)
+}
+
+// appendAvroLong appends v as a zigzag varint, the encoding of Avro int and long.
+func appendAvroLong(b []byte, v int64) []byte {
+	return binary.AppendUvarint(b, uint64(v<<1)^uint64(v>>63))
+}
+
+func appendAvroString(b []byte, s string) []byte {
+	b = appendAvroLong(b, int64(len(s)))
+	return append(b, s...)
+}
+
+func appendAvroDouble(b []byte, f float64) []byte {
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+}
+
+func appendAvroBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 1)
+	}
+	return append(b, 0)
+}
+
+// appendAvroTime appends t as a timestamp-micros long.
+func appendAvroTime(b []byte, t time.Time) []byte {
+	return appendAvroLong(b, t.UnixMicro())
+}
+
+// appendAvroOptionalString appends a ["null", "string"] union.
+func appendAvroOptionalString(b []byte, s *string) []byte {
+	if s == nil {
+		return appendAvroLong(b, 0)
+	}
+	return appendAvroString(appendAvroLong(b, 1), *s)
+}
+
+// appendAvroOptionalDouble appends a ["null", "double"] union.
+func appendAvroOptionalDouble(b []byte, f *float64) []byte {
+	if f == nil {
+		return appendAvroLong(b, 0)
+	}
+	return appendAvroDouble(appendAvroLong(b, 1), *f)
+}
+
+// appendAvroStrings appends a string array as a single block.
+func appendAvroStrings(b []byte, items []string) []byte {
+	if len(items) > 0 {
+		b = appendAvroLong(b, int64(len(items)))
+		for _, s := range items {
+			b = appendAvroString(b, s)
+		}
+	}
+	return appendAvroLong(b, 0)
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Dead-letter headers describing why and where a message failed. The original
+// message headers are carried over alongside these.
+const (
+	headerDLQError        = "dlq_error"
+	headerDLQSourceTopic  = "dlq_source_topic"
+	headerDLQSourcePart   = "dlq_source_partition"
+	headerDLQSourceOffset = "dlq_source_offset"
+	headerDLQFailedAt     = "dlq_failed_at"
+	deadLetterHeaderCount = 5
+)
+
+// DeadLetterWriter republishes raw messages that failed transformation to a
+// dead-letter topic. It implements pipeline.DeadLetterLoader.
+type DeadLetterWriter struct {
+	writer *kafkago.Writer
+	logger *slog.Logger
+}
+
+// NewDeadLetterWriter creates a Kafka producer for the configured dead-letter topic.
+func NewDeadLetterWriter(cfg *config.Config, logger *slog.Logger) *DeadLetterWriter {
+	w := &kafkago.Writer{
+		Addr:         kafkago.TCP(cfg.KafkaBrokers...),
+		Topic:        cfg.KafkaDLQTopic,
+		Balancer:     &kafkago.Hash{},
+		RequiredAcks: kafkago.RequireAll,
+	}
+	return &DeadLetterWriter{writer: w, logger: logger}
+}
+
+// LoadDeadLetter publishes the original key, value, and headers of raw along
+// with the failure reason and source coordinates.
+func (w *DeadLetterWriter) LoadDeadLetter(ctx context.Context, raw domain.RawEvent, cause error) error {
+	return w.writer.WriteMessages(ctx, buildDeadLetterMessage(raw, cause, time.Now()))
+}
+
+func (w *DeadLetterWriter) Close() error {
+	return w.writer.Close()
+}
+
+// buildDeadLetterMessage copies the raw message and appends failure headers.
+// The original Kafka timestamp is preserved so a replay derives the same
+// event date for legacy HHMM payloads.
+func buildDeadLetterMessage(raw domain.RawEvent, cause error, failedAt time.Time) kafkago.Message {
+	headers := make([]kafkago.Header, 0, len(raw.Headers)+deadLetterHeaderCount)
+	for _, k := range slices.Sorted(maps.Keys(raw.Headers)) {
+		// Drop stale failure headers from a previous dead-lettering of the same payload.
+		if strings.HasPrefix(k, "dlq_") {
+			continue
+		}
+		headers = append(headers, kafkago.Header{Key: k, Value: []byte(raw.Headers[k])})
+	}
+
+	reason := ""
+	if cause != nil {
+		reason = cause.Error()
+	}
+	headers = append(headers,
+		kafkago.Header{Key: headerDLQError, Value: []byte(reason)},
+		kafkago.Header{Key: headerDLQSourceTopic, Value: []byte(raw.Topic)},
+		kafkago.Header{Key: headerDLQSourcePart, Value: []byte(strconv.Itoa(raw.Partition))},
+		kafkago.Header{Key: headerDLQSourceOffset, Value: []byte(strconv.FormatInt(raw.Offset, 10))},
+		kafkago.Header{Key: headerDLQFailedAt, Value: []byte(failedAt.UTC().Format(time.RFC3339))},
+	)
+
+	return kafkago.Message{
+		Key:     raw.Key,
+		Value:   raw.Value,
+		Headers: headers,
+		Time:    raw.Timestamp,
+	}
+}
@@ -0,0 +1,223 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// GroupReader consumes the source topic as a member of the consumer group,
+// like Reader, but runs the group membership itself so that every message
+// records the generation it was fetched in. TransactionalWriter commits
+// offsets with that generation, and the coordinator rejects the commit if the
+// group has rebalanced since. Offsets are only committed through the
+// transaction, so GroupReader is not a pipeline.BatchCommitter.
+//
+// It implements pipeline.BatchExtractor, pipeline.ConnectivityChecker,
+// pipeline.LagReporter, and pipeline.FlushIntervalAdjuster.
+type GroupReader struct {
+	group         *kafkago.ConsumerGroup
+	brokers       []string
+	topic         string
+	flushInterval atomic.Int64 // time.Duration; read each ExtractBatch
+	logger        *slog.Logger
+
+	msgs   chan groupMessage
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu         sync.Mutex
+	generation *domain.GroupGeneration   // nil between generations
+	positions  map[int]partitionPosition // by partition, this generation
+}
+
+// groupMessage is a fetched message and the generation it was fetched in.
+type groupMessage struct {
+	msg        kafkago.Message
+	generation *domain.GroupGeneration
+}
+
+// NewGroupReader joins the configured consumer group for the source topic.
+func NewGroupReader(cfg *config.Config, logger *slog.Logger) (*GroupReader, error) {
+	group, err := kafkago.NewConsumerGroup(kafkago.ConsumerGroupConfig{
+		ID:          cfg.KafkaGroupID,
+		Brokers:     cfg.KafkaBrokers,
+		Topics:      []string{cfg.KafkaSourceTopic},
+		StartOffset: kafkago.FirstOffset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("consumer group: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &GroupReader{
+		group:     group,
+		brokers:   cfg.KafkaBrokers,
+		topic:     cfg.KafkaSourceTopic,
+		logger:    logger,
+		msgs:      make(chan groupMessage),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		positions: make(map[int]partitionPosition),
+	}
+	r.SetFlushInterval(cfg.BatchFlushInterval)
+	go r.run(ctx)
+	return r, nil
+}
+
+// run starts a fetcher for each partition assigned in every generation of the
+// group until ctx is cancelled or the group is closed.
+func (r *GroupReader) run(ctx context.Context) {
+	defer close(r.done)
+	for {
+		gen, err := r.group.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, kafkago.ErrGroupClosed) {
+				return
+			}
+			r.logger.Warn("consumer group generation failed", "error", err)
+			continue
+		}
+
+		g := &domain.GroupGeneration{ID: int(gen.ID), MemberID: gen.MemberID}
+		r.mu.Lock()
+		r.generation = g
+		r.positions = make(map[int]partitionPosition)
+		r.mu.Unlock()
+		r.logger.Info("joined consumer group generation",
+			"generation", g.ID, "partitions", len(gen.Assignments[r.topic]))
+
+		gen.Start(func(ctx context.Context) {
+			<-ctx.Done()
+			r.mu.Lock()
+			if r.generation == g {
+				r.generation = nil
+			}
+			r.mu.Unlock()
+		})
+		for _, a := range gen.Assignments[r.topic] {
+			gen.Start(func(ctx context.Context) { r.fetch(ctx, g, a) })
+		}
+	}
+}
+
+// fetch reads one assigned partition from its committed offset until the
+// generation ends. Returning early ends the generation, so a failed partition
+// is retried after the group rejoins.
+func (r *GroupReader) fetch(ctx context.Context, g *domain.GroupGeneration, a kafkago.PartitionAssignment) {
+	pr := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     r.topic,
+		Partition: a.ID,
+		MinBytes:  1,
+		MaxBytes:  10e6, // 10 MB
+	})
+	defer pr.Close()
+	if err := pr.SetOffset(a.Offset); err != nil {
+		r.logger.Error("seek partition failed", "partition", a.ID, "offset", a.Offset, "error", err)
+		return
+	}
+	for {
+		msg, err := pr.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("fetch failed", "partition", a.ID, "error", err)
+			}
+			return
+		}
+		select {
+		case r.msgs <- groupMessage{msg: msg, generation: g}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ExtractBatch returns up to batchSize messages from the current generation.
+// Returns a partial batch when the flush interval elapses or the context is
+// cancelled. Messages fetched in an earlier generation are discarded, since
+// their partitions are read again from the committed offset.
+func (r *GroupReader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	batch := make([]domain.RawEvent, 0, batchSize)
+	timer := time.NewTimer(r.FlushInterval())
+	defer timer.Stop()
+
+	for len(batch) < batchSize {
+		select {
+		case <-ctx.Done():
+			return batch, nil
+		case <-timer.C:
+			return batch, nil
+		case m := <-r.msgs:
+			if !r.current(m.generation) {
+				continue
+			}
+			if len(batch) > 0 && batch[0].Generation != m.generation {
+				batch = batch[:0]
+			}
+			r.trackPosition(m.msg)
+			raw := mapMessageToRawEvent(m.msg)
+			raw.Generation = m.generation
+			batch = append(batch, raw)
+		}
+	}
+	return batch, nil
+}
+
+func (r *GroupReader) current(g *domain.GroupGeneration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.generation == g
+}
+
+// FlushInterval returns how long ExtractBatch waits to fill a batch.
+func (r *GroupReader) FlushInterval() time.Duration {
+	return time.Duration(r.flushInterval.Load())
+}
+
+// SetFlushInterval changes how long ExtractBatch waits to fill a batch,
+// starting with the next call.
+func (r *GroupReader) SetFlushInterval(d time.Duration) {
+	r.flushInterval.Store(int64(d))
+}
+
+// CheckConnectivity returns nil if any configured broker accepts a connection.
+func (r *GroupReader) CheckConnectivity(ctx context.Context) error {
+	return checkConnectivity(ctx, r.brokers)
+}
+
+// Lag returns the total consumer lag across the partitions fetched from in
+// the current generation, as of the last message fetched from each.
+func (r *GroupReader) Lag() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lag int64
+	for _, pos := range r.positions {
+		lag += pos.lag
+	}
+	return lag
+}
+
+func (r *GroupReader) trackPosition(msg kafkago.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.positions[msg.Partition] = partitionPosition{
+		offset: msg.Offset,
+		lag:    max(msg.HighWaterMark-msg.Offset-1, 0),
+	}
+}
+
+// Close leaves the consumer group and stops the partition fetchers.
+func (r *GroupReader) Close() error {
+	r.cancel()
+	err := r.group.Close()
+	<-r.done
+	return err
+}
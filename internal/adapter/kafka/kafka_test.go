@@ -1,11 +1,23 @@
 package kafka
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/internal/schema"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,6 +47,342 @@ func TestMapMessageToRawEvent(t *testing.T) {
 	assert.Equal(t, "noaa", raw.Headers["source"])
 }
 
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestMapMessageToRawEvent_TraceContext(t *testing.T) {
+	raw := mapMessageToRawEvent(kafkago.Message{
+		Headers: []kafkago.Header{{Key: "traceparent", Value: []byte(testTraceparent)}},
+	})
+
+	require.True(t, raw.TraceContext.IsValid())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", raw.TraceContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", raw.TraceContext.SpanID().String())
+	assert.True(t, raw.TraceContext.IsRemote())
+
+	assert.False(t, mapMessageToRawEvent(kafkago.Message{}).TraceContext.IsValid())
+}
+
+func TestLatestOffsets(t *testing.T) {
+	events := []domain.RawEvent{
+		{Topic: "raw", Partition: 0, Offset: 10},
+		{Topic: "raw", Partition: 1, Offset: 4},
+		{Topic: "raw", Partition: 0, Offset: 12},
+		{Topic: "raw", Partition: 0, Offset: 11},
+		{Topic: "raw", Partition: 1, Offset: 5},
+	}
+
+	msgs := latestOffsets(events)
+
+	require.Len(t, msgs, 2)
+	assert.Equal(t, kafkago.Message{Topic: "raw", Partition: 0, Offset: 12}, msgs[0])
+	assert.Equal(t, kafkago.Message{Topic: "raw", Partition: 1, Offset: 5}, msgs[1])
+}
+
+func TestTxnOffsets(t *testing.T) {
+	events := []domain.RawEvent{
+		{Topic: "raw", Partition: 0, Offset: 10},
+		{Topic: "raw", Partition: 1, Offset: 4},
+		{Topic: "raw", Partition: 0, Offset: 12},
+	}
+
+	assert.Equal(t, map[string][]kafkago.TxnOffsetCommit{
+		"raw": {{Partition: 0, Offset: 13}, {Partition: 1, Offset: 5}},
+	}, txnOffsets(events), "the committed offset is the next one to consume")
+	assert.Empty(t, txnOffsets(nil))
+}
+
+func TestTransactionalRecordSet(t *testing.T) {
+	at := time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		producerID int64
+		epoch      int16
+		sequence   int32
+	}{
+		{name: "first batch", producerID: 4001, epoch: 0, sequence: 0},
+		{name: "later batch", producerID: 4001, epoch: 3, sequence: 17},
+		{name: "high bits set", producerID: 1<<62 + 5, epoch: 1<<15 - 1, sequence: 1<<31 - 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Record keys and values are readers, consumed by encoding.
+			records := []kafkago.Record{
+				{Time: at, Key: kafkago.NewBytes([]byte("hail-1")), Value: kafkago.NewBytes([]byte(`{"id":"hail-1"}`)),
+					Headers: []kafkago.Header{{Key: "event_action", Value: []byte("create")}}},
+				{Time: at, Key: kafkago.NewBytes([]byte("wind-2")), Value: kafkago.NewBytes([]byte(`{"id":"wind-2"}`))},
+			}
+			set, err := transactionalRecordSet(records, tt.producerID, tt.epoch, tt.sequence)
+			require.NoError(t, err)
+			data, err := io.ReadAll(set.Reader)
+			require.NoError(t, err)
+
+			// Field positions from the v2 record batch layout, after the
+			// 4-byte size prefix.
+			require.Equal(t, len(data)-4, int(binary.BigEndian.Uint32(data)), "size prefix")
+			batch := data[4:]
+			assert.Equal(t, int64(0), int64(binary.BigEndian.Uint64(batch[0:])), "base offset")
+			assert.Equal(t, len(batch)-12, int(binary.BigEndian.Uint32(batch[8:])), "batch length")
+			assert.Equal(t, byte(2), batch[16], "magic")
+			assert.Equal(t, crc32.Checksum(batch[21:], crc32.MakeTable(crc32.Castagnoli)), binary.BigEndian.Uint32(batch[17:]),
+				"CRC-32C covers the patched fields")
+			assert.NotZero(t, binary.BigEndian.Uint16(batch[21:])&uint16(protocol.Transactional), "transactional attribute")
+			assert.Equal(t, int32(len(records)-1), int32(binary.BigEndian.Uint32(batch[23:])), "last offset delta")
+			assert.Equal(t, tt.producerID, int64(binary.BigEndian.Uint64(batch[43:])), "producer ID")
+			assert.Equal(t, tt.epoch, int16(binary.BigEndian.Uint16(batch[51:])), "producer epoch")
+			assert.Equal(t, tt.sequence, int32(binary.BigEndian.Uint32(batch[53:])), "base sequence")
+			assert.Equal(t, int32(len(records)), int32(binary.BigEndian.Uint32(batch[57:])), "record count")
+
+			// kafka-go's decoder verifies the checksum.
+			var rs protocol.RecordSet
+			_, err = rs.ReadFrom(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.True(t, rs.Attributes.Transactional())
+			rb := firstRecordBatch(t, rs.Records)
+			assert.Equal(t, tt.producerID, rb.ProducerID)
+			assert.Equal(t, tt.epoch, rb.ProducerEpoch)
+			assert.Equal(t, tt.sequence, rb.BaseSequence)
+
+			var keys []string
+			for {
+				r, err := rb.ReadRecord()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				key, err := protocol.ReadAll(r.Key)
+				require.NoError(t, err)
+				keys = append(keys, string(key))
+			}
+			assert.Equal(t, []string{"hail-1", "wind-2"}, keys)
+
+			corrupt := bytes.Clone(data)
+			corrupt[4+53]++ // base sequence, covered by the checksum
+			_, err = new(protocol.RecordSet).ReadFrom(bytes.NewReader(corrupt))
+			assert.ErrorContains(t, err, "checksum")
+		})
+	}
+}
+
+// firstRecordBatch returns the first v2 batch of a decoded record set.
+func firstRecordBatch(t *testing.T, r protocol.RecordReader) *protocol.RecordBatch {
+	t.Helper()
+	switch r := r.(type) {
+	case *protocol.RecordBatch:
+		return r
+	case *protocol.RecordStream:
+		require.NotEmpty(t, r.Records)
+		return firstRecordBatch(t, r.Records[0])
+	}
+	t.Fatalf("unexpected record reader %T", r)
+	return nil
+}
+
+func TestTransactionalWriter_RequiresOpenTransaction(t *testing.T) {
+	w := &TransactionalWriter{serializer: JSONSerializer{}, logger: slog.Default()}
+
+	assert.ErrorContains(t, w.LoadBatch(context.Background(), []domain.StormEvent{{ID: "evt-1"}}), "no open transaction")
+	assert.ErrorContains(t, w.CommitTxn(context.Background(), nil), "no open transaction")
+	assert.NoError(t, w.AbortTxn(context.Background()), "nothing to abort")
+}
+
+func TestBatchGeneration(t *testing.T) {
+	gen1 := &domain.GroupGeneration{ID: 1, MemberID: "member-a"}
+	gen2 := &domain.GroupGeneration{ID: 2, MemberID: "member-a"}
+
+	tests := []struct {
+		name       string
+		events     []domain.RawEvent
+		expected   *domain.GroupGeneration
+		errMsg     string
+		rebalanced bool
+	}{
+		{name: "one generation", events: []domain.RawEvent{{Generation: gen2}, {Generation: &domain.GroupGeneration{ID: 2, MemberID: "member-a"}}}, expected: gen2},
+		{name: "no generation", events: []domain.RawEvent{{}}, errMsg: "no consumer group generation"},
+		{name: "spans generations", events: []domain.RawEvent{{Generation: gen1}, {Generation: gen2}}, errMsg: "spans generations", rebalanced: true},
+		{name: "partly without a generation", events: []domain.RawEvent{{Generation: gen1}, {}}, errMsg: "spans generations", rebalanced: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := batchGeneration(tt.events)
+			if tt.errMsg != "" {
+				require.ErrorContains(t, err, tt.errMsg)
+				assert.Equal(t, tt.rebalanced, errors.Is(err, pipeline.ErrRebalanced))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, gen)
+		})
+	}
+}
+
+func TestIsRebalanced(t *testing.T) {
+	assert.True(t, isRebalanced(kafkago.IllegalGeneration))
+	assert.True(t, isRebalanced(kafkago.UnknownMemberId))
+	assert.True(t, isRebalanced(kafkago.RebalanceInProgress))
+	assert.True(t, isRebalanced(kafkago.FencedInstanceID))
+	assert.False(t, isRebalanced(kafkago.NotCoordinatorForGroup))
+	assert.False(t, isRebalanced(errors.New("connection reset")))
+}
+
+func TestGroupReader_ExtractBatchDropsEarlierGenerations(t *testing.T) {
+	gen1 := &domain.GroupGeneration{ID: 1, MemberID: "member-a"}
+	gen2 := &domain.GroupGeneration{ID: 2, MemberID: "member-a"}
+	r := &GroupReader{
+		logger:     slog.Default(),
+		msgs:       make(chan groupMessage),
+		generation: gen1,
+		positions:  make(map[int]partitionPosition),
+	}
+	r.SetFlushInterval(time.Second)
+
+	go func() {
+		r.msgs <- groupMessage{msg: kafkago.Message{Partition: 0, Offset: 5, HighWaterMark: 20}, generation: gen1}
+		// The group rebalances: partition 0 moves away and 1 is assigned.
+		r.mu.Lock()
+		r.generation = gen2
+		r.positions = make(map[int]partitionPosition)
+		r.mu.Unlock()
+		r.msgs <- groupMessage{msg: kafkago.Message{Partition: 0, Offset: 6, HighWaterMark: 20}, generation: gen1}
+		r.msgs <- groupMessage{msg: kafkago.Message{Partition: 1, Offset: 3, HighWaterMark: 5}, generation: gen2}
+		r.msgs <- groupMessage{msg: kafkago.Message{Partition: 1, Offset: 4, HighWaterMark: 5}, generation: gen2}
+	}()
+
+	batch, err := r.ExtractBatch(context.Background(), 3)
+	require.NoError(t, err)
+	require.Len(t, batch, 2, "the first generation's messages are dropped")
+	for _, raw := range batch {
+		assert.Equal(t, 1, raw.Partition)
+		assert.Same(t, gen2, raw.Generation)
+	}
+	assert.Equal(t, int64(0), r.Lag(), "the revoked partition no longer counts towards lag")
+}
+
+func newPositionReader() *Reader {
+	return &Reader{
+		reader:    kafkago.NewReader(kafkago.ReaderConfig{Brokers: []string{"localhost:9092"}, Topic: "raw"}),
+		positions: make(map[int]partitionPosition),
+		reported:  make(map[int]bool),
+	}
+}
+
+func TestReader_TracksPartitionPositions(t *testing.T) {
+	r := newPositionReader()
+	defer r.Close()
+
+	r.trackPosition(kafkago.Message{Partition: 0, Offset: 10, HighWaterMark: 21})
+	r.trackPosition(kafkago.Message{Partition: 1, Offset: 4, HighWaterMark: 5})
+	r.trackPosition(kafkago.Message{Partition: 0, Offset: 15, HighWaterMark: 21})
+
+	assert.Equal(t, int64(5), r.Lag())
+
+	metrics := observability.NewMetricsForTesting()
+	r.ReportStats(metrics)
+	assert.InDelta(t, 15, testutil.ToFloat64(metrics.FetchedOffset.WithLabelValues("0")), 0)
+	assert.InDelta(t, 5, testutil.ToFloat64(metrics.ConsumerLag.WithLabelValues("0")), 0)
+	assert.InDelta(t, 4, testutil.ToFloat64(metrics.FetchedOffset.WithLabelValues("1")), 0)
+	assert.InDelta(t, 0, testutil.ToFloat64(metrics.ConsumerLag.WithLabelValues("1")), 0)
+}
+
+func TestReader_RebalanceForgetsRevokedPartitions(t *testing.T) {
+	r := newPositionReader()
+	defer r.Close()
+	metrics := observability.NewMetricsForTesting()
+
+	r.trackPosition(kafkago.Message{Partition: 0, Offset: 10, HighWaterMark: 21})
+	r.trackPosition(kafkago.Message{Partition: 1, Offset: 4, HighWaterMark: 105})
+	r.ReportStats(metrics)
+	require.Equal(t, int64(110), r.Lag())
+
+	// Partition 1 is revoked; partition 0 is kept and fetched from again.
+	r.observeStats(kafkago.ReaderStats{Rebalances: 1})
+	r.trackPosition(kafkago.Message{Partition: 0, Offset: 11, HighWaterMark: 21})
+
+	assert.Equal(t, int64(9), r.Lag(), "the revoked partition's lag no longer counts")
+
+	r.ReportStats(metrics)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.KafkaRebalances), 0)
+	assert.InDelta(t, 9, testutil.ToFloat64(metrics.ConsumerLag.WithLabelValues("0")), 0)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.ConsumerLag), "the revoked partition's gauge is removed")
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.FetchedOffset))
+}
+
+func TestWriter_LoadBatch_OversizedMessagesArePermanent(t *testing.T) {
+	// kafka-go rejects oversized messages before contacting the broker.
+	w := &Writer{
+		writer:     &kafkago.Writer{Addr: kafkago.TCP("127.0.0.1:1"), Topic: "sink", BatchBytes: 16},
+		serializer: JSONSerializer{},
+		logger:     slog.Default(),
+	}
+	events := []domain.StormEvent{
+		{ID: "evt-1", EventType: "hail", Comments: "large hail reported"},
+		{ID: "evt-2", EventType: "wind", Comments: "trees down"},
+	}
+
+	err := w.LoadBatch(context.Background(), events)
+
+	var ble *pipeline.BatchLoadError
+	require.ErrorAs(t, err, &ble)
+	require.Len(t, ble.Errs, 2)
+	for _, e := range ble.Errs {
+		assert.True(t, pipeline.IsPermanent(e))
+		assert.ErrorIs(t, e, kafkago.MessageSizeTooLarge)
+	}
+}
+
+type failingRegistry struct{}
+
+func (failingRegistry) Register(context.Context, string, Schema) (int, error) {
+	return 0, errors.New("registry unavailable")
+}
+
+func TestWriter_LoadBatch_RegistryErrorIsRetryable(t *testing.T) {
+	w := &Writer{
+		writer:     &kafkago.Writer{Addr: kafkago.TCP("127.0.0.1:1"), Topic: "sink"},
+		serializer: NewAvroSerializer(failingRegistry{}, "sink-value"),
+		logger:     slog.Default(),
+	}
+
+	err := w.LoadBatch(context.Background(), []domain.StormEvent{{ID: "evt-1"}})
+
+	require.ErrorContains(t, err, "registry unavailable")
+	var ble *pipeline.BatchLoadError
+	assert.False(t, errors.As(err, &ble))
+	assert.False(t, pipeline.IsPermanent(err))
+}
+
+func TestWriter_LoadBatch_SchemaViolationsArePermanent(t *testing.T) {
+	// Both events fail validation, so nothing reaches the unreachable broker.
+	w := &Writer{
+		writer:     &kafkago.Writer{Addr: kafkago.TCP("127.0.0.1:1"), Topic: "sink"},
+		serializer: JSONSerializer{},
+		validate:   true,
+		logger:     slog.Default(),
+	}
+	events := []domain.StormEvent{
+		{ID: "evt-1", EventType: "hail"},
+		{ID: "hail-0123456789abcdef", EventType: "sleet"},
+	}
+
+	err := w.LoadBatch(context.Background(), events)
+
+	var ble *pipeline.BatchLoadError
+	require.ErrorAs(t, err, &ble)
+	require.Len(t, ble.Errs, 2)
+	for _, e := range ble.Errs {
+		assert.True(t, pipeline.IsPermanent(e))
+		var violations schema.Violations
+		assert.ErrorAs(t, e, &violations)
+	}
+	assert.ErrorContains(t, ble.Errs[0], `id: "evt-1" does not match`)
+	assert.ErrorContains(t, ble.Errs[1], `event_type: "sleet" is not one of`)
+}
+
+func TestWriteError(t *testing.T) {
+	assert.True(t, pipeline.IsPermanent(writeError(kafkago.MessageSizeTooLarge)))
+	assert.False(t, pipeline.IsPermanent(writeError(kafkago.LeaderNotAvailable)))
+}
+
 func TestSerializeToMessage(t *testing.T) {
 	now := time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
 	event := domain.StormEvent{
@@ -44,14 +392,116 @@ func TestSerializeToMessage(t *testing.T) {
 		ProcessedAt: now,
 	}
 
-	msg, err := serializeToMessage(event)
+	msg, err := serializeToMessage(JSONSerializer{}, event)
 	require.NoError(t, err)
 
 	assert.Equal(t, []byte("evt-1"), msg.Key)
 	assert.Contains(t, string(msg.Value), `"event_type":"hail"`)
-	assert.Len(t, msg.Headers, 2)
+	assert.Len(t, msg.Headers, 4)
 	assert.Equal(t, "event_type", msg.Headers[0].Key)
 	assert.Equal(t, []byte("hail"), msg.Headers[0].Value)
 	assert.Equal(t, "processed_at", msg.Headers[1].Key)
 	assert.Equal(t, []byte(now.Format(time.RFC3339)), msg.Headers[1].Value)
+	assert.Equal(t, "event_action", msg.Headers[2].Key)
+	assert.Equal(t, []byte("create"), msg.Headers[2].Value)
+	assert.Equal(t, "schema_version", msg.Headers[3].Key)
+	assert.Equal(t, []byte(schema.Version), msg.Headers[3].Value)
+}
+
+func TestSerializeToMessage_TraceContext(t *testing.T) {
+	raw := mapMessageToRawEvent(kafkago.Message{
+		Headers: []kafkago.Header{{Key: "traceparent", Value: []byte(testTraceparent)}},
+	})
+	event := domain.StormEvent{ID: "evt-1", EventType: "hail", TraceContext: raw.TraceContext}
+
+	msg, err := serializeToMessage(JSONSerializer{}, event)
+	require.NoError(t, err)
+
+	require.Len(t, msg.Headers, 5)
+	assert.Equal(t, "traceparent", msg.Headers[4].Key)
+	assert.Equal(t, testTraceparent, string(msg.Headers[4].Value))
+	assert.NotContains(t, string(msg.Value), "trace")
+}
+
+func TestSerializeToMessage_Revision(t *testing.T) {
+	event := domain.StormEvent{ID: "evt-1", EventType: "hail", Revision: 2}
+
+	msg, err := serializeToMessage(JSONSerializer{}, event)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("update"), msg.Headers[2].Value)
+	assert.Contains(t, string(msg.Value), `"revision":2`)
+}
+
+func TestBuildDeadLetterMessage(t *testing.T) {
+	ts := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+	failedAt := time.Date(2024, 4, 27, 6, 0, 0, 0, time.UTC)
+	raw := domain.RawEvent{
+		Key:       []byte("key-1"),
+		Value:     []byte("not-json{{{"),
+		Headers:   map[string]string{"source": "collector", "dlq_error": "stale"},
+		Topic:     "raw-weather-reports",
+		Partition: 3,
+		Offset:    99,
+		Timestamp: ts,
+	}
+
+	msg := buildDeadLetterMessage(raw, errors.New("parse raw event: invalid character"), failedAt)
+
+	assert.Equal(t, []byte("key-1"), msg.Key)
+	assert.Equal(t, []byte("not-json{{{"), msg.Value)
+	assert.Equal(t, ts, msg.Time)
+
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Len(t, msg.Headers, 6)
+	assert.Equal(t, "collector", headers["source"])
+	assert.Equal(t, "parse raw event: invalid character", headers["dlq_error"])
+	assert.Equal(t, "raw-weather-reports", headers["dlq_source_topic"])
+	assert.Equal(t, "3", headers["dlq_source_partition"])
+	assert.Equal(t, "99", headers["dlq_source_offset"])
+	assert.Equal(t, "2024-04-27T06:00:00Z", headers["dlq_failed_at"])
+}
+
+func TestSerializeToMessage_DuplicateHeader(t *testing.T) {
+	event := domain.StormEvent{ID: "evt-1", EventType: "hail", Duplicate: true}
+
+	msg, err := serializeToMessage(JSONSerializer{}, event)
+	require.NoError(t, err)
+
+	require.Len(t, msg.Headers, 5)
+	assert.Equal(t, "duplicate", msg.Headers[4].Key)
+	assert.Equal(t, []byte("true"), msg.Headers[4].Value)
+	assert.Contains(t, string(msg.Value), `"duplicate":true`)
+}
+
+func TestRange_Bounds(t *testing.T) {
+	tests := []struct {
+		name                     string
+		r                        Range
+		sinceOffset, untilOffset int64
+		start, end               int64
+	}{
+		{"unbounded", Range{FromOffset: -1, ToOffset: -1}, -1, -1, 10, 100},
+		{"offsets", Range{FromOffset: 20, ToOffset: 29}, -1, -1, 20, 30},
+		{"offsets beyond log", Range{FromOffset: 0, ToOffset: 500}, -1, -1, 10, 100},
+		{"times", Range{FromOffset: -1, ToOffset: -1}, 40, 60, 40, 60},
+		{"offsets and times intersect", Range{FromOffset: 50, ToOffset: 90}, 40, 60, 50, 60},
+		{"empty", Range{FromOffset: 200, ToOffset: -1}, -1, -1, 200, 200},
+	}
+	for _, tt := range tests {
+		start, end := tt.r.bounds(10, 100, tt.sinceOffset, tt.untilOffset)
+		assert.Equal(t, tt.start, start, tt.name)
+		assert.Equal(t, tt.end, end, tt.name)
+	}
+}
+
+func TestRange_Validate(t *testing.T) {
+	t0 := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, Range{FromOffset: -1, ToOffset: -1}.Validate())
+	require.NoError(t, Range{FromOffset: 5, ToOffset: 5, Since: t0, Until: t0.Add(time.Hour)}.Validate())
+	assert.Error(t, Range{FromOffset: 5, ToOffset: 4}.Validate())
+	assert.Error(t, Range{FromOffset: -1, ToOffset: -1, Since: t0, Until: t0}.Validate())
 }
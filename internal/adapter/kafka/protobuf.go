@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	_ "embed"
+	"math"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// protobufSchema is the Protobuf schema of the sink message.
+// appendProtobufEvent writes its fields by number;
+// TestProtobufSchema_MatchesStormEvent catches drift.
+//
+//go:embed schemas/storm_event.proto
+var protobufSchema string
+
+// appendProtobufEvent appends event as a storm.v1.StormEvent message. Scalar
+// fields at their zero value are omitted, as proto3 requires; optional fields
+// are written whenever they are set.
+func appendProtobufEvent(b []byte, e domain.StormEvent) []byte {
+	b = appendProtoString(b, 1, e.ID)
+	b = appendProtoString(b, 2, e.EventType)
+	b = appendProtoMessage(b, 3, appendProtoGeo(nil, e.Geo))
+
+	var m []byte
+	m = appendProtoDouble(m, 1, e.Measurement.Magnitude)
+	m = appendProtoString(m, 2, e.Measurement.Unit)
+	if e.Measurement.Severity != nil {
+		m = appendProtoStringAlways(m, 3, *e.Measurement.Severity)
+	}
+	b = appendProtoMessage(b, 4, m)
+
+	b = appendProtoMessage(b, 5, appendProtoTimestamp(nil, e.EventTime))
+
+	loc := e.Location
+	var l []byte
+	l = appendProtoString(l, 1, loc.Raw)
+	l = appendProtoString(l, 2, loc.Name)
+	if loc.Distance != nil {
+		l = appendProtoDoubleAlways(l, 3, *loc.Distance)
+	}
+	if loc.Direction != nil {
+		l = appendProtoStringAlways(l, 4, *loc.Direction)
+	}
+	l = appendProtoString(l, 5, loc.State)
+	l = appendProtoString(l, 6, loc.County)
+	l = appendProtoString(l, 7, loc.CountyFIPS)
+	l = appendProtoString(l, 8, loc.CanonicalCounty)
+	l = appendProtoString(l, 9, loc.StateFIPS)
+	l = appendProtoString(l, 10, loc.ForecastZone)
+	l = appendProtoBool(l, 11, loc.CountyMismatch)
+	if loc.ProjectedGeo != nil {
+		l = appendProtoMessage(l, 12, appendProtoGeo(nil, *loc.ProjectedGeo))
+	}
+	if loc.GeoDiscrepancyKm != nil {
+		l = appendProtoDoubleAlways(l, 13, *loc.GeoDiscrepancyKm)
+	}
+	l = appendProtoString(l, 14, loc.GeoIssue)
+	b = appendProtoMessage(b, 6, l)
+
+	b = appendProtoString(b, 7, e.Comments)
+	b = appendProtoString(b, 8, e.SourceOffice)
+	b = appendProtoMessage(b, 9, appendProtoTimestamp(nil, e.TimeBucket))
+
+	q := e.Quality
+	var qb []byte
+	for i, paths := range [][]string{q.Defaulted, q.Unknown, q.Inferred, q.Corrected, q.Unparseable} {
+		for _, p := range paths {
+			qb = appendProtoStringAlways(qb, protowire.Number(i+1), p)
+		}
+	}
+	b = appendProtoMessage(b, 10, qb)
+
+	b = appendProtoString(b, 11, e.SeverityRulesVersion)
+	b = appendProtoBool(b, 12, e.Duplicate)
+	if e.Revision != 0 {
+		b = protowire.AppendTag(b, 13, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(e.Revision)))
+	}
+	return appendProtoMessage(b, 14, appendProtoTimestamp(nil, e.ProcessedAt))
+}
+
+func appendProtoGeo(b []byte, g domain.Geo) []byte {
+	b = appendProtoDouble(b, 1, g.Lat)
+	return appendProtoDouble(b, 2, g.Lon)
+}
+
+// appendProtoTimestamp appends the fields of a google.protobuf.Timestamp.
+func appendProtoTimestamp(b []byte, t time.Time) []byte {
+	if secs := t.Unix(); secs != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(secs))
+	}
+	if nanos := t.Nanosecond(); nanos != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(nanos))
+	}
+	return b
+}
+
+// appendProtoMessage appends an embedded message. Empty messages are still
+// written so the field reads as present.
+func appendProtoMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendProtoString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendProtoStringAlways(b, num, s)
+}
+
+func appendProtoStringAlways(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendProtoDouble(b []byte, num protowire.Number, f float64) []byte {
+	if f == 0 && !math.Signbit(f) {
+		return b
+	}
+	return appendProtoDoubleAlways(b, num, f)
+}
+
+func appendProtoDoubleAlways(b []byte, num protowire.Number, f float64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(f))
+}
+
+func appendProtoBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
@@ -0,0 +1,252 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// rangeIdleTimeout is how long RangeReader waits for a message it expects
+// before deciding the rest of the partition's range is unreadable, e.g.
+// because it ends in transaction markers.
+const rangeIdleTimeout = 5 * time.Second
+
+// Range bounds the messages a RangeReader reads. Negative offsets and zero
+// times are unbounded. ToOffset is inclusive; Until is exclusive.
+type Range struct {
+	FromOffset int64
+	ToOffset   int64
+	Since      time.Time
+	Until      time.Time
+}
+
+// Validate reports a range that can never match anything.
+func (r Range) Validate() error {
+	if r.FromOffset >= 0 && r.ToOffset >= 0 && r.ToOffset < r.FromOffset {
+		return fmt.Errorf("to offset %d is before from offset %d", r.ToOffset, r.FromOffset)
+	}
+	if !r.Since.IsZero() && !r.Until.IsZero() && !r.Until.After(r.Since) {
+		return fmt.Errorf("until %s is not after since %s", r.Until.Format(time.RFC3339), r.Since.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// bounds returns the half-open offset range [start, end) to read from a
+// partition whose log spans [first, last). sinceOffset and untilOffset are
+// the partition's offsets for Since and Until, or -1 when unset.
+func (r Range) bounds(first, last, sinceOffset, untilOffset int64) (start, end int64) {
+	start = max(first, r.FromOffset, sinceOffset)
+	end = last
+	if r.ToOffset >= 0 {
+		end = min(end, r.ToOffset+1)
+	}
+	if untilOffset >= 0 {
+		end = min(end, untilOffset)
+	}
+	return start, max(start, end)
+}
+
+// RangeReader reads a bounded range of every partition of a topic, without a
+// consumer group, and returns io.EOF once the range is exhausted. The upper
+// bound is fixed when it opens, so messages produced later are not read.
+// Successive batches rotate through the partitions so partition workers can
+// process them in parallel. Nothing is committed; resume with SkipTo.
+// It implements pipeline.BatchExtractor.
+type RangeReader struct {
+	brokers []string
+	topic   string
+	parts   []*partitionRange // partitions with messages left to read
+	next    int               // index into parts of the next partition to read
+	logger  *slog.Logger
+}
+
+// partitionRange is what is left to read of one partition.
+type partitionRange struct {
+	id     int
+	start  int64 // next offset to read
+	end    int64 // exclusive
+	reader *kafkago.Reader
+}
+
+// OpenRange resolves r to an offset range for each partition of topic.
+func OpenRange(ctx context.Context, brokers []string, topic string, r Range, logger *slog.Logger) (*RangeReader, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("no Kafka brokers configured")
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	conn, err := kafkago.DialContext(ctx, "tcp", brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("dial kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(topic)
+	_ = conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read partitions for %s: %w", topic, err)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].ID < partitions[j].ID })
+
+	rr := &RangeReader{brokers: brokers, topic: topic, logger: logger}
+	for _, p := range partitions {
+		start, end, err := resolveRange(ctx, brokers[0], topic, p.ID, r)
+		if err != nil {
+			return nil, err
+		}
+		if start < end {
+			rr.parts = append(rr.parts, &partitionRange{id: p.ID, start: start, end: end})
+		}
+	}
+	return rr, nil
+}
+
+// resolveRange looks up the partition's offsets and the offsets of the time
+// bounds, and applies r to them.
+func resolveRange(ctx context.Context, broker, topic string, partition int, r Range) (start, end int64, err error) {
+	leader, err := kafkago.DialLeader(ctx, "tcp", broker, topic, partition)
+	if err != nil {
+		return 0, 0, fmt.Errorf("dial leader for %s/%d: %w", topic, partition, err)
+	}
+	defer leader.Close()
+
+	first, last, err := leader.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("read offsets for %s/%d: %w", topic, partition, err)
+	}
+	sinceOffset, untilOffset := int64(-1), int64(-1)
+	if !r.Since.IsZero() {
+		if sinceOffset, err = offsetAt(leader, r.Since, last); err != nil {
+			return 0, 0, fmt.Errorf("find %s/%d offset at %s: %w", topic, partition, r.Since.Format(time.RFC3339), err)
+		}
+	}
+	if !r.Until.IsZero() {
+		if untilOffset, err = offsetAt(leader, r.Until, last); err != nil {
+			return 0, 0, fmt.Errorf("find %s/%d offset at %s: %w", topic, partition, r.Until.Format(time.RFC3339), err)
+		}
+	}
+	start, end = r.bounds(first, last, sinceOffset, untilOffset)
+	return start, end, nil
+}
+
+// offsetAt returns the first offset with a timestamp at or after t, or last
+// if there is none.
+func offsetAt(leader *kafkago.Conn, t time.Time, last int64) (int64, error) {
+	offset, err := leader.ReadOffset(t)
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return last, nil
+	}
+	return offset, nil
+}
+
+// Remaining returns how many offsets are left in the range. Compacted or
+// transactional topics can have fewer messages than offsets.
+func (r *RangeReader) Remaining() int64 {
+	var n int64
+	for _, p := range r.parts {
+		n += p.end - p.start
+	}
+	return n
+}
+
+// SkipTo moves a partition's start forward to offset. Call it before the
+// first ExtractBatch to resume an earlier read.
+func (r *RangeReader) SkipTo(partition int, offset int64) {
+	for i, p := range r.parts {
+		if p.id != partition {
+			continue
+		}
+		p.start = max(p.start, offset)
+		if p.start >= p.end {
+			r.parts = append(r.parts[:i], r.parts[i+1:]...)
+		}
+		return
+	}
+}
+
+// ExtractBatch reads up to batchSize messages from the next partition in turn.
+// It returns io.EOF once every partition's range has been read.
+func (r *RangeReader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	for len(r.parts) > 0 {
+		i := r.next % len(r.parts)
+		p := r.parts[i]
+		batch, err := r.read(ctx, p, batchSize)
+		if p.start >= p.end {
+			if p.reader != nil {
+				_ = p.reader.Close()
+			}
+			r.parts = append(r.parts[:i], r.parts[i+1:]...)
+		} else {
+			r.next = i + 1
+		}
+		if len(batch) > 0 {
+			return batch, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, io.EOF
+}
+
+// read fetches up to batchSize messages from p, opening its reader on first
+// use.
+func (r *RangeReader) read(ctx context.Context, p *partitionRange, batchSize int) ([]domain.RawEvent, error) {
+	if p.reader == nil {
+		p.reader = kafkago.NewReader(kafkago.ReaderConfig{
+			Brokers:   r.brokers,
+			Topic:     r.topic,
+			Partition: p.id,
+			MinBytes:  1,
+			MaxBytes:  10e6, // 10 MB
+		})
+		if err := p.reader.SetOffset(p.start); err != nil {
+			_ = p.reader.Close()
+			p.reader = nil
+			return nil, fmt.Errorf("seek %s/%d to %d: %w", r.topic, p.id, p.start, err)
+		}
+	}
+
+	batch := make([]domain.RawEvent, 0, batchSize)
+	for len(batch) < batchSize && p.start < p.end {
+		fetchCtx, cancel := context.WithTimeout(ctx, rangeIdleTimeout)
+		msg, err := p.reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				r.logger.Warn("no more messages in range, skipping rest of partition",
+					"topic", r.topic, "partition", p.id, "offset", p.start, "end", p.end)
+				p.start = p.end
+				break
+			}
+			return batch, fmt.Errorf("read %s/%d: %w", r.topic, p.id, err)
+		}
+		if msg.Offset >= p.end {
+			p.start = p.end
+			break
+		}
+		batch = append(batch, mapMessageToRawEvent(msg))
+		p.start = msg.Offset + 1
+	}
+	return batch, nil
+}
+
+// Close closes the readers of partitions still being read.
+func (r *RangeReader) Close() error {
+	var errs []error
+	for _, p := range r.parts {
+		if p.reader != nil {
+			errs = append(errs, p.reader.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -2,7 +2,10 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/config"
@@ -11,11 +14,26 @@ import (
 )
 
 // Reader consumes messages from a Kafka topic.
-// It implements pipeline.BatchExtractor.
+// It implements pipeline.BatchExtractor, pipeline.BatchCommitter,
+// pipeline.ConnectivityChecker, pipeline.LagReporter, and
+// pipeline.FlushIntervalAdjuster.
 type Reader struct {
 	reader        *kafkago.Reader
-	flushInterval time.Duration
+	brokers       []string
+	flushInterval atomic.Int64 // time.Duration; read each ExtractBatch
 	logger        *slog.Logger
+
+	mu         sync.Mutex
+	positions  map[int]partitionPosition // by partition, since the last rebalance
+	unreported kafkago.ReaderStats       // counters not yet added to metrics
+	reported   map[int]bool              // partitions with per-partition gauges set
+}
+
+// partitionPosition is a partition's last fetched offset and the lag behind
+// its high-water mark at that point.
+type partitionPosition struct {
+	offset int64
+	lag    int64
 }
 
 // NewReader creates a Kafka consumer for the configured source topic and group.
@@ -28,7 +46,15 @@ func NewReader(cfg *config.Config, logger *slog.Logger) *Reader {
 		MinBytes:    1,
 		MaxBytes:    10e6, // 10 MB
 	})
-	return &Reader{reader: r, flushInterval: cfg.BatchFlushInterval, logger: logger}
+	reader := &Reader{
+		reader:    r,
+		brokers:   cfg.KafkaBrokers,
+		logger:    logger,
+		positions: make(map[int]partitionPosition),
+		reported:  make(map[int]bool),
+	}
+	reader.SetFlushInterval(cfg.BatchFlushInterval)
+	return reader
 }
 
 // ExtractBatch fetches up to batchSize messages from Kafka.
@@ -36,7 +62,7 @@ func NewReader(cfg *config.Config, logger *slog.Logger) *Reader {
 // Returns a partial batch when the flush interval elapses or the context is cancelled.
 func (r *Reader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
 	batch := make([]domain.RawEvent, 0, batchSize)
-	deadline := time.Now().Add(r.flushInterval)
+	deadline := time.Now().Add(r.FlushInterval())
 
 	for len(batch) < batchSize {
 		timeout := time.Until(deadline)
@@ -61,6 +87,7 @@ func (r *Reader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawE
 			return nil, err
 		}
 
+		r.trackPosition(msg)
 		raw := mapMessageToRawEvent(msg)
 		raw.Commit = func(commitCtx context.Context) error {
 			return r.reader.CommitMessages(commitCtx, msg)
@@ -71,6 +98,84 @@ func (r *Reader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawE
 	return batch, nil
 }
 
+// FlushInterval returns how long ExtractBatch waits to fill a batch.
+func (r *Reader) FlushInterval() time.Duration {
+	return time.Duration(r.flushInterval.Load())
+}
+
+// SetFlushInterval changes how long ExtractBatch waits to fill a batch,
+// starting with the next call.
+func (r *Reader) SetFlushInterval(d time.Duration) {
+	r.flushInterval.Store(int64(d))
+}
+
+// CommitBatch commits the highest offset per partition in events with a
+// single CommitMessages call, rather than one call per message.
+func (r *Reader) CommitBatch(ctx context.Context, events []domain.RawEvent) error {
+	return r.reader.CommitMessages(ctx, latestOffsets(events)...)
+}
+
+// CheckConnectivity returns nil if any configured broker accepts a connection.
+func (r *Reader) CheckConnectivity(ctx context.Context) error {
+	return checkConnectivity(ctx, r.brokers)
+}
+
+// checkConnectivity returns nil if any of brokers accepts a connection.
+func checkConnectivity(ctx context.Context, brokers []string) error {
+	var errs []error
+	for _, broker := range brokers {
+		conn, err := kafkago.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Lag returns the total consumer lag across the partitions fetched from
+// since the group last rebalanced, as of the last message fetched from each.
+func (r *Reader) Lag() int64 {
+	r.pollStats()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lag int64
+	for _, pos := range r.positions {
+		lag += pos.lag
+	}
+	return lag
+}
+
+func (r *Reader) trackPosition(msg kafkago.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.positions[msg.Partition] = partitionPosition{
+		offset: msg.Offset,
+		lag:    max(msg.HighWaterMark-msg.Offset-1, 0),
+	}
+}
+
+// pollStats takes kafka-go's counters, which reset on every Stats call, and
+// holds them for ReportStats.
+func (r *Reader) pollStats() {
+	r.observeStats(r.reader.Stats())
+}
+
+// observeStats accumulates stats and, if the group rebalanced, forgets the
+// partition positions. Partitions may have been revoked, and a revoked
+// partition's lag would otherwise count forever; partitions still assigned
+// are tracked again on their next fetch.
+func (r *Reader) observeStats(stats kafkago.ReaderStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unreported.Errors += stats.Errors
+	r.unreported.Rebalances += stats.Rebalances
+	r.unreported.Bytes += stats.Bytes
+	if stats.Rebalances > 0 {
+		clear(r.positions)
+	}
+}
+
 func (r *Reader) Close() error {
 	return r.reader.Close()
 }
@@ -81,12 +186,35 @@ func mapMessageToRawEvent(msg kafkago.Message) domain.RawEvent {
 		headers[h.Key] = string(h.Value)
 	}
 	return domain.RawEvent{
-		Key:       msg.Key,
-		Value:     msg.Value,
-		Headers:   headers,
-		Topic:     msg.Topic,
-		Partition: msg.Partition,
-		Offset:    msg.Offset,
-		Timestamp: msg.Time,
+		Key:          msg.Key,
+		Value:        msg.Value,
+		Headers:      headers,
+		Topic:        msg.Topic,
+		Partition:    msg.Partition,
+		Offset:       msg.Offset,
+		Timestamp:    msg.Time,
+		TraceContext: extractTraceContext(headers),
+	}
+}
+
+// latestOffsets returns one message per topic-partition carrying the highest
+// offset in events, which is all a consumer-group commit needs.
+func latestOffsets(events []domain.RawEvent) []kafkago.Message {
+	type topicPartition struct {
+		topic     string
+		partition int
+	}
+	index := make(map[topicPartition]int)
+	var msgs []kafkago.Message
+	for _, raw := range events {
+		tp := topicPartition{raw.Topic, raw.Partition}
+		i, ok := index[tp]
+		if !ok {
+			index[tp] = len(msgs)
+			msgs = append(msgs, kafkago.Message{Topic: raw.Topic, Partition: raw.Partition, Offset: raw.Offset})
+			continue
+		}
+		msgs[i].Offset = max(msgs[i].Offset, raw.Offset)
 	}
+	return msgs
 }
@@ -0,0 +1,220 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// registryContentType is the media type of the Confluent Schema Registry API.
+const registryContentType = "application/vnd.schemaregistry.v1+json"
+
+// Schema is a schema definition as stored in a schema registry.
+type Schema struct {
+	// Type is "AVRO", "PROTOBUF", or "JSON".
+	Type       string
+	Definition string
+}
+
+// SchemaRegistry assigns IDs to schemas. Registering a schema that is already
+// registered under the subject returns its existing ID.
+type SchemaRegistry interface {
+	Register(ctx context.Context, subject string, schema Schema) (int, error)
+}
+
+// registerRequest and registerResponse are the bodies of
+// POST /subjects/{subject}/versions.
+type registerRequest struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"` // omitted for AVRO
+}
+
+type registerResponse struct {
+	ID int `json:"id"`
+}
+
+// schemaResponse is the body of GET /schemas/ids/{id}.
+type schemaResponse struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+}
+
+// registryError is the error body of every registry endpoint.
+type registryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// RegistryClient talks to a Confluent-compatible Schema Registry over HTTP.
+// Credentials in the base URL are sent as basic auth.
+// It implements SchemaRegistry.
+type RegistryClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewRegistryClient creates a client for the registry at baseURL.
+func NewRegistryClient(baseURL string) *RegistryClient {
+	return &RegistryClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Register registers schema under subject and returns its ID.
+func (c *RegistryClient) Register(ctx context.Context, subject string, schema Schema) (int, error) {
+	body, err := json.Marshal(newRegisterRequest(schema))
+	if err != nil {
+		return 0, err
+	}
+	endpoint := c.baseURL + "/subjects/" + url.PathEscape(subject) + "/versions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", registryContentType)
+	req.Header.Set("Accept", registryContentType)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("register schema for %s: %w", subject, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, fmt.Errorf("register schema for %s: %w", subject, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var rerr registryError
+		if json.Unmarshal(data, &rerr) == nil && rerr.Message != "" {
+			return 0, fmt.Errorf("register schema for %s: %s (%d)", subject, rerr.Message, rerr.ErrorCode)
+		}
+		return 0, fmt.Errorf("register schema for %s: %s", subject, resp.Status)
+	}
+	var out registerResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return 0, fmt.Errorf("register schema for %s: %w", subject, err)
+	}
+	return out.ID, nil
+}
+
+func newRegisterRequest(schema Schema) registerRequest {
+	req := registerRequest{Schema: schema.Definition}
+	if schema.Type != "AVRO" {
+		req.SchemaType = schema.Type
+	}
+	return req
+}
+
+// LocalRegistry is an in-process schema registry for tests and local runs.
+// It serves the subset of the Confluent Schema Registry API that
+// RegistryClient and schema-aware consumers use: registering a schema version
+// and fetching a schema by ID. It performs no compatibility checks.
+// It implements SchemaRegistry and http.Handler.
+type LocalRegistry struct {
+	mu       sync.Mutex
+	schemas  []Schema                  // by ID - 1
+	subjects map[string]map[string]int // subject -> definition -> ID
+}
+
+// NewLocalRegistry returns an empty registry.
+func NewLocalRegistry() *LocalRegistry {
+	return &LocalRegistry{subjects: map[string]map[string]int{}}
+}
+
+// Register registers schema under subject. A definition already registered
+// under any subject keeps its ID, as in Confluent's registry.
+func (r *LocalRegistry) Register(_ context.Context, subject string, schema Schema) (int, error) {
+	if schema.Definition == "" {
+		return 0, errors.New("empty schema")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.subjects[subject][schema.Definition]; ok {
+		return id, nil
+	}
+	id := 0
+	for i, s := range r.schemas {
+		if s == schema {
+			id = i + 1
+			break
+		}
+	}
+	if id == 0 {
+		r.schemas = append(r.schemas, schema)
+		id = len(r.schemas)
+	}
+	if r.subjects[subject] == nil {
+		r.subjects[subject] = map[string]int{}
+	}
+	r.subjects[subject][schema.Definition] = id
+	return id, nil
+}
+
+// SchemaByID returns the schema registered with id.
+func (r *LocalRegistry) SchemaByID(id int) (Schema, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id < 1 || id > len(r.schemas) {
+		return Schema{}, false
+	}
+	return r.schemas[id-1], true
+}
+
+// ServeHTTP handles POST /subjects/{subject}/versions and
+// GET /schemas/ids/{id}.
+func (r *LocalRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", registryContentType)
+	path := strings.Trim(req.URL.EscapedPath(), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case req.Method == http.MethodPost && len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions":
+		subject, err := url.PathUnescape(parts[1])
+		if err != nil {
+			writeRegistryError(w, http.StatusNotFound, 40401, "subject not found")
+			return
+		}
+		var body registerRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeRegistryError(w, http.StatusUnprocessableEntity, 42201, "invalid schema")
+			return
+		}
+		schemaType := body.SchemaType
+		if schemaType == "" {
+			schemaType = "AVRO"
+		}
+		id, err := r.Register(req.Context(), subject, Schema{Type: schemaType, Definition: body.Schema})
+		if err != nil {
+			writeRegistryError(w, http.StatusUnprocessableEntity, 42201, err.Error())
+			return
+		}
+		_ = json.NewEncoder(w).Encode(registerResponse{ID: id})
+
+	case req.Method == http.MethodGet && len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		id, err := strconv.Atoi(parts[2])
+		schema, ok := r.SchemaByID(id)
+		if err != nil || !ok {
+			writeRegistryError(w, http.StatusNotFound, 40403, "schema not found")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(schemaResponse{Schema: schema.Definition, SchemaType: newRegisterRequest(schema).SchemaType})
+
+	default:
+		writeRegistryError(w, http.StatusNotFound, 404, "not found")
+	}
+}
+
+func writeRegistryError(w http.ResponseWriter, status, code int, msg string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(registryError{ErrorCode: code, Message: msg})
+}
@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// wireMagicByte starts every message in the Confluent wire format.
+const wireMagicByte = 0
+
+// Serializer encodes StormEvents as Kafka message values.
+type Serializer interface {
+	// Prepare readies the serializer before a batch is encoded, e.g. by
+	// registering its schema. Errors are retryable.
+	Prepare(ctx context.Context) error
+	// Serialize encodes event. An error means the event cannot be encoded.
+	Serialize(event domain.StormEvent) ([]byte, error)
+}
+
+// newSerializer returns the Serializer selected by SINK_FORMAT. Schemas are
+// registered under the sink topic's value subject, "<topic>-value", following
+// the registry's default topic name strategy.
+func newSerializer(cfg *config.Config) Serializer {
+	subject := cfg.KafkaSinkTopic + "-value"
+	switch cfg.SinkFormat {
+	case "avro":
+		return NewAvroSerializer(NewRegistryClient(cfg.SchemaRegistryURL), subject)
+	case "protobuf":
+		return NewProtobufSerializer(NewRegistryClient(cfg.SchemaRegistryURL), subject)
+	default:
+		return JSONSerializer{}
+	}
+}
+
+// JSONSerializer encodes events as plain JSON with no framing.
+// It implements Serializer.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Prepare(context.Context) error { return nil }
+
+func (JSONSerializer) Serialize(event domain.StormEvent) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// schemaSerializer encodes events against a registered schema and frames them
+// in the Confluent wire format: the magic byte, the big-endian 4-byte schema
+// ID, the format's preamble, then the encoded event.
+// It implements Serializer.
+type schemaSerializer struct {
+	registry SchemaRegistry
+	subject  string
+	schema   Schema
+	preamble []byte
+	encode   func(b []byte, event domain.StormEvent) []byte
+
+	mu     sync.Mutex
+	header []byte // magic byte, schema ID, and preamble once registered
+}
+
+// NewAvroSerializer encodes events in Avro binary with the embedded
+// StormEvent schema, registering it under subject on first use.
+func NewAvroSerializer(registry SchemaRegistry, subject string) Serializer {
+	return &schemaSerializer{
+		registry: registry,
+		subject:  subject,
+		schema:   Schema{Type: "AVRO", Definition: avroSchema},
+		encode:   appendAvroEvent,
+	}
+}
+
+// NewProtobufSerializer encodes events as the storm.v1.StormEvent Protobuf
+// message, registering its schema under subject on first use.
+func NewProtobufSerializer(registry SchemaRegistry, subject string) Serializer {
+	return &schemaSerializer{
+		registry: registry,
+		subject:  subject,
+		schema:   Schema{Type: "PROTOBUF", Definition: protobufSchema},
+		// The message index path of StormEvent, the first message in the
+		// file. [0] is abbreviated to a single zero byte.
+		preamble: []byte{0},
+		encode:   appendProtobufEvent,
+	}
+}
+
+// Prepare registers the schema. Once it succeeds later calls do nothing.
+func (s *schemaSerializer) Prepare(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.header != nil {
+		return nil
+	}
+	id, err := s.registry.Register(ctx, s.subject, s.schema)
+	if err != nil {
+		return err
+	}
+	s.header = appendWireHeader(nil, id, s.preamble)
+	return nil
+}
+
+func (s *schemaSerializer) Serialize(event domain.StormEvent) ([]byte, error) {
+	s.mu.Lock()
+	header := s.header
+	s.mu.Unlock()
+	if header == nil {
+		return nil, errors.New("schema not registered")
+	}
+	return s.encode(append([]byte(nil), header...), event), nil
+}
+
+// appendWireHeader appends the Confluent wire-format header for schemaID.
+func appendWireHeader(b []byte, schemaID int, preamble []byte) []byte {
+	b = append(b, wireMagicByte)
+	b = binary.BigEndian.AppendUint32(b, uint32(schemaID))
+	return append(b, preamble...)
+}
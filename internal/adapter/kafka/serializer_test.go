@@ -0,0 +1,364 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// fullEvent sets every field of the sink message.
+func fullEvent() domain.StormEvent {
+	return domain.StormEvent{
+		ID:          "hail-1",
+		EventType:   "hail",
+		Geo:         domain.Geo{Lat: 31.02, Lon: -98.44},
+		Measurement: domain.Measurement{Magnitude: 1.25, Unit: "in", Severity: ptr("moderate")},
+		EventTime:   time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC),
+		Location: domain.Location{
+			Raw: "8 ESE Chappel", Name: "Chappel", Distance: ptr(8.0), Direction: ptr("ESE"),
+			State: "TX", County: "San Saba", CountyFIPS: "48411", CanonicalCounty: "San Saba",
+			StateFIPS: "48", ForecastZone: "TXZ155", CountyMismatch: true,
+			ProjectedGeo: &domain.Geo{Lat: 31.0, Lon: -98.4}, GeoDiscrepancyKm: ptr(3.5), GeoIssue: "transposed",
+		},
+		Comments:             "1.25 inch hail. (SJT)",
+		SourceOffice:         "SJT",
+		TimeBucket:           time.Date(2024, 4, 26, 15, 0, 0, 0, time.UTC),
+		Quality:              domain.Quality{Inferred: []string{"measurement.unit"}, Corrected: []string{"measurement.magnitude", "geo.lat"}},
+		SeverityRulesVersion: "nws-default-1",
+		Duplicate:            true,
+		Revision:             2,
+		ProcessedAt:          time.Date(2024, 4, 26, 15, 11, 0, 123456000, time.UTC),
+	}
+}
+
+func splitWireHeader(t *testing.T, msg []byte) (int, []byte) {
+	t.Helper()
+	require.GreaterOrEqual(t, len(msg), 5)
+	require.Equal(t, byte(wireMagicByte), msg[0])
+	return int(binary.BigEndian.Uint32(msg[1:5])), msg[5:]
+}
+
+func TestRegistryClient_LocalRegistry(t *testing.T) {
+	local := NewLocalRegistry()
+	srv := httptest.NewServer(local)
+	defer srv.Close()
+	client := NewRegistryClient(srv.URL + "/")
+	ctx := context.Background()
+
+	avro := Schema{Type: "AVRO", Definition: avroSchema}
+	id, err := client.Register(ctx, "storm-value", avro)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	// Re-registering is idempotent, and the same definition keeps its ID
+	// under another subject.
+	id, err = client.Register(ctx, "storm-value", avro)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+	id, err = client.Register(ctx, "other-value", avro)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	id, err = client.Register(ctx, "storm-value", Schema{Type: "PROTOBUF", Definition: protobufSchema})
+	require.NoError(t, err)
+	assert.Equal(t, 2, id)
+	got, ok := local.SchemaByID(2)
+	require.True(t, ok)
+	assert.Equal(t, "PROTOBUF", got.Type)
+
+	_, err = client.Register(ctx, "storm-value", Schema{Type: "AVRO"})
+	assert.ErrorContains(t, err, "empty schema")
+
+	_, err = NewRegistryClient(srv.URL+"/missing").Register(ctx, "storm-value", avro)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestSchemaSerializer_RegistersOnPrepare(t *testing.T) {
+	registry := NewLocalRegistry()
+	// Take ID 1 so the serializer's schema gets a distinguishable ID.
+	_, err := registry.Register(context.Background(), "unrelated", Schema{Type: "JSON", Definition: "{}"})
+	require.NoError(t, err)
+
+	s := NewAvroSerializer(registry, "transformed-weather-data-value")
+	_, err = s.Serialize(fullEvent())
+	assert.ErrorContains(t, err, "not registered")
+
+	require.NoError(t, s.Prepare(context.Background()))
+	msg, err := s.Serialize(fullEvent())
+	require.NoError(t, err)
+	id, _ := splitWireHeader(t, msg)
+	assert.Equal(t, 2, id)
+
+	p := NewProtobufSerializer(registry, "transformed-weather-data-value")
+	require.NoError(t, p.Prepare(context.Background()))
+	msg, err = p.Serialize(fullEvent())
+	require.NoError(t, err)
+	id, rest := splitWireHeader(t, msg)
+	assert.Equal(t, 3, id)
+	assert.Equal(t, byte(0), rest[0], "message index of StormEvent")
+}
+
+func TestAvroPrimitives(t *testing.T) {
+	assert.Equal(t, []byte{0x00}, appendAvroLong(nil, 0))
+	assert.Equal(t, []byte{0x01}, appendAvroLong(nil, -1))
+	assert.Equal(t, []byte{0x80, 0x01}, appendAvroLong(nil, 64))
+	assert.Equal(t, []byte{0x04, 'h', 'i'}, appendAvroString(nil, "hi"))
+	assert.Equal(t, []byte{0x02, 0x02, 'a', 0x00}, appendAvroStrings(nil, []string{"a"}))
+	assert.Equal(t, []byte{0x00}, appendAvroStrings(nil, nil))
+}
+
+// avroDecoder decodes Avro binary generically from a parsed schema, so the
+// test checks appendAvroEvent against the schema rather than against itself.
+type avroDecoder struct {
+	t     *testing.T
+	buf   []byte
+	named map[string]any
+}
+
+func (d *avroDecoder) long() int64 {
+	u, n := binary.Uvarint(d.buf)
+	require.Positive(d.t, n)
+	d.buf = d.buf[n:]
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+func (d *avroDecoder) decode(schema any) any {
+	switch s := schema.(type) {
+	case string:
+		switch s {
+		case "null":
+			return nil
+		case "boolean":
+			v := d.buf[0] == 1
+			d.buf = d.buf[1:]
+			return v
+		case "int", "long":
+			return d.long()
+		case "double":
+			v := math.Float64frombits(binary.LittleEndian.Uint64(d.buf))
+			d.buf = d.buf[8:]
+			return v
+		case "string":
+			n := d.long()
+			v := string(d.buf[:n])
+			d.buf = d.buf[n:]
+			return v
+		}
+		named, ok := d.named[s]
+		require.True(d.t, ok, "unknown type %s", s)
+		return d.decode(named)
+	case []any:
+		return d.decode(s[d.long()])
+	case map[string]any:
+		switch s["type"] {
+		case "record":
+			d.named[s["name"].(string)] = s
+			out := map[string]any{}
+			for _, f := range s["fields"].([]any) {
+				field := f.(map[string]any)
+				out[field["name"].(string)] = d.decode(field["type"])
+			}
+			return out
+		case "array":
+			var out []any
+			for n := d.long(); n != 0; n = d.long() {
+				for range n {
+					out = append(out, d.decode(s["items"]))
+				}
+			}
+			return out
+		default:
+			return d.decode(s["type"])
+		}
+	}
+	d.t.Fatalf("unsupported schema %v", schema)
+	return nil
+}
+
+func TestAppendAvroEvent_MatchesSchema(t *testing.T) {
+	var schema any
+	require.NoError(t, json.Unmarshal([]byte(avroSchema), &schema))
+	event := fullEvent()
+
+	d := &avroDecoder{t: t, buf: appendAvroEvent(nil, event), named: map[string]any{}}
+	got := d.decode(schema).(map[string]any)
+	assert.Empty(t, d.buf, "trailing bytes")
+
+	assert.Equal(t, "hail-1", got["id"])
+	assert.Equal(t, map[string]any{"lat": 31.02, "lon": -98.44}, got["geo"])
+	assert.Equal(t, map[string]any{"magnitude": 1.25, "unit": "in", "severity": "moderate"}, got["measurement"])
+	assert.Equal(t, event.EventTime.UnixMicro(), got["event_time"])
+	assert.Equal(t, event.ProcessedAt.UnixMicro(), got["processed_at"])
+	loc := got["location"].(map[string]any)
+	assert.Equal(t, 8.0, loc["distance"])
+	assert.Equal(t, "ESE", loc["direction"])
+	assert.Equal(t, true, loc["county_mismatch"])
+	assert.Equal(t, map[string]any{"lat": 31.0, "lon": -98.4}, loc["projected_geo"])
+	assert.Equal(t, "transposed", loc["geo_issue"])
+	quality := got["quality"].(map[string]any)
+	assert.Equal(t, []any{"measurement.magnitude", "geo.lat"}, quality["corrected"])
+	assert.Nil(t, quality["unknown"])
+	assert.Equal(t, true, got["duplicate"])
+	assert.Equal(t, int64(2), got["revision"])
+
+	// Unset optional fields decode as null.
+	d = &avroDecoder{t: t, buf: appendAvroEvent(nil, domain.StormEvent{ID: "x"}), named: map[string]any{}}
+	got = d.decode(schema).(map[string]any)
+	assert.Empty(t, d.buf)
+	assert.Nil(t, got["measurement"].(map[string]any)["severity"])
+	assert.Nil(t, got["location"].(map[string]any)["projected_geo"])
+}
+
+// protoFields parses msg into its fields by number, keeping the last value
+// of each and every value of repeated ones.
+func protoFields(t *testing.T, msg []byte) map[protowire.Number][]any {
+	t.Helper()
+	out := map[protowire.Number][]any{}
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		require.Positive(t, n)
+		msg = msg[n:]
+		var v any
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(msg)
+		case protowire.Fixed64Type:
+			var u uint64
+			u, n = protowire.ConsumeFixed64(msg)
+			v = math.Float64frombits(u)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(msg)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		require.Positive(t, n)
+		msg = msg[n:]
+		out[num] = append(out[num], v)
+	}
+	return out
+}
+
+func TestAppendProtobufEvent(t *testing.T) {
+	event := fullEvent()
+	f := protoFields(t, appendProtobufEvent(nil, event))
+
+	assert.Equal(t, []byte("hail-1"), f[1][0])
+	assert.Equal(t, uint64(2), f[13][0])
+	assert.Equal(t, uint64(1), f[12][0])
+
+	geo := protoFields(t, f[3][0].([]byte))
+	assert.Equal(t, 31.02, geo[1][0])
+	assert.Equal(t, -98.44, geo[2][0])
+
+	ts := protoFields(t, f[14][0].([]byte))
+	assert.Equal(t, uint64(event.ProcessedAt.Unix()), ts[1][0])
+	assert.Equal(t, uint64(123456000), ts[2][0])
+
+	loc := protoFields(t, f[6][0].([]byte))
+	assert.Equal(t, 8.0, loc[3][0])
+	assert.Equal(t, []byte("ESE"), loc[4][0])
+	assert.Equal(t, 3.5, loc[13][0])
+
+	quality := protoFields(t, f[10][0].([]byte))
+	assert.Equal(t, []any{[]byte("measurement.magnitude"), []byte("geo.lat")}, quality[4])
+
+	// proto3 omits zero scalars but keeps set optionals and message fields.
+	f = protoFields(t, appendProtobufEvent(nil, domain.StormEvent{
+		Location: domain.Location{Distance: ptr(0.0)},
+	}))
+	assert.NotContains(t, f, protowire.Number(1))
+	assert.NotContains(t, f, protowire.Number(13))
+	assert.Contains(t, f, protowire.Number(4))
+	assert.Equal(t, 0.0, protoFields(t, f[6][0].([]byte))[3][0])
+}
+
+// The message structs and their JSON names, which both schemas mirror.
+var sinkMessageTypes = map[string]reflect.Type{
+	"StormEvent":  reflect.TypeFor[domain.StormEvent](),
+	"Geo":         reflect.TypeFor[domain.Geo](),
+	"Measurement": reflect.TypeFor[domain.Measurement](),
+	"Location":    reflect.TypeFor[domain.Location](),
+	"Quality":     reflect.TypeFor[domain.Quality](),
+}
+
+func jsonFieldNames(typ reflect.Type) []string {
+	var names []string
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name != "-" && f.IsExported() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestAvroSchema_MatchesStormEvent(t *testing.T) {
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal([]byte(avroSchema), &schema))
+
+	records := map[string][]string{}
+	var collect func(any)
+	collect = func(s any) {
+		switch s := s.(type) {
+		case []any:
+			for _, item := range s {
+				collect(item)
+			}
+		case map[string]any:
+			if s["type"] != "record" {
+				collect(s["type"])
+				collect(s["items"])
+				return
+			}
+			var names []string
+			for _, f := range s["fields"].([]any) {
+				field := f.(map[string]any)
+				names = append(names, field["name"].(string))
+				collect(field["type"])
+			}
+			records[s["name"].(string)] = names
+		}
+	}
+	collect(schema)
+
+	require.Len(t, records, len(sinkMessageTypes))
+	for name, typ := range sinkMessageTypes {
+		assert.Equal(t, jsonFieldNames(typ), records[name], "Avro record %s", name)
+	}
+}
+
+func TestProtobufSchema_MatchesStormEvent(t *testing.T) {
+	messageRe := regexp.MustCompile(`(?s)\nmessage (\w+) \{(.*?)\n\}`)
+	fieldRe := regexp.MustCompile(`(?m)^\s+(?:optional |repeated )?[\w.]+ (\w+) = (\d+);`)
+
+	messages := messageRe.FindAllStringSubmatch(protobufSchema, -1)
+	require.Len(t, messages, len(sinkMessageTypes))
+	assert.Equal(t, "StormEvent", messages[0][1], "StormEvent must be the first message")
+	for _, m := range messages {
+		typ, ok := sinkMessageTypes[m[1]]
+		require.True(t, ok, "unexpected message %s", m[1])
+		var names []string
+		for i, f := range fieldRe.FindAllStringSubmatch(m[2], -1) {
+			names = append(names, f[1])
+			// The encoder numbers fields in declaration order.
+			assert.Equal(t, strconv.Itoa(i+1), f[2], "%s.%s", m[1], f[1])
+		}
+		assert.Equal(t, jsonFieldNames(typ), names, "Protobuf message %s", m[1])
+	}
+}
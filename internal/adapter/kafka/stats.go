@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// StatsReporter records a Kafka client's statistics into metrics.
+type StatsReporter interface {
+	ReportStats(m *observability.Metrics)
+}
+
+// ExportStats calls ReportStats on each reporter every interval until ctx is
+// cancelled.
+func ExportStats(ctx context.Context, interval time.Duration, m *observability.Metrics, reporters ...StatsReporter) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range reporters {
+				r.ReportStats(m)
+			}
+		}
+	}
+}
+
+// ReportStats adds the reader's counters since the previous call and sets the
+// per-partition offset and lag gauges. Gauges for partitions no longer
+// tracked after a rebalance are removed.
+func (r *Reader) ReportStats(m *observability.Metrics) {
+	r.pollStats()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m.KafkaFetchErrors.Add(float64(r.unreported.Errors))
+	m.KafkaRebalances.Add(float64(r.unreported.Rebalances))
+	m.KafkaConsumedBytes.Add(float64(r.unreported.Bytes))
+	r.unreported = kafkago.ReaderStats{}
+
+	for partition := range r.reported {
+		if _, ok := r.positions[partition]; !ok {
+			label := strconv.Itoa(partition)
+			m.FetchedOffset.DeleteLabelValues(label)
+			m.ConsumerLag.DeleteLabelValues(label)
+			delete(r.reported, partition)
+		}
+	}
+	for partition, pos := range r.positions {
+		label := strconv.Itoa(partition)
+		m.FetchedOffset.WithLabelValues(label).Set(float64(pos.offset))
+		m.ConsumerLag.WithLabelValues(label).Set(float64(pos.lag))
+		r.reported[partition] = true
+	}
+}
+
+// ReportStats adds the writer's counters since the previous call.
+func (w *Writer) ReportStats(m *observability.Metrics) {
+	stats := w.writer.Stats()
+	m.KafkaWriteErrors.Add(float64(stats.Errors))
+	m.KafkaProducedBytes.Add(float64(stats.Bytes))
+}
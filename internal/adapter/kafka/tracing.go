@@ -0,0 +1,34 @@
+package kafka
+
+import (
+	"context"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceContext reads and writes W3C traceparent and tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// extractTraceContext returns the span context carried in headers, or an
+// invalid one if there is none.
+func extractTraceContext(headers map[string]string) trace.SpanContext {
+	ctx := traceContext.Extract(context.Background(), propagation.MapCarrier(headers))
+	return trace.SpanContextFromContext(ctx)
+}
+
+// injectTraceContext appends sc to headers if it is valid.
+func injectTraceContext(headers []kafkago.Header, sc trace.SpanContext) []kafkago.Header {
+	if !sc.IsValid() {
+		return headers
+	}
+	carrier := propagation.MapCarrier{}
+	traceContext.Inject(trace.ContextWithSpanContext(context.Background(), sc), carrier)
+	for _, key := range traceContext.Fields() {
+		if v, ok := carrier[key]; ok {
+			headers = append(headers, kafkago.Header{Key: key, Value: []byte(v)})
+		}
+	}
+	return headers
+}
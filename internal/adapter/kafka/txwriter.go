@@ -0,0 +1,409 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+)
+
+// txnTimeout is how long the broker lets a transaction stay open before it
+// aborts it, e.g. after the producer crashed mid-batch.
+const txnTimeout = time.Minute
+
+// TransactionalWriter produces messages to the sink topic in Kafka
+// transactions and commits the consumer group's source offsets in the same
+// transaction, as the group member the events were fetched by (see
+// GroupReader). It implements pipeline.TransactionalLoader.
+//
+// kafka-go's Writer has no transactional mode, so TransactionalWriter speaks
+// the transaction protocol through kafka-go's Client: InitProducerId,
+// AddPartitionsToTxn, produce requests carrying the producer ID, epoch, and
+// sequence numbers, AddOffsetsToTxn, TxnOffsetCommit, and EndTxn. It runs one
+// transaction at a time and is not safe for concurrent use.
+type TransactionalWriter struct {
+	client     *kafkago.Client
+	transport  *kafkago.Transport
+	topic      string
+	groupID    string
+	txnID      string
+	serializer Serializer
+	validate   bool
+	balancer   kafkago.Balancer
+	logger     *slog.Logger
+
+	session    *kafkago.ProducerSession // nil until the next BeginTxn initializes one
+	sequences  map[int]int32            // next sequence number per sink partition
+	partitions []int                    // sink topic partitions
+	added      map[int]bool             // partitions added to the open transaction
+	open       bool
+}
+
+// NewTransactionalWriter creates a transactional producer for the configured
+// sink topic that commits offsets for the consumer group, encoding events as
+// NewWriter does.
+func NewTransactionalWriter(cfg *config.Config, logger *slog.Logger) *TransactionalWriter {
+	transport := &kafkago.Transport{}
+	return &TransactionalWriter{
+		client:     &kafkago.Client{Addr: kafkago.TCP(cfg.KafkaBrokers...), Transport: transport},
+		transport:  transport,
+		topic:      cfg.KafkaSinkTopic,
+		groupID:    cfg.KafkaGroupID,
+		txnID:      cfg.KafkaTransactionalID,
+		serializer: newSerializer(cfg),
+		validate:   cfg.SinkSchemaValidation,
+		balancer:   &kafkago.LeastBytes{},
+		logger:     logger,
+	}
+}
+
+// BeginTxn opens a transaction. The first call, and the first after an abort,
+// registers the transactional ID with the coordinator, which fences any older
+// producer with the same ID and aborts its open transaction.
+func (w *TransactionalWriter) BeginTxn(ctx context.Context) error {
+	if w.open {
+		return errors.New("transaction already open")
+	}
+	if w.session == nil {
+		if err := w.initProducer(ctx); err != nil {
+			return err
+		}
+	}
+	if w.partitions == nil {
+		partitions, err := w.sinkPartitions(ctx)
+		if err != nil {
+			return err
+		}
+		w.partitions = partitions
+	}
+	w.added = make(map[int]bool)
+	w.open = true
+	return nil
+}
+
+func (w *TransactionalWriter) initProducer(ctx context.Context) error {
+	res, err := w.client.InitProducerID(ctx, &kafkago.InitProducerIDRequest{
+		TransactionalID:      w.txnID,
+		TransactionTimeoutMs: int(txnTimeout.Milliseconds()),
+	})
+	if err == nil {
+		err = res.Error
+	}
+	if err != nil {
+		return fmt.Errorf("init producer id: %w", err)
+	}
+	w.session = res.Producer
+	w.sequences = make(map[int]int32)
+	return nil
+}
+
+func (w *TransactionalWriter) sinkPartitions(ctx context.Context) ([]int, error) {
+	res, err := w.client.Metadata(ctx, &kafkago.MetadataRequest{Topics: []string{w.topic}})
+	if err != nil {
+		return nil, fmt.Errorf("sink topic metadata: %w", err)
+	}
+	if len(res.Topics) != 1 || res.Topics[0].Error != nil || len(res.Topics[0].Partitions) == 0 {
+		return nil, fmt.Errorf("sink topic metadata: no partitions for %s", w.topic)
+	}
+	partitions := make([]int, len(res.Topics[0].Partitions))
+	for i, p := range res.Topics[0].Partitions {
+		partitions[i] = p.ID
+	}
+	slices.Sort(partitions)
+	return partitions, nil
+}
+
+// LoadBatch produces events in the open transaction. Events that cannot be
+// encoded are reported in a *pipeline.BatchLoadError as permanent failures,
+// as with Writer; any produce failure fails the whole call, since the
+// transaction must then be aborted.
+func (w *TransactionalWriter) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	if !w.open {
+		return errors.New("no open transaction")
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	if err := w.serializer.Prepare(ctx); err != nil {
+		return fmt.Errorf("prepare serializer: %w", err)
+	}
+	msgs, _, errs, failed := encodeBatch(w.serializer, w.validate, events)
+
+	byPartition := make(map[int][]kafkago.Record)
+	for _, msg := range msgs {
+		p := w.balancer.Balance(msg, w.partitions...)
+		byPartition[p] = append(byPartition[p], kafkago.Record{
+			Time:    time.Now(),
+			Key:     kafkago.NewBytes(msg.Key),
+			Value:   kafkago.NewBytes(msg.Value),
+			Headers: msg.Headers,
+		})
+	}
+	partitions := slices.Sorted(func(yield func(int) bool) {
+		for p := range byPartition {
+			if !yield(p) {
+				return
+			}
+		}
+	})
+	if err := w.addPartitions(ctx, partitions); err != nil {
+		return err
+	}
+	for _, p := range partitions {
+		if err := w.produce(ctx, p, byPartition[p]); err != nil {
+			return err
+		}
+	}
+
+	if failed {
+		return &pipeline.BatchLoadError{Errs: errs}
+	}
+	return nil
+}
+
+// addPartitions registers the partitions not yet written in this transaction.
+func (w *TransactionalWriter) addPartitions(ctx context.Context, partitions []int) error {
+	var add []kafkago.AddPartitionToTxn
+	for _, p := range partitions {
+		if !w.added[p] {
+			add = append(add, kafkago.AddPartitionToTxn{Partition: p})
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+	res, err := w.client.AddPartitionsToTxn(ctx, &kafkago.AddPartitionsToTxnRequest{
+		TransactionalID: w.txnID,
+		ProducerID:      w.session.ProducerID,
+		ProducerEpoch:   w.session.ProducerEpoch,
+		Topics:          map[string][]kafkago.AddPartitionToTxn{w.topic: add},
+	})
+	if err != nil {
+		return fmt.Errorf("add partitions to transaction: %w", err)
+	}
+	for _, p := range res.Topics[w.topic] {
+		if p.Error != nil {
+			return fmt.Errorf("add partition %d to transaction: %w", p.Partition, p.Error)
+		}
+	}
+	for _, p := range add {
+		w.added[p.Partition] = true
+	}
+	return nil
+}
+
+// produce writes records to partition as one transactional record batch.
+func (w *TransactionalWriter) produce(ctx context.Context, partition int, records []kafkago.Record) error {
+	seq := w.sequences[partition]
+	set, err := transactionalRecordSet(records, int64(w.session.ProducerID), int16(w.session.ProducerEpoch), seq)
+	if err != nil {
+		return fmt.Errorf("encode partition %d: %w", partition, err)
+	}
+	res, err := w.client.RawProduce(ctx, &kafkago.RawProduceRequest{
+		Topic:           w.topic,
+		Partition:       partition,
+		RequiredAcks:    kafkago.RequireAll,
+		TransactionalID: w.txnID,
+		RawRecords:      set,
+	})
+	if err == nil {
+		err = res.Error
+	}
+	if err != nil {
+		return fmt.Errorf("produce to partition %d: %w", partition, err)
+	}
+	w.sequences[partition] = seq + int32(len(records))
+	return nil
+}
+
+// CommitTxn adds the next offset to consume after events, per partition, to
+// the transaction for the consumer group and commits the transaction.
+func (w *TransactionalWriter) CommitTxn(ctx context.Context, events []domain.RawEvent) error {
+	if !w.open {
+		return errors.New("no open transaction")
+	}
+	if offsets := txnOffsets(events); len(offsets) > 0 {
+		gen, err := batchGeneration(events)
+		if err != nil {
+			return err
+		}
+		if err := w.sendOffsets(ctx, gen, offsets); err != nil {
+			return err
+		}
+	}
+	res, err := w.client.EndTxn(ctx, &kafkago.EndTxnRequest{
+		TransactionalID: w.txnID,
+		ProducerID:      w.session.ProducerID,
+		ProducerEpoch:   w.session.ProducerEpoch,
+		Committed:       true,
+	})
+	if err == nil {
+		err = res.Error
+	}
+	if err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	w.open = false
+	return nil
+}
+
+// sendOffsets adds offsets to the transaction on behalf of the group member
+// gen. The coordinator rejects them if the group has rebalanced since, which
+// is reported as pipeline.ErrRebalanced.
+func (w *TransactionalWriter) sendOffsets(ctx context.Context, gen *domain.GroupGeneration, offsets map[string][]kafkago.TxnOffsetCommit) error {
+	added, err := w.client.AddOffsetsToTxn(ctx, &kafkago.AddOffsetsToTxnRequest{
+		TransactionalID: w.txnID,
+		ProducerID:      w.session.ProducerID,
+		ProducerEpoch:   w.session.ProducerEpoch,
+		GroupID:         w.groupID,
+	})
+	if err == nil {
+		err = added.Error
+	}
+	if err != nil {
+		return fmt.Errorf("add offsets to transaction: %w", err)
+	}
+
+	res, err := w.client.TxnOffsetCommit(ctx, &kafkago.TxnOffsetCommitRequest{
+		TransactionalID: w.txnID,
+		GroupID:         w.groupID,
+		ProducerID:      w.session.ProducerID,
+		ProducerEpoch:   w.session.ProducerEpoch,
+		GenerationID:    gen.ID,
+		MemberID:        gen.MemberID,
+		Topics:          offsets,
+	})
+	if err != nil {
+		return fmt.Errorf("commit offsets in transaction: %w", err)
+	}
+	for topic, partitions := range res.Topics {
+		for _, p := range partitions {
+			if p.Error == nil {
+				continue
+			}
+			err := fmt.Errorf("commit offset for %s/%d in transaction: %w", topic, p.Partition, p.Error)
+			if isRebalanced(p.Error) {
+				return fmt.Errorf("%w: %w", pipeline.ErrRebalanced, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// batchGeneration returns the consumer group generation events were fetched
+// in. Events from a source without one, or from more than one generation,
+// cannot be committed.
+func batchGeneration(events []domain.RawEvent) (*domain.GroupGeneration, error) {
+	gen := events[0].Generation
+	if gen == nil {
+		return nil, errors.New("commit offsets in transaction: events carry no consumer group generation")
+	}
+	for _, raw := range events[1:] {
+		if raw.Generation == nil || *raw.Generation != *gen {
+			return nil, fmt.Errorf("commit offsets in transaction: %w: batch spans generations", pipeline.ErrRebalanced)
+		}
+	}
+	return gen, nil
+}
+
+// isRebalanced reports whether err is the coordinator rejecting a commit from
+// a member that is no longer part of the current generation.
+func isRebalanced(err error) bool {
+	return errors.Is(err, kafkago.IllegalGeneration) ||
+		errors.Is(err, kafkago.UnknownMemberId) ||
+		errors.Is(err, kafkago.RebalanceInProgress) ||
+		errors.Is(err, kafkago.FencedInstanceID)
+}
+
+// AbortTxn aborts the open transaction. The producer session is dropped even
+// if the abort fails, so the next BeginTxn starts a new epoch, which aborts
+// anything left open and resets the sequence numbers.
+func (w *TransactionalWriter) AbortTxn(ctx context.Context) error {
+	if !w.open {
+		return nil
+	}
+	w.open = false
+	session := w.session
+	w.session = nil
+	res, err := w.client.EndTxn(ctx, &kafkago.EndTxnRequest{
+		TransactionalID: w.txnID,
+		ProducerID:      session.ProducerID,
+		ProducerEpoch:   session.ProducerEpoch,
+		Committed:       false,
+	})
+	if err == nil {
+		err = res.Error
+	}
+	if err != nil {
+		return fmt.Errorf("abort transaction: %w", err)
+	}
+	return nil
+}
+
+// Close aborts a transaction left open and releases the broker connections.
+func (w *TransactionalWriter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := w.AbortTxn(ctx)
+	w.transport.CloseIdleConnections()
+	return err
+}
+
+// txnOffsets returns the next offset to consume per topic-partition in
+// events, in the form TxnOffsetCommit takes.
+func txnOffsets(events []domain.RawEvent) map[string][]kafkago.TxnOffsetCommit {
+	offsets := make(map[string][]kafkago.TxnOffsetCommit)
+	for _, msg := range latestOffsets(events) {
+		offsets[msg.Topic] = append(offsets[msg.Topic], kafkago.TxnOffsetCommit{
+			Partition: msg.Partition,
+			Offset:    msg.Offset + 1,
+		})
+	}
+	return offsets
+}
+
+// Offsets of the producer fields in a v2 record batch, after the 4-byte size
+// prefix RecordSet.WriteTo writes. The CRC-32C covers everything from the
+// attributes to the end of the batch.
+const (
+	batchCRCOffset        = 4 + 17
+	batchAttributesOffset = 4 + 21
+	batchProducerIDOffset = 4 + 43
+	batchEpochOffset      = 4 + 51
+	batchSequenceOffset   = 4 + 53
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// transactionalRecordSet encodes records as a transactional v2 record batch.
+// kafka-go always writes a producer ID of -1, so the producer fields are
+// patched in and the checksum recomputed.
+func transactionalRecordSet(records []kafkago.Record, producerID int64, epoch int16, sequence int32) (protocol.RawRecordSet, error) {
+	rs := protocol.RecordSet{
+		Version:    2,
+		Attributes: protocol.Transactional,
+		Records:    kafkago.NewRecordReader(records...),
+	}
+	var buf bytes.Buffer
+	if _, err := rs.WriteTo(&buf); err != nil {
+		return protocol.RawRecordSet{}, err
+	}
+	b := buf.Bytes()
+	binary.BigEndian.PutUint64(b[batchProducerIDOffset:], uint64(producerID))
+	binary.BigEndian.PutUint16(b[batchEpochOffset:], uint16(epoch))
+	binary.BigEndian.PutUint32(b[batchSequenceOffset:], uint32(sequence))
+	binary.BigEndian.PutUint32(b[batchCRCOffset:], crc32.Checksum(b[batchAttributesOffset:], castagnoli))
+	return protocol.RawRecordSet{Reader: bytes.NewReader(b)}, nil
+}
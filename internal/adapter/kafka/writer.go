@@ -1,25 +1,33 @@
 package kafka
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/config"
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/internal/schema"
 	kafkago "github.com/segmentio/kafka-go"
 )
 
 // Writer produces messages to a Kafka topic.
 // It implements pipeline.BatchLoader.
 type Writer struct {
-	writer *kafkago.Writer
-	logger *slog.Logger
+	writer     *kafkago.Writer
+	serializer Serializer
+	validate   bool
+	logger     *slog.Logger
 }
 
-// NewWriter creates a Kafka producer for the configured sink topic.
+// NewWriter creates a Kafka producer for the configured sink topic, encoding
+// events in the SINK_FORMAT and, with SINK_SCHEMA_VALIDATION, checking them
+// against the message schema first.
 func NewWriter(cfg *config.Config, logger *slog.Logger) *Writer {
 	w := &kafkago.Writer{
 		Addr:         kafkago.TCP(cfg.KafkaBrokers...),
@@ -27,42 +35,157 @@ func NewWriter(cfg *config.Config, logger *slog.Logger) *Writer {
 		Balancer:     &kafkago.LeastBytes{},
 		RequiredAcks: kafkago.RequireAll,
 	}
-	return &Writer{writer: w, logger: logger}
+	return &Writer{writer: w, serializer: newSerializer(cfg), validate: cfg.SinkSchemaValidation, logger: logger}
 }
 
 // LoadBatch serializes and publishes multiple storm events to the sink Kafka
-// topic in a single WriteMessages call for efficiency.
+// topic in a single WriteMessages call for efficiency. When only some events
+// fail it returns a *pipeline.BatchLoadError; events that can never be written
+// (unserializable, not matching the message schema, or larger than the writer
+// accepts) are marked pipeline.Permanent.
 func (w *Writer) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
 	if len(events) == 0 {
 		return nil
 	}
-	msgs := make([]kafkago.Message, len(events))
+	if err := w.serializer.Prepare(ctx); err != nil {
+		return fmt.Errorf("prepare serializer: %w", err)
+	}
+	msgs, index, errs, failed := encodeBatch(w.serializer, w.validate, events)
+
+	for len(msgs) > 0 {
+		err := w.writer.WriteMessages(ctx, msgs...)
+		if err == nil {
+			break
+		}
+
+		// kafka-go rejects the whole call for the first oversized message;
+		// drop it and write the rest.
+		var tooLarge kafkago.MessageTooLargeError
+		if errors.As(err, &tooLarge) {
+			j := indexOfMessage(msgs, tooLarge.Message)
+			errs[index[j]] = pipeline.Permanent(fmt.Errorf("event %s: %w", events[index[j]].ID, err))
+			failed = true
+			msgs = slices.Delete(msgs, j, j+1)
+			index = slices.Delete(index, j, j+1)
+			continue
+		}
+
+		// Every message failing with a retryable error is a sink problem,
+		// not a problem with these events; report it as a whole-batch error.
+		var writeErrs kafkago.WriteErrors
+		if errors.As(err, &writeErrs) && len(writeErrs) == len(msgs) && (failed || !allRetryable(writeErrs)) {
+			for j, werr := range writeErrs {
+				if werr != nil {
+					errs[index[j]] = writeError(werr)
+					failed = true
+				}
+			}
+			break
+		}
+
+		if !failed {
+			return err
+		}
+		for _, i := range index {
+			errs[i] = err
+		}
+		break
+	}
+
+	if failed {
+		return &pipeline.BatchLoadError{Errs: errs}
+	}
+	return nil
+}
+
+// encodeBatch converts events to messages, checking them against the message
+// schema first when validate is set. index holds the position in events of
+// each message; events that cannot be encoded get a permanent error in errs,
+// which is parallel to events, and set failed.
+func encodeBatch(s Serializer, validate bool, events []domain.StormEvent) (msgs []kafkago.Message, index []int, errs []error, failed bool) {
+	errs = make([]error, len(events))
+	msgs = make([]kafkago.Message, 0, len(events))
+	index = make([]int, 0, len(events))
 	for i := range events {
-		msg, err := serializeToMessage(events[i])
+		if validate {
+			if err := schema.ValidateEvent(events[i]); err != nil {
+				errs[i] = pipeline.Permanent(fmt.Errorf("event %s: %w", events[i].ID, err))
+				failed = true
+				continue
+			}
+		}
+		msg, err := serializeToMessage(s, events[i])
 		if err != nil {
-			return err
+			errs[i] = pipeline.Permanent(err)
+			failed = true
+			continue
+		}
+		msgs = append(msgs, msg)
+		index = append(index, i)
+	}
+	return msgs, index, errs, failed
+}
+
+// writeError marks per-message broker errors that a retry cannot fix as permanent.
+func writeError(err error) error {
+	switch {
+	case errors.Is(err, kafkago.MessageSizeTooLarge),
+		errors.Is(err, kafkago.InvalidMessage),
+		errors.Is(err, kafkago.InvalidRecord):
+		return pipeline.Permanent(err)
+	}
+	return err
+}
+
+// allRetryable reports whether every message failed with a non-permanent error.
+func allRetryable(errs kafkago.WriteErrors) bool {
+	for _, err := range errs {
+		if err == nil || pipeline.IsPermanent(writeError(err)) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexOfMessage returns the position of the first message in msgs with the
+// same key and value as target. kafka-go checks sizes in order, so identical
+// messages are rejected first-to-last.
+func indexOfMessage(msgs []kafkago.Message, target kafkago.Message) int {
+	for i := range msgs {
+		if bytes.Equal(msgs[i].Key, target.Key) && bytes.Equal(msgs[i].Value, target.Value) {
+			return i
 		}
-		msgs[i] = msg
 	}
-	return w.writer.WriteMessages(ctx, msgs...)
+	return 0
 }
 
 func (w *Writer) Close() error {
 	return w.writer.Close()
 }
 
-// serializeToMessage marshals a StormEvent into a Kafka message.
-func serializeToMessage(event domain.StormEvent) (kafkago.Message, error) {
-	data, err := json.Marshal(event)
+// serializeToMessage encodes a StormEvent into a Kafka message.
+func serializeToMessage(s Serializer, event domain.StormEvent) (kafkago.Message, error) {
+	data, err := s.Serialize(event)
 	if err != nil {
 		return kafkago.Message{}, fmt.Errorf("serialize storm event: %w", err)
 	}
+	action := "create"
+	if event.Revision > 0 {
+		action = "update"
+	}
+	headers := []kafkago.Header{
+		{Key: "event_type", Value: []byte(event.EventType)},
+		{Key: "processed_at", Value: []byte(event.ProcessedAt.Format(time.RFC3339))},
+		{Key: "event_action", Value: []byte(action)},
+		{Key: "schema_version", Value: []byte(schema.Version)},
+	}
+	if event.Duplicate {
+		headers = append(headers, kafkago.Header{Key: "duplicate", Value: []byte("true")})
+	}
+	headers = injectTraceContext(headers, event.TraceContext)
 	return kafkago.Message{
-		Key:   []byte(event.ID),
-		Value: data,
-		Headers: []kafkago.Header{
-			{Key: "event_type", Value: []byte(event.EventType)},
-			{Key: "processed_at", Value: []byte(event.ProcessedAt.Format(time.RFC3339))},
-		},
+		Key:     []byte(event.ID),
+		Value:   data,
+		Headers: headers,
 	}, nil
 }
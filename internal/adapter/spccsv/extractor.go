@@ -0,0 +1,204 @@
+package spccsv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// Extractor streams the rows of a set of SPC report CSVs, oldest report date
+// first. Each row becomes a RawEvent on partition 0 carrying the RawCSVRecord
+// JSON the collector would have published, timestamped with the report date
+// from its filename. Offsets number the rows across the whole file set, so
+// they are stable for a given set of files. The spc_file and spc_line headers
+// locate the row in its source file.
+//
+// Nothing is checkpointed; the caller decides where to resume with SkipTo.
+// Once every file has been read ExtractBatch returns io.EOF.
+// It implements pipeline.BatchExtractor.
+type Extractor struct {
+	mu    sync.Mutex
+	files []string
+	index int         // next file to open
+	cur   *fileReader // nil between files
+	next  int64       // offset of the next row
+}
+
+// Open resolves pattern to a set of SPC report files. A directory selects
+// the hail, tornado, and wind report files in it and ignores anything else;
+// any other pattern is a glob, and every file it matches must be an SPC
+// report.
+func Open(pattern string) (*Extractor, error) {
+	dir := false
+	if fi, err := os.Stat(pattern); err == nil && fi.IsDir() {
+		pattern = filepath.Join(pattern, "*_rpts_*.csv")
+		dir = true
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPC CSV pattern %q: %w", pattern, err)
+	}
+
+	files := make([]string, 0, len(matches))
+	infos := make(map[string]FileInfo, len(matches))
+	for _, f := range matches {
+		info, err := ParseFilename(f)
+		if err != nil && dir {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		infos[f] = info
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no SPC report files match %s", pattern)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		di, dj := infos[files[i]].Date, infos[files[j]].Date
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return files[i] < files[j]
+	})
+	return &Extractor{files: files}, nil
+}
+
+// Files returns the report files in the order they are read.
+func (e *Extractor) Files() []string {
+	return e.files
+}
+
+// CountRows returns the number of rows across every file, reading each file
+// in full.
+func (e *Extractor) CountRows() (int64, error) {
+	var n int64
+	for _, path := range e.files {
+		fr, err := openFile(path)
+		if err != nil {
+			return 0, err
+		}
+		for {
+			_, err := fr.r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				_ = fr.Close()
+				return 0, fmt.Errorf("read csv %s: %w", path, err)
+			}
+			n++
+		}
+		_ = fr.Close()
+	}
+	return n, nil
+}
+
+// SkipTo skips rows until the next row extracted has the given offset. It only
+// moves forward.
+func (e *Extractor) SkipTo(offset int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for e.next < offset {
+		if _, err := e.read(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// read returns the next row, opening the next file as each one runs out.
+func (e *Extractor) read() (domain.RawEvent, error) {
+	for {
+		if e.cur == nil {
+			if e.index == len(e.files) {
+				return domain.RawEvent{}, io.EOF
+			}
+			cur, err := openFile(e.files[e.index])
+			if err != nil {
+				return domain.RawEvent{}, err
+			}
+			e.cur = cur
+			e.index++
+		}
+
+		rec, err := e.cur.next()
+		if errors.Is(err, io.EOF) {
+			_ = e.cur.Close()
+			e.cur = nil
+			continue
+		}
+		if err != nil {
+			return domain.RawEvent{}, err
+		}
+		value, err := json.Marshal(rec)
+		if err != nil {
+			return domain.RawEvent{}, fmt.Errorf("marshal record: %w", err)
+		}
+
+		name := filepath.Base(e.cur.path)
+		raw := domain.RawEvent{
+			Value: value,
+			Headers: map[string]string{
+				"spc_file": name,
+				"spc_line": strconv.Itoa(e.cur.line()),
+			},
+			Topic:     name,
+			Offset:    e.next,
+			Timestamp: e.cur.info.Date,
+		}
+		e.next++
+		return raw, nil
+	}
+}
+
+// ExtractBatch reads up to batchSize rows, continuing across files. It returns
+// io.EOF once every file has been read.
+func (e *Extractor) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	batch := make([]domain.RawEvent, 0, batchSize)
+	for len(batch) < batchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		raw, err := e.read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, raw)
+	}
+	if len(batch) == 0 {
+		return nil, io.EOF
+	}
+	return batch, nil
+}
+
+// Close closes the file being read, if any.
+func (e *Extractor) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cur == nil {
+		return nil
+	}
+	err := e.cur.Close()
+	e.cur = nil
+	return err
+}
@@ -0,0 +1,160 @@
+// Package spccsv reads NOAA Storm Prediction Center daily report CSVs, such as
+// 240426_rpts_hail.csv, into the RawCSVRecord format the collector publishes.
+package spccsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// filenameRe matches SPC report filenames: the YYMMDD report date, then the
+// report type. The "filtered" variants SPC also publishes are accepted.
+var filenameRe = regexp.MustCompile(`^(\d{6})_rpts_(?:filtered_)?(hail|torn|wind)\.csv$`)
+
+// eventTypes maps the report type in a filename to the event type and the
+// column holding its magnitude.
+var eventTypes = map[string]struct {
+	eventType string
+	magCol    string
+}{
+	"hail": {"hail", "Size"},
+	"torn": {"tornado", "F_Scale"},
+	"wind": {"wind", "Speed"},
+}
+
+// FileInfo is what an SPC report filename says about its contents.
+type FileInfo struct {
+	Date      time.Time // report date (UTC midnight); HHMM times are relative to it
+	EventType string    // "hail", "tornado", or "wind"
+	MagColumn string    // "Size", "F_Scale", or "Speed"
+}
+
+// ParseFilename returns the report date and event type encoded in an SPC
+// report filename.
+func ParseFilename(path string) (FileInfo, error) {
+	m := filenameRe.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return FileInfo{}, fmt.Errorf("not an SPC report file: %s", filepath.Base(path))
+	}
+	date, err := time.Parse("060102", m[1])
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("invalid report date in %s: %w", filepath.Base(path), err)
+	}
+	t := eventTypes[m[2]]
+	return FileInfo{Date: date, EventType: t.eventType, MagColumn: t.magCol}, nil
+}
+
+// ReadFile reads every row of an SPC report CSV.
+func ReadFile(path string) ([]domain.RawCSVRecord, error) {
+	r, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var recs []domain.RawCSVRecord
+	for {
+		rec, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return recs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// fileReader streams the rows of one SPC report CSV.
+type fileReader struct {
+	path string
+	info FileInfo
+	f    *os.File
+	r    *csv.Reader
+	cols map[string]int
+}
+
+func openFile(path string) (*fileReader, error) {
+	info, err := ParseFilename(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1 // short rows leave the missing columns empty
+	header, err := r.Read()
+	if err != nil {
+		_ = f.Close()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("read csv %s: no header", path)
+		}
+		return nil, fmt.Errorf("read csv %s: %w", path, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.TrimSpace(h)] = i
+	}
+	return &fileReader{path: path, info: info, f: f, r: r, cols: cols}, nil
+}
+
+// next returns the next row as a RawCSVRecord, or io.EOF after the last one.
+func (fr *fileReader) next() (domain.RawCSVRecord, error) {
+	row, err := fr.r.Read()
+	if errors.Is(err, io.EOF) {
+		return domain.RawCSVRecord{}, io.EOF
+	}
+	if err != nil {
+		return domain.RawCSVRecord{}, fmt.Errorf("read csv %s: %w", fr.path, err)
+	}
+
+	rec := domain.RawCSVRecord{
+		Time:      fr.get(row, "Time"),
+		Location:  fr.get(row, "Location"),
+		County:    fr.get(row, "County"),
+		State:     fr.get(row, "State"),
+		Lat:       fr.get(row, "Lat"),
+		Lon:       fr.get(row, "Lon"),
+		Comments:  fr.get(row, "Comments"),
+		EventType: fr.info.EventType,
+	}
+	mag := fr.get(row, fr.info.MagColumn)
+	switch fr.info.EventType {
+	case "hail":
+		rec.Size = mag
+	case "tornado":
+		rec.FScale = mag
+	case "wind":
+		rec.Speed = mag
+	}
+	return rec, nil
+}
+
+func (fr *fileReader) get(row []string, col string) string {
+	i, ok := fr.cols[col]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// line returns the 1-based line number of the row last read.
+func (fr *fileReader) line() int {
+	line, _ := fr.r.FieldPos(0)
+	return line
+}
+
+func (fr *fileReader) Close() error {
+	return fr.f.Close()
+}
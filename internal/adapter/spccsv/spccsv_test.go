@@ -0,0 +1,205 @@
+package spccsv
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	hailCSV = "Time,Size,Location,County,State,Lat,Lon,Comments\n" +
+		"1510,125,8 ESE Chappel,San Saba,TX,31.02,-98.44,1.25 inch hail reported. (SJT)\n" +
+		"1703,100,3 SE Burleson,Johnson,TX,32.5,-97.29,\"Quarter hail, reported. (FWD)\"\n"
+	tornCSV = "Time,F_Scale,Location,County,State,Lat,Lon,Comments\n" +
+		"2045,UNK,2 N Elkhorn,Douglas,NE,41.31,-96.24,Tornado reported. (OAX)\n"
+	windCSV = "Time,Speed,Location,County,State,Lat,Lon,Comments\n" +
+		"0005,UNK,Kalamazoo,Kalamazoo,MI,42.29,-85.59,Trees down (GRR)\n"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func extractAll(t *testing.T, e *Extractor, batchSize int) []domain.RawEvent {
+	t.Helper()
+	var all []domain.RawEvent
+	for {
+		batch, err := e.ExtractBatch(context.Background(), batchSize)
+		if err == io.EOF {
+			return all
+		}
+		require.NoError(t, err)
+		all = append(all, batch...)
+	}
+}
+
+func decode(t *testing.T, raw domain.RawEvent) domain.RawCSVRecord {
+	t.Helper()
+	var rec domain.RawCSVRecord
+	require.NoError(t, json.Unmarshal(raw.Value, &rec))
+	return rec
+}
+
+func TestParseFilename(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		magCol    string
+		date      time.Time
+	}{
+		{"240426_rpts_hail.csv", "hail", "Size", time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)},
+		{"archive/2011/110427_rpts_torn.csv", "tornado", "F_Scale", time.Date(2011, 4, 27, 0, 0, 0, 0, time.UTC)},
+		{"990503_rpts_filtered_wind.csv", "wind", "Speed", time.Date(1999, 5, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		info, err := ParseFilename(tt.name)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.eventType, info.EventType, tt.name)
+		assert.Equal(t, tt.magCol, info.MagColumn, tt.name)
+		assert.Equal(t, tt.date, info.Date, tt.name)
+	}
+
+	for _, name := range []string{"240426_rpts.csv", "rpts_hail.csv", "240426_rpts_hail.json", "241350_rpts_hail.csv"} {
+		_, err := ParseFilename(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"240426_rpts_hail.csv": hailCSV})
+
+	recs, err := ReadFile(filepath.Join(dir, "240426_rpts_hail.csv"))
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, domain.RawCSVRecord{
+		Time: "1510", Size: "125", Location: "8 ESE Chappel", County: "San Saba", State: "TX",
+		Lat: "31.02", Lon: "-98.44", Comments: "1.25 inch hail reported. (SJT)", EventType: "hail",
+	}, recs[0])
+	assert.Equal(t, "Quarter hail, reported. (FWD)", recs[1].Comments)
+}
+
+func TestReadFile_ShortRow(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"240426_rpts_wind.csv": "Time,Speed,Location,County,State,Lat,Lon,Comments\n0005,65,Kalamazoo\n",
+	})
+
+	recs, err := ReadFile(filepath.Join(dir, "240426_rpts_wind.csv"))
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "65", recs[0].Speed)
+	assert.Equal(t, "Kalamazoo", recs[0].Location)
+	assert.Empty(t, recs[0].Lat)
+}
+
+func TestExtractor_Directory(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"240426_rpts_hail.csv": hailCSV,
+		"240426_rpts_torn.csv": tornCSV,
+		"240425_rpts_wind.csv": windCSV,
+		"240426_rpts.csv":      "not a per-type report",
+		"README.md":            "ignored",
+	})
+
+	e, err := Open(dir)
+	require.NoError(t, err)
+	defer e.Close()
+
+	// Oldest report date first, then by filename.
+	var names []string
+	for _, f := range e.Files() {
+		names = append(names, filepath.Base(f))
+	}
+	assert.Equal(t, []string{"240425_rpts_wind.csv", "240426_rpts_hail.csv", "240426_rpts_torn.csv"}, names)
+
+	raws := extractAll(t, e, 2)
+	require.Len(t, raws, 4)
+	var types []string
+	for i, raw := range raws {
+		assert.Equal(t, int64(i), raw.Offset)
+		assert.Equal(t, 0, raw.Partition)
+		types = append(types, decode(t, raw).EventType)
+	}
+	assert.Equal(t, []string{"wind", "hail", "hail", "tornado"}, types)
+
+	assert.Equal(t, time.Date(2024, 4, 25, 0, 0, 0, 0, time.UTC), raws[0].Timestamp)
+	assert.Equal(t, time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC), raws[1].Timestamp)
+	assert.Equal(t, "240426_rpts_hail.csv", raws[2].Topic)
+	assert.Equal(t, map[string]string{"spc_file": "240426_rpts_hail.csv", "spc_line": "3"}, raws[2].Headers)
+	assert.Equal(t, "UNK", decode(t, raws[3]).FScale)
+}
+
+func TestExtractor_Glob(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"240426_rpts_hail.csv": hailCSV,
+		"240426_rpts_torn.csv": tornCSV,
+	})
+
+	e, err := Open(filepath.Join(dir, "*_hail.csv"))
+	require.NoError(t, err)
+	defer e.Close()
+	assert.Len(t, extractAll(t, e, 10), 2)
+
+	// A glob must only match report files.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.csv"), []byte("x"), 0o644))
+	_, err = Open(filepath.Join(dir, "*.csv"))
+	assert.ErrorContains(t, err, "notes.csv")
+
+	_, err = Open(filepath.Join(dir, "*_wind.csv"))
+	assert.ErrorContains(t, err, "no SPC report files")
+}
+
+func TestExtractor_SkipTo(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"240426_rpts_hail.csv": hailCSV,
+		"240426_rpts_torn.csv": tornCSV,
+	})
+
+	e, err := Open(dir)
+	require.NoError(t, err)
+	defer e.Close()
+
+	// Resuming in the middle of the set continues across the file boundary
+	// with the same offsets as a full read.
+	require.NoError(t, e.SkipTo(1))
+	raws := extractAll(t, e, 10)
+	require.Len(t, raws, 2)
+	assert.Equal(t, int64(1), raws[0].Offset)
+	assert.Equal(t, "1703", decode(t, raws[0]).Time)
+	assert.Equal(t, "tornado", decode(t, raws[1]).EventType)
+
+	// Skipping past the end leaves nothing to extract.
+	require.NoError(t, e.SkipTo(100))
+	_, err = e.ExtractBatch(context.Background(), 10)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestExtractor_CountRows(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"240426_rpts_hail.csv": hailCSV,
+		"240426_rpts_torn.csv": tornCSV,
+		"240425_rpts_wind.csv": windCSV,
+	})
+
+	e, err := Open(dir)
+	require.NoError(t, err)
+	defer e.Close()
+
+	n, err := e.CountRows()
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+	// Counting does not consume rows.
+	assert.Len(t, extractAll(t, e, 10), 4)
+}
@@ -2,25 +2,109 @@ package config
 
 import (
 	"errors"
-
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/schema"
 	sharedcfg "github.com/couchcryptid/storm-data-shared/config"
 )
 
+// Endpoint is a parsed SOURCE or SINK URI: "kafka", or "<scheme>://<path>"
+// with optional query parameters for a local adapter.
+type Endpoint struct {
+	Scheme string // "kafka", "file", or (SOURCE only) "spccsv"
+	Path   string
+	Params url.Values
+}
+
 // Config holds all service settings, populated from environment variables.
 type Config struct {
+	// Source and Sink select where raw reports are read from and enriched
+	// events written to. Kafka uses the KAFKA_* settings below.
+	Source Endpoint
+	Sink   Endpoint
+	// SinkFormat is json, avro, or protobuf. Avro and Protobuf messages are
+	// framed in the Confluent wire format with the ID of a schema registered
+	// at SchemaRegistryURL.
+	SinkFormat        string
+	SchemaRegistryURL string
+	// SinkSchemaValidation checks every event against the JSON Schema of the
+	// sink message before producing it. It requires SeverityRules to use only
+	// the severity labels the schema allows.
+	SinkSchemaValidation bool
+
+	// DeliveryGuarantee is at-least-once or exactly-once. Exactly-once writes
+	// each batch and commits its source offsets in one Kafka transaction
+	// under KafkaTransactionalID, which must be unique per instance. It needs
+	// a Kafka SOURCE and SINK and a single pipeline worker.
+	DeliveryGuarantee    string
+	KafkaTransactionalID string
+
 	KafkaBrokers     []string
 	KafkaSourceTopic string
 	KafkaSinkTopic   string
+	KafkaDLQTopic    string
 	KafkaGroupID     string
 	HTTPAddr         string
 	LogLevel         string
 	LogFormat        string
 	ShutdownTimeout  time.Duration
+	ValidationMode   string
+
+	// AdminToken is the bearer token for the admin HTTP endpoints; empty
+	// disables them.
+	AdminToken string
+
+	// TracesExporter is none, otlp, or stdout. The OTLP exporter reads its
+	// endpoint and headers from the standard OTEL_EXPORTER_OTLP_* variables.
+	TracesExporter string
+
+	// EnrichmentSteps lists enricher names in execution order.
+	EnrichmentSteps []string
+	// SeverityRules is loaded from SEVERITY_RULES_FILE, or the embedded default.
+	SeverityRules *domain.SeverityRules
+	// CountyBoundariesFile and ForecastZonesFile are GeoJSON boundary files
+	// for the geocode step, and PlacesGazetteerFile is a Census Gazetteer
+	// places file for the place step. Each overrides the embedded default
+	// when set. All are loaded by geo.LoadEnricherDeps.
+	CountyBoundariesFile string
+	ForecastZonesFile    string
+	PlacesGazetteerFile  string
+	// RepairGeo fills in missing coordinates from the place step's projection.
+	RepairGeo bool
+
+	// DedupMode is off, drop, or tag. Repeats of an ID loaded within
+	// DedupWindow are dropped or tagged. DedupStorePath selects the on-disk
+	// store; when empty an in-memory store of DedupCacheSize IDs is used.
+	DedupMode      string
+	DedupWindow    time.Duration
+	DedupStorePath string
+	DedupCacheSize int
 
 	BatchSize          int
 	BatchFlushInterval time.Duration
+	// PipelineWorkers is the number of partition workers; 1 processes
+	// batches on a single goroutine.
+	PipelineWorkers int
+	// LoadMaxAttempts bounds how often an event that fails to load on its
+	// own is retried before it is dead-lettered.
+	LoadMaxAttempts int
+	// LoadBreakerThreshold consecutive failed loads open the load circuit
+	// breaker for LoadBreakerOpenDuration; 0 disables it.
+	LoadBreakerThreshold    int
+	LoadBreakerOpenDuration time.Duration
+
+	// ReadinessStaleAfter is how long batches may stay in flight without one
+	// completing before /readyz fails; ReadinessMaxLag fails it when consumer
+	// lag exceeds the limit (0 disables the lag check).
+	ReadinessStaleAfter time.Duration
+	ReadinessMaxLag     int64
 }
 
 // Load reads configuration from environment variables, applying defaults where unset.
@@ -40,17 +124,58 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	workers, err := strconv.Atoi(sharedcfg.EnvOrDefault("PIPELINE_WORKERS", "1"))
+	if err != nil || workers < 1 || workers > 64 {
+		return nil, errors.New("invalid PIPELINE_WORKERS: must be an integer between 1 and 64")
+	}
+
+	loadAttempts, err := strconv.Atoi(sharedcfg.EnvOrDefault("LOAD_MAX_ATTEMPTS", "5"))
+	if err != nil || loadAttempts < 1 {
+		return nil, errors.New("invalid LOAD_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	breakerThreshold, err := strconv.Atoi(sharedcfg.EnvOrDefault("LOAD_BREAKER_THRESHOLD", "5"))
+	if err != nil || breakerThreshold < 0 {
+		return nil, errors.New("invalid LOAD_BREAKER_THRESHOLD: must be a non-negative integer")
+	}
+
+	breakerOpen, err := time.ParseDuration(sharedcfg.EnvOrDefault("LOAD_BREAKER_OPEN_DURATION", "30s"))
+	if err != nil || breakerOpen <= 0 {
+		return nil, errors.New("invalid LOAD_BREAKER_OPEN_DURATION: must be a positive duration")
+	}
+
+	staleAfter, err := time.ParseDuration(sharedcfg.EnvOrDefault("READINESS_STALE_AFTER", "5m"))
+	if err != nil || staleAfter <= 0 {
+		return nil, errors.New("invalid READINESS_STALE_AFTER: must be a positive duration")
+	}
+
+	maxLag, err := strconv.ParseInt(sharedcfg.EnvOrDefault("READINESS_MAX_LAG", "0"), 10, 64)
+	if err != nil || maxLag < 0 {
+		return nil, errors.New("invalid READINESS_MAX_LAG: must be a non-negative integer")
+	}
+
 	cfg := &Config{
 		KafkaBrokers:       sharedcfg.ParseBrokers(sharedcfg.EnvOrDefault("KAFKA_BROKERS", "kafka:9092")),
 		KafkaSourceTopic:   sharedcfg.EnvOrDefault("KAFKA_SOURCE_TOPIC", "raw-weather-reports"),
 		KafkaSinkTopic:     sharedcfg.EnvOrDefault("KAFKA_SINK_TOPIC", "transformed-weather-data"),
+		KafkaDLQTopic:      sharedcfg.EnvOrDefault("KAFKA_DLQ_TOPIC", ""),
 		KafkaGroupID:       sharedcfg.EnvOrDefault("KAFKA_GROUP_ID", "storm-data-etl"),
 		HTTPAddr:           sharedcfg.EnvOrDefault("HTTP_ADDR", ":8080"),
 		LogLevel:           sharedcfg.EnvOrDefault("LOG_LEVEL", "info"),
 		LogFormat:          sharedcfg.EnvOrDefault("LOG_FORMAT", "json"),
 		ShutdownTimeout:    shutdownTimeout,
+		ValidationMode:     sharedcfg.EnvOrDefault("VALIDATION_MODE", "lenient"),
+		TracesExporter:     sharedcfg.EnvOrDefault("OTEL_TRACES_EXPORTER", "none"),
+		AdminToken:         os.Getenv("ADMIN_TOKEN"),
 		BatchSize:          batchSize,
 		BatchFlushInterval: flushInterval,
+		PipelineWorkers:    workers,
+		LoadMaxAttempts:    loadAttempts,
+
+		LoadBreakerThreshold:    breakerThreshold,
+		LoadBreakerOpenDuration: breakerOpen,
+		ReadinessStaleAfter:     staleAfter,
+		ReadinessMaxLag:         maxLag,
 	}
 
 	if len(cfg.KafkaBrokers) == 0 {
@@ -62,6 +187,291 @@ func Load() (*Config, error) {
 	if cfg.KafkaSinkTopic == "" {
 		return nil, errors.New("KAFKA_SINK_TOPIC is required")
 	}
+	if cfg.KafkaDLQTopic != "" && (cfg.KafkaDLQTopic == cfg.KafkaSourceTopic || cfg.KafkaDLQTopic == cfg.KafkaSinkTopic) {
+		return nil, errors.New("KAFKA_DLQ_TOPIC must differ from the source and sink topics")
+	}
+	if cfg.Source, err = parseEndpoint("SOURCE", "file", "spccsv"); err != nil {
+		return nil, err
+	}
+	if cfg.Sink, err = parseEndpoint("SINK", "file"); err != nil {
+		return nil, err
+	}
+	if cfg.Source.Scheme == "file" && cfg.Sink.Scheme == "file" && cfg.Source.Path == cfg.Sink.Path {
+		return nil, errors.New("SOURCE and SINK must be different files")
+	}
+	if err := loadSinkFormat(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadDelivery(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadEnrichment(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadDedup(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.SinkSchemaValidation {
+		if err := schema.CheckSeverityRules(cfg.SeverityRules); err != nil {
+			return nil, fmt.Errorf("SINK_SCHEMA_VALIDATION with SEVERITY_RULES_FILE: %w", err)
+		}
+	}
+
+	switch cfg.ValidationMode {
+	case "lenient", "warn", "strict":
+	default:
+		return nil, fmt.Errorf("VALIDATION_MODE must be lenient, warn, or strict, got %q", cfg.ValidationMode)
+	}
+
+	switch cfg.TracesExporter {
+	case "none", "otlp", "stdout":
+	default:
+		return nil, fmt.Errorf("OTEL_TRACES_EXPORTER must be none, otlp, or stdout, got %q", cfg.TracesExporter)
+	}
 
 	return cfg, nil
 }
+
+// parseEndpoint reads the URI in the named variable, which may be kafka (the
+// default) or use one of schemes. Paths may be absolute (file:///data/x.json)
+// or relative (file://data/x.json).
+func parseEndpoint(name string, schemes ...string) (Endpoint, error) {
+	raw := sharedcfg.EnvOrDefault(name, "kafka")
+	if raw == "kafka" || raw == "kafka://" {
+		return Endpoint{Scheme: "kafka"}, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || !slices.Contains(schemes, u.Scheme) {
+		allowed := []string{"kafka"}
+		for _, s := range schemes {
+			allowed = append(allowed, s+"://<path>")
+		}
+		return Endpoint{}, fmt.Errorf("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), raw)
+	}
+	path := u.Host + u.Path
+	if path == "" {
+		return Endpoint{}, fmt.Errorf("%s %s URI has no path", name, u.Scheme)
+	}
+	return Endpoint{Scheme: u.Scheme, Path: path, Params: u.Query()}, nil
+}
+
+// String formats e as a URI.
+func (e Endpoint) String() string {
+	if e.Scheme == "kafka" {
+		return e.Scheme
+	}
+	s := e.Scheme + "://" + e.Path
+	if len(e.Params) > 0 {
+		s += "?" + e.Params.Encode()
+	}
+	return s
+}
+
+// redacted replaces the value of a secret setting.
+const redacted = "[redacted]"
+
+// Snapshot returns the effective settings keyed by environment variable,
+// with secrets redacted, for the admin status endpoint.
+func (c *Config) Snapshot() map[string]string {
+	adminToken := ""
+	if c.AdminToken != "" {
+		adminToken = redacted
+	}
+	severityVersion := ""
+	if c.SeverityRules != nil {
+		severityVersion = c.SeverityRules.Version
+	}
+	return map[string]string{
+		"SOURCE":                     c.Source.String(),
+		"SINK":                       c.Sink.String(),
+		"SINK_FORMAT":                c.SinkFormat,
+		"SCHEMA_REGISTRY_URL":        redactURL(c.SchemaRegistryURL),
+		"SINK_SCHEMA_VALIDATION":     strconv.FormatBool(c.SinkSchemaValidation),
+		"DELIVERY_GUARANTEE":         c.DeliveryGuarantee,
+		"KAFKA_TRANSACTIONAL_ID":     c.KafkaTransactionalID,
+		"KAFKA_BROKERS":              strings.Join(c.KafkaBrokers, ","),
+		"KAFKA_SOURCE_TOPIC":         c.KafkaSourceTopic,
+		"KAFKA_SINK_TOPIC":           c.KafkaSinkTopic,
+		"KAFKA_DLQ_TOPIC":            c.KafkaDLQTopic,
+		"KAFKA_GROUP_ID":             c.KafkaGroupID,
+		"HTTP_ADDR":                  c.HTTPAddr,
+		"LOG_LEVEL":                  c.LogLevel,
+		"LOG_FORMAT":                 c.LogFormat,
+		"SHUTDOWN_TIMEOUT":           c.ShutdownTimeout.String(),
+		"VALIDATION_MODE":            c.ValidationMode,
+		"ADMIN_TOKEN":                adminToken,
+		"OTEL_TRACES_EXPORTER":       c.TracesExporter,
+		"ENRICHMENT_STEPS":           strings.Join(c.EnrichmentSteps, ","),
+		"SEVERITY_RULES_VERSION":     severityVersion,
+		"COUNTY_BOUNDARIES_FILE":     c.CountyBoundariesFile,
+		"FORECAST_ZONES_FILE":        c.ForecastZonesFile,
+		"PLACES_GAZETTEER_FILE":      c.PlacesGazetteerFile,
+		"GEO_REPAIR":                 strconv.FormatBool(c.RepairGeo),
+		"DEDUP_MODE":                 c.DedupMode,
+		"DEDUP_WINDOW":               c.DedupWindow.String(),
+		"DEDUP_STORE_PATH":           c.DedupStorePath,
+		"DEDUP_CACHE_SIZE":           strconv.Itoa(c.DedupCacheSize),
+		"BATCH_SIZE":                 strconv.Itoa(c.BatchSize),
+		"BATCH_FLUSH_INTERVAL":       c.BatchFlushInterval.String(),
+		"PIPELINE_WORKERS":           strconv.Itoa(c.PipelineWorkers),
+		"LOAD_MAX_ATTEMPTS":          strconv.Itoa(c.LoadMaxAttempts),
+		"LOAD_BREAKER_THRESHOLD":     strconv.Itoa(c.LoadBreakerThreshold),
+		"LOAD_BREAKER_OPEN_DURATION": c.LoadBreakerOpenDuration.String(),
+		"READINESS_STALE_AFTER":      c.ReadinessStaleAfter.String(),
+		"READINESS_MAX_LAG":          strconv.FormatInt(c.ReadinessMaxLag, 10),
+	}
+}
+
+// redactURL hides the password of a URL with credentials.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+// loadSinkFormat reads and validates the sink message encoding and checks.
+func loadSinkFormat(cfg *Config) error {
+	cfg.SinkFormat = sharedcfg.EnvOrDefault("SINK_FORMAT", "json")
+	cfg.SchemaRegistryURL = sharedcfg.EnvOrDefault("SCHEMA_REGISTRY_URL", "")
+	validate, err := strconv.ParseBool(sharedcfg.EnvOrDefault("SINK_SCHEMA_VALIDATION", "false"))
+	if err != nil {
+		return fmt.Errorf("SINK_SCHEMA_VALIDATION: %w", err)
+	}
+	cfg.SinkSchemaValidation = validate
+	switch cfg.SinkFormat {
+	case "json":
+		return nil
+	case "avro", "protobuf":
+	default:
+		return fmt.Errorf("SINK_FORMAT must be json, avro, or protobuf, got %q", cfg.SinkFormat)
+	}
+	if cfg.Sink.Scheme != "kafka" {
+		return fmt.Errorf("SINK_FORMAT=%s requires a Kafka SINK", cfg.SinkFormat)
+	}
+	if cfg.SchemaRegistryURL == "" {
+		return fmt.Errorf("SINK_FORMAT=%s requires SCHEMA_REGISTRY_URL", cfg.SinkFormat)
+	}
+	u, err := url.Parse(cfg.SchemaRegistryURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid SCHEMA_REGISTRY_URL: must be an http or https URL")
+	}
+	return nil
+}
+
+// loadDelivery reads and validates the delivery guarantee.
+func loadDelivery(cfg *Config) error {
+	cfg.DeliveryGuarantee = sharedcfg.EnvOrDefault("DELIVERY_GUARANTEE", "at-least-once")
+	cfg.KafkaTransactionalID = os.Getenv("KAFKA_TRANSACTIONAL_ID")
+	switch cfg.DeliveryGuarantee {
+	case "at-least-once":
+		return nil
+	case "exactly-once":
+	default:
+		return fmt.Errorf("DELIVERY_GUARANTEE must be at-least-once or exactly-once, got %q", cfg.DeliveryGuarantee)
+	}
+	if cfg.Source.Scheme != "kafka" || cfg.Sink.Scheme != "kafka" {
+		return errors.New("DELIVERY_GUARANTEE=exactly-once requires a Kafka SOURCE and SINK")
+	}
+	if cfg.PipelineWorkers != 1 {
+		return errors.New("DELIVERY_GUARANTEE=exactly-once requires PIPELINE_WORKERS=1")
+	}
+	// There is no default: replicas sharing an ID fence each other, so each
+	// instance must be given its own, stable across restarts.
+	if cfg.KafkaTransactionalID == "" {
+		return errors.New("DELIVERY_GUARANTEE=exactly-once requires KAFKA_TRANSACTIONAL_ID, unique to each instance")
+	}
+	return nil
+}
+
+// loadDedup reads and validates the duplicate-suppression settings.
+func loadDedup(cfg *Config) error {
+	cfg.DedupMode = sharedcfg.EnvOrDefault("DEDUP_MODE", "off")
+	switch cfg.DedupMode {
+	case "off", "drop", "tag":
+	default:
+		return fmt.Errorf("DEDUP_MODE must be off, drop, or tag, got %q", cfg.DedupMode)
+	}
+
+	window, err := time.ParseDuration(sharedcfg.EnvOrDefault("DEDUP_WINDOW", "24h"))
+	if err != nil || window <= 0 {
+		return errors.New("invalid DEDUP_WINDOW: must be a positive duration")
+	}
+	cfg.DedupWindow = window
+
+	size, err := strconv.Atoi(sharedcfg.EnvOrDefault("DEDUP_CACHE_SIZE", "100000"))
+	if err != nil || size <= 0 {
+		return errors.New("invalid DEDUP_CACHE_SIZE: must be a positive integer")
+	}
+	cfg.DedupCacheSize = size
+
+	cfg.DedupStorePath = sharedcfg.EnvOrDefault("DEDUP_STORE_PATH", "")
+	return nil
+}
+
+// loadEnrichment resolves the enrichment chain and loads or checks the data
+// files its steps need.
+func loadEnrichment(cfg *Config) error {
+	cfg.CountyBoundariesFile = sharedcfg.EnvOrDefault("COUNTY_BOUNDARIES_FILE", "")
+	cfg.ForecastZonesFile = sharedcfg.EnvOrDefault("FORECAST_ZONES_FILE", "")
+	cfg.PlacesGazetteerFile = sharedcfg.EnvOrDefault("PLACES_GAZETTEER_FILE", "")
+	for _, f := range []struct{ env, path string }{
+		{"COUNTY_BOUNDARIES_FILE", cfg.CountyBoundariesFile},
+		{"FORECAST_ZONES_FILE", cfg.ForecastZonesFile},
+		{"PLACES_GAZETTEER_FILE", cfg.PlacesGazetteerFile},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			return fmt.Errorf("%s: %w", f.env, err)
+		}
+	}
+
+	repair, err := strconv.ParseBool(sharedcfg.EnvOrDefault("GEO_REPAIR", "false"))
+	if err != nil {
+		return fmt.Errorf("GEO_REPAIR: %w", err)
+	}
+	cfg.RepairGeo = repair
+
+	// Data files opt in to their steps without having to restate the chain.
+	// place runs before geocode so repaired coordinates are geocoded.
+	defaults := domain.DefaultEnricherNames()
+	at := slices.Index(defaults, "location") + 1
+	if cfg.CountyBoundariesFile != "" || cfg.ForecastZonesFile != "" {
+		defaults = slices.Insert(defaults, at, "geocode")
+	}
+	if cfg.PlacesGazetteerFile != "" {
+		defaults = slices.Insert(defaults, at, "place")
+	}
+	cfg.EnrichmentSteps = parseList(sharedcfg.EnvOrDefault("ENRICHMENT_STEPS", strings.Join(defaults, ",")))
+	if err := domain.CheckEnricherNames(cfg.EnrichmentSteps); err != nil {
+		return fmt.Errorf("ENRICHMENT_STEPS: %w", err)
+	}
+	if cfg.RepairGeo && !slices.Contains(cfg.EnrichmentSteps, "place") {
+		return errors.New("GEO_REPAIR requires the place enrichment step")
+	}
+
+	cfg.SeverityRules = domain.DefaultSeverityRules()
+	if path := sharedcfg.EnvOrDefault("SEVERITY_RULES_FILE", ""); path != "" {
+		rules, err := domain.LoadSeverityRules(path)
+		if err != nil {
+			return fmt.Errorf("SEVERITY_RULES_FILE: %w", err)
+		}
+		cfg.SeverityRules = rules
+	}
+	return nil
+}
+
+// parseList splits a comma-separated value, trimming whitespace and dropping
+// empty entries.
+func parseList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
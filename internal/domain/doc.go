@@ -57,6 +57,9 @@
 //	  Wind:    <50 mph minor | <74 mph moderate | <96 mph severe | ≥96 mph extreme
 //	  Tornado: EF0–1 minor | EF2 moderate | EF3–4 severe | EF5 extreme
 //
+//	These are the embedded defaults (severity_rules.yaml). Deployments can
+//	supply their own tiers; see [SeverityRules].
+//
 // # ID Generation
 //
 // Event IDs are deterministic SHA-256 hashes of event_type|state|lat|lon|time|magnitude. This
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Enricher is a single enrichment step applied to a parsed StormEvent.
+// Steps mutate the event in place and run in the order they are chained.
+type Enricher interface {
+	Name() string
+	Enrich(ctx context.Context, event *StormEvent) error
+}
+
+// enricherFunc adapts an infallible enrichment helper to the Enricher interface.
+type enricherFunc struct {
+	name string
+	fn   func(event *StormEvent)
+}
+
+func (e enricherFunc) Name() string { return e.name }
+
+func (e enricherFunc) Enrich(_ context.Context, event *StormEvent) error {
+	e.fn(event)
+	return nil
+}
+
+// EnricherDeps supplies the data that configurable built-in enrichers need.
+// A nil SeverityRules falls back to the embedded defaults. Optional steps
+// (see OptionalEnricherNames) are only available when their dependency is set.
+type EnricherDeps struct {
+	SeverityRules *SeverityRules
+	Geocoder      Geocoder
+	Gazetteer     Gazetteer
+	// RepairGeo lets the place step fill in missing coordinates from the
+	// projected reference location.
+	RepairGeo bool
+}
+
+// builtinEnrichers lists the built-in steps in their default order. Later
+// steps depend on earlier ones: unit needs the normalized event type,
+// magnitude needs the unit, and severity needs the normalized magnitude.
+func builtinEnrichers(deps EnricherDeps) []Enricher {
+	rules := deps.SeverityRules
+	if rules == nil {
+		rules = defaultSeverityRules
+	}
+
+	return []Enricher{
+		enricherFunc{"event_type", enrichEventType},
+		enricherFunc{"unit", enrichUnit},
+		enricherFunc{"magnitude", enrichMagnitude},
+		enricherFunc{"severity", func(e *StormEvent) {
+			e.Measurement.Severity = rules.Classify(e.EventType, e.Measurement.Magnitude)
+			e.SeverityRulesVersion = rules.Version
+		}},
+		enricherFunc{"source_office", func(e *StormEvent) {
+			e.SourceOffice = extractSourceOffice(e.Comments)
+		}},
+		enricherFunc{"location", func(e *StormEvent) {
+			e.Location.Name, e.Location.Distance, e.Location.Direction = parseLocation(e.Location.Raw)
+		}},
+		enricherFunc{"time_bucket", func(e *StormEvent) {
+			e.TimeBucket = deriveTimeBucket(e.EventTime)
+		}},
+		enricherFunc{"processed_at", func(e *StormEvent) {
+			e.ProcessedAt = clock.Now()
+		}},
+	}
+}
+
+// DefaultEnricherNames returns the names of the built-in enrichers in their
+// default order.
+func DefaultEnricherNames() []string {
+	builtins := builtinEnrichers(EnricherDeps{})
+	names := make([]string, len(builtins))
+	for i, e := range builtins {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+// optionalEnrichers lists steps that are not in the default chain because they
+// need external data. A step is nil when its dependency is missing.
+func optionalEnrichers(deps EnricherDeps) map[string]Enricher {
+	opt := map[string]Enricher{"geocode": nil, "place": nil}
+	if deps.Geocoder != nil {
+		opt["geocode"] = enricherFunc{"geocode", enrichGeocode(deps.Geocoder)}
+	}
+	if deps.Gazetteer != nil {
+		opt["place"] = enricherFunc{"place", enrichPlace(deps.Gazetteer, deps.RepairGeo)}
+	}
+	return opt
+}
+
+// OptionalEnricherNames returns the names of enrichers that can be added to
+// a chain but are not part of the default order.
+func OptionalEnricherNames() []string {
+	names := slices.Collect(maps.Keys(optionalEnrichers(EnricherDeps{})))
+	slices.Sort(names)
+	return names
+}
+
+// DefaultEnrichers returns the built-in enrichment chain used by EnrichStormEvent.
+func DefaultEnrichers() []Enricher {
+	return builtinEnrichers(EnricherDeps{})
+}
+
+// CheckEnricherNames rejects unknown and repeated enricher names without
+// resolving dependencies.
+func CheckEnricherNames(names []string) error {
+	builtins := builtinEnrichers(EnricherDeps{})
+	optional := optionalEnrichers(EnricherDeps{})
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return fmt.Errorf("enricher %q listed more than once", name)
+		}
+		seen[name] = true
+
+		_, isOptional := optional[name]
+		if !isOptional && !slices.ContainsFunc(builtins, func(e Enricher) bool { return e.Name() == name }) {
+			return fmt.Errorf("unknown enricher %q", name)
+		}
+	}
+	return nil
+}
+
+// Enrichers resolves enrichers by name, preserving the given order. It
+// rejects unknown and repeated names, and optional steps whose dependency
+// is missing from deps.
+func Enrichers(names []string, deps EnricherDeps) ([]Enricher, error) {
+	if err := CheckEnricherNames(names); err != nil {
+		return nil, err
+	}
+
+	builtins := builtinEnrichers(deps)
+	optional := optionalEnrichers(deps)
+	chain := make([]Enricher, 0, len(names))
+	for _, name := range names {
+		if i := slices.IndexFunc(builtins, func(e Enricher) bool { return e.Name() == name }); i >= 0 {
+			chain = append(chain, builtins[i])
+			continue
+		}
+		e := optional[name]
+		if e == nil {
+			return nil, fmt.Errorf("enricher %q is not configured", name)
+		}
+		chain = append(chain, e)
+	}
+	return chain, nil
+}
+
+// enrichEventType normalizes the event type, recording a missing or
+// unrecognized source value in Quality.
+func enrichEventType(event *StormEvent) {
+	rawType := event.EventType
+	event.EventType = normalizeEventType(rawType)
+	switch {
+	case rawType == "":
+		event.Quality.record("event_type", fieldEmpty)
+	case event.EventType == "":
+		event.Quality.record("event_type", fieldUnparseable)
+	}
+}
+
+// enrichUnit fills in the default unit for the event type, recording it as inferred.
+func enrichUnit(event *StormEvent) {
+	unit := normalizeUnit(event.EventType, event.Measurement.Unit)
+	if event.Measurement.Unit == "" && unit != "" {
+		event.Quality.record("measurement.unit", fieldInferred)
+	}
+	event.Measurement.Unit = unit
+}
+
+// enrichMagnitude corrects known magnitude encoding issues, recording any
+// rewrite as a correction.
+func enrichMagnitude(event *StormEvent) {
+	magnitude := normalizeMagnitude(event.EventType, event.Measurement.Magnitude, event.Measurement.Unit)
+	if magnitude != event.Measurement.Magnitude {
+		event.Quality.record("measurement.magnitude", fieldCorrected)
+	}
+	event.Measurement.Magnitude = magnitude
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultEnricherNames(t *testing.T) {
+	assert.Equal(t, []string{
+		"event_type", "unit", "magnitude", "severity",
+		"source_office", "location", "time_bucket", "processed_at",
+	}, DefaultEnricherNames())
+}
+
+func TestEnrichers(t *testing.T) {
+	t.Run("preserves order", func(t *testing.T) {
+		chain, err := Enrichers([]string{"location", "event_type"}, EnricherDeps{})
+		require.NoError(t, err)
+		require.Len(t, chain, 2)
+		assert.Equal(t, "location", chain[0].Name())
+		assert.Equal(t, "event_type", chain[1].Name())
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		_, err := Enrichers([]string{"event_type", "geocode"}, EnricherDeps{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"geocode"`)
+	})
+
+	t.Run("repeated name", func(t *testing.T) {
+		_, err := Enrichers([]string{"unit", "unit"}, EnricherDeps{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "more than once")
+	})
+
+	t.Run("subset only applies listed steps", func(t *testing.T) {
+		chain, err := Enrichers([]string{"source_office"}, EnricherDeps{})
+		require.NoError(t, err)
+
+		event := StormEvent{EventType: "hail", Measurement: Measurement{Magnitude: 175}, Comments: "Hail. (FWD)"}
+		for _, e := range chain {
+			require.NoError(t, e.Enrich(context.Background(), &event))
+		}
+		assert.Equal(t, "FWD", event.SourceOffice)
+		assert.InDelta(t, 175.0, event.Measurement.Magnitude, 0.0001)
+		assert.Empty(t, event.Measurement.Unit)
+		assert.True(t, event.ProcessedAt.IsZero())
+	})
+}
+
+func TestDefaultEnrichers_MatchesEnrichStormEvent(t *testing.T) {
+	SetClock(clockwork.NewFakeClockAt(time.Date(2024, 4, 26, 16, 0, 0, 0, time.UTC)))
+	t.Cleanup(func() { SetClock(nil) })
+
+	event := StormEvent{
+		EventType:   "hail",
+		Measurement: Measurement{Magnitude: 175},
+		Location:    Location{Raw: "8 ESE Chappel"},
+		Comments:    "Quarter hail reported. (FWD)",
+		EventTime:   time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC),
+	}
+
+	chained := event
+	for _, e := range DefaultEnrichers() {
+		require.NoError(t, e.Enrich(context.Background(), &chained))
+	}
+	assert.Equal(t, EnrichStormEvent(event), chained)
+}
@@ -3,6 +3,8 @@ package domain
 import (
 	"context"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RawCSVRecord represents the flat JSON structure produced by the collector.
@@ -32,12 +34,31 @@ type RawEvent struct {
 	Offset    int64
 	Timestamp time.Time
 	Commit    func(ctx context.Context) error
+
+	// TraceContext is the W3C trace context the producer attached to the
+	// message, if any.
+	TraceContext trace.SpanContext
+
+	// Generation is the consumer group generation the message was fetched
+	// in, for sources that manage their own group membership; nil otherwise.
+	Generation *GroupGeneration
+}
+
+// GroupGeneration identifies a consumer group member in one generation of the
+// group. An offset commit that names it is rejected once the group has
+// rebalanced, which fences a member that lost its partitions.
+type GroupGeneration struct {
+	ID       int
+	MemberID string
 }
 
 // Location holds both the raw NWS location string and its parsed components.
 // Nested because these fields are tightly coupled: enrichment parses the raw
-// NWS format ("8 ESE Chappel") into name/distance/direction, and all six fields
+// NWS format ("8 ESE Chappel") into name/distance/direction, and all fields
 // travel together through the pipeline. The API flattens to location_* columns.
+//
+// State and County are as reported by the collector. The geocoded fields are
+// derived from Geo by the optional geocode enrichment step.
 type Location struct {
 	Raw       string   `json:"raw,omitempty"`
 	Name      string   `json:"name,omitempty"`
@@ -45,6 +66,23 @@ type Location struct {
 	Direction *string  `json:"direction,omitempty"`
 	State     string   `json:"state,omitempty"`
 	County    string   `json:"county,omitempty"`
+
+	CountyFIPS      string `json:"county_fips,omitempty"`
+	CanonicalCounty string `json:"canonical_county,omitempty"`
+	StateFIPS       string `json:"state_fips,omitempty"`
+	ForecastZone    string `json:"forecast_zone,omitempty"`
+	// CountyMismatch is true when the reported county or state disagrees
+	// with the geocoded county.
+	CountyMismatch bool `json:"county_mismatch,omitempty"`
+
+	// ProjectedGeo is the point Distance miles toward Direction from the
+	// gazetteer coordinates of Name, set by the optional place step.
+	ProjectedGeo *Geo `json:"projected_geo,omitempty"`
+	// GeoDiscrepancyKm is the distance between the reported Geo and ProjectedGeo.
+	GeoDiscrepancyKm *float64 `json:"geo_discrepancy_km,omitempty"`
+	// GeoIssue names the likely coordinate error (see GeoIssueTransposed and
+	// friends) when correcting it brings Geo close to ProjectedGeo.
+	GeoIssue string `json:"geo_issue,omitempty"`
 }
 
 // Geo represents a WGS-84 latitude/longitude coordinate pair.
@@ -66,6 +104,29 @@ type Measurement struct {
 	Severity  *string `json:"severity,omitempty"`
 }
 
+// Quality lists output fields whose values did not come verbatim from the
+// source payload, so consumers can tell a measured 0 from a missing one.
+// Entries are JSON paths of the output message, e.g. "measurement.magnitude".
+// A field appears in at most one list except "measurement.magnitude", which
+// can be both parsed and Corrected.
+type Quality struct {
+	// Defaulted fields were empty in the source and hold a default:
+	// 0 for numbers, the Kafka timestamp for event_time.
+	Defaulted []string `json:"defaulted,omitempty"`
+	// Unknown fields were explicitly reported as unknown ("UNK") and hold 0.
+	Unknown []string `json:"unknown,omitempty"`
+	// Inferred fields were derived from context rather than read directly,
+	// e.g. the unit from the event type, or the date of a bare HHMM event_time
+	// from the Kafka timestamp.
+	Inferred []string `json:"inferred,omitempty"`
+	// Corrected fields were parsed but rewritten to fix a known encoding
+	// issue, e.g. hail sizes reported in hundredths of an inch.
+	Corrected []string `json:"corrected,omitempty"`
+	// Unparseable fields held a value that could not be parsed and were
+	// replaced with their default.
+	Unparseable []string `json:"unparseable,omitempty"`
+}
+
 // StormEvent is the domain-rich representation after parsing and enrichment.
 //
 // All fields are grouped into nested structs when they represent cohesive domain
@@ -83,7 +144,23 @@ type StormEvent struct {
 	Comments     string      `json:"comments,omitempty"`
 	SourceOffice string      `json:"source_office,omitempty"`
 	TimeBucket   time.Time   `json:"time_bucket,omitempty"`
+	Quality      Quality     `json:"quality"`
+
+	// SeverityRulesVersion identifies the rule set that produced Measurement.Severity.
+	SeverityRulesVersion string `json:"severity_rules_version,omitempty"`
+
+	// Duplicate is set when the pipeline's dedup stage runs in tag mode and
+	// this ID was already loaded within the dedup window.
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Revision counts content changes to an already loaded ID: 0 for the first
+	// version, incremented each time the same ID arrives with a different
+	// ContentHash. Set by the pipeline's dedup stage.
+	Revision int `json:"revision,omitempty"`
 
 	RawPayload  []byte    `json:"-"`
 	ProcessedAt time.Time `json:"processed_at"`
+
+	// TraceContext is the span that processed this event, propagated to the
+	// sink so consumers can continue the trace.
+	TraceContext trace.SpanContext `json:"-"`
 }
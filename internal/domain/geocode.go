@@ -0,0 +1,63 @@
+package domain
+
+import "strings"
+
+// GeocodeResult is the administrative geography containing a point.
+type GeocodeResult struct {
+	CountyFIPS   string // 5-digit state+county FIPS, e.g. "48411"
+	CountyName   string // canonical county name, e.g. "San Saba"
+	StateFIPS    string // 2-digit state FIPS, e.g. "48"
+	State        string // USPS abbreviation, e.g. "TX"
+	ForecastZone string // NWS public forecast zone, e.g. "TXZ155"
+}
+
+// Geocoder resolves coordinates to the county and forecast zone containing them.
+type Geocoder interface {
+	ReverseGeocode(lat, lon float64) (GeocodeResult, bool)
+}
+
+// enrichGeocode assigns geocoded county and zone fields and flags reports
+// whose collector-supplied county or state disagrees with the coordinates.
+// Events without coordinates, or outside every boundary, are left unchanged.
+func enrichGeocode(g Geocoder) func(*StormEvent) {
+	return func(e *StormEvent) {
+		if e.Geo.Lat == 0 && e.Geo.Lon == 0 {
+			return
+		}
+		res, ok := g.ReverseGeocode(e.Geo.Lat, e.Geo.Lon)
+		if !ok {
+			return
+		}
+
+		loc := &e.Location
+		loc.CountyFIPS = res.CountyFIPS
+		loc.CanonicalCounty = res.CountyName
+		loc.StateFIPS = res.StateFIPS
+		loc.ForecastZone = res.ForecastZone
+		loc.CountyMismatch = res.CountyName != "" && loc.County != "" &&
+			(countyKey(loc.County) != countyKey(res.CountyName) ||
+				(loc.State != "" && res.State != "" && !strings.EqualFold(loc.State, res.State)))
+	}
+}
+
+// countySuffixes are the legal/statistical area descriptors that reports
+// sometimes include and boundary files omit.
+var countySuffixes = []string{" county", " parish", " borough", " census area", " municipality", " municipio"}
+
+// countyKey normalizes a county name for comparison: case-insensitive,
+// "Saint" and "St." equivalent, punctuation, spacing, and area suffixes ignored.
+// "St. Louis", "Saint Louis", and "ST LOUIS COUNTY" share a key.
+func countyKey(name string) string {
+	s := strings.ToLower(strings.TrimSpace(name))
+	for _, suffix := range countySuffixes {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	s = strings.ReplaceAll(s, "saint ", "st ")
+	s = strings.ReplaceAll(s, "sainte ", "ste ")
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
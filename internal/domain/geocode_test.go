@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubGeocoder struct {
+	result GeocodeResult
+	ok     bool
+}
+
+func (s stubGeocoder) ReverseGeocode(float64, float64) (GeocodeResult, bool) {
+	return s.result, s.ok
+}
+
+func TestCountyKey(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"St. Louis", "Saint Louis", true},
+		{"ST LOUIS COUNTY", "St. Louis", true},
+		{"De Kalb", "DeKalb", true},
+		{"Ste. Genevieve", "Sainte Genevieve", true},
+		{"Orleans Parish", "Orleans", true},
+		{"Matanuska-Susitna Borough", "Matanuska-Susitna", true},
+		{"San Saba", "Saba", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.equal, countyKey(tt.a) == countyKey(tt.b))
+		})
+	}
+}
+
+func TestEnrichGeocode(t *testing.T) {
+	geo := stubGeocoder{
+		result: GeocodeResult{CountyFIPS: "29189", CountyName: "St. Louis", StateFIPS: "29", State: "MO", ForecastZone: "MOZ063"},
+		ok:     true,
+	}
+	chain, err := Enrichers([]string{"geocode"}, EnricherDeps{Geocoder: geo})
+	require.NoError(t, err)
+	geocode := chain[0]
+
+	t.Run("assigns geocoded fields", func(t *testing.T) {
+		event := StormEvent{Geo: Geo{Lat: 38.6, Lon: -90.3}, Location: Location{County: "Saint Louis", State: "MO"}}
+		require.NoError(t, geocode.Enrich(t.Context(), &event))
+		assert.Equal(t, "29189", event.Location.CountyFIPS)
+		assert.Equal(t, "St. Louis", event.Location.CanonicalCounty)
+		assert.Equal(t, "29", event.Location.StateFIPS)
+		assert.Equal(t, "MOZ063", event.Location.ForecastZone)
+		assert.False(t, event.Location.CountyMismatch)
+	})
+
+	t.Run("flags county mismatch", func(t *testing.T) {
+		event := StormEvent{Geo: Geo{Lat: 38.6, Lon: -90.3}, Location: Location{County: "Jefferson", State: "MO"}}
+		require.NoError(t, geocode.Enrich(t.Context(), &event))
+		assert.True(t, event.Location.CountyMismatch)
+	})
+
+	t.Run("flags state mismatch", func(t *testing.T) {
+		event := StormEvent{Geo: Geo{Lat: 38.6, Lon: -90.3}, Location: Location{County: "St. Louis", State: "IL"}}
+		require.NoError(t, geocode.Enrich(t.Context(), &event))
+		assert.True(t, event.Location.CountyMismatch)
+	})
+
+	t.Run("skips events without coordinates", func(t *testing.T) {
+		event := StormEvent{Location: Location{County: "Jefferson"}}
+		require.NoError(t, geocode.Enrich(t.Context(), &event))
+		assert.Empty(t, event.Location.CountyFIPS)
+		assert.False(t, event.Location.CountyMismatch)
+	})
+
+	t.Run("unresolved point leaves location unchanged", func(t *testing.T) {
+		chain, err := Enrichers([]string{"geocode"}, EnricherDeps{Geocoder: stubGeocoder{}})
+		require.NoError(t, err)
+		event := StormEvent{Geo: Geo{Lat: 25.0, Lon: -89.0}, Location: Location{County: "Offshore"}}
+		require.NoError(t, chain[0].Enrich(t.Context(), &event))
+		assert.Equal(t, Location{County: "Offshore"}, event.Location)
+	})
+}
+
+func TestEnrichers_OptionalStepRequiresDependency(t *testing.T) {
+	require.NoError(t, CheckEnricherNames([]string{"geocode"}))
+
+	_, err := Enrichers([]string{"event_type", "geocode"}, EnricherDeps{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"geocode" is not configured`)
+
+	assert.Equal(t, []string{"geocode", "place"}, OptionalEnricherNames())
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"math"
+)
+
+// Gazetteer resolves a populated place to its coordinates.
+type Gazetteer interface {
+	LookupPlace(name, state string) (lat, lon float64, ok bool)
+}
+
+const (
+	earthRadiusKm = 6371.0088
+	kmPerMile     = 1.609344
+
+	// geoSuspectKm is the discrepancy above which reported coordinates are
+	// checked for transposition or sign errors.
+	geoSuspectKm = 50.0
+	// geoRepairMatchKm is how close a corrected candidate must land to the
+	// projected point to be identified as the error.
+	geoRepairMatchKm = 25.0
+)
+
+// Geo issues detected by comparing reported coordinates with the point
+// projected from the NWS relative location.
+const (
+	GeoIssueTransposed      = "transposed"
+	GeoIssueLatSignFlipped  = "lat_sign_flipped"
+	GeoIssueLonSignFlipped  = "lon_sign_flipped"
+	GeoIssueTransposedFlips = "transposed_sign_flipped"
+)
+
+// compassBearing maps the 16-point compass used in NWS locations to degrees.
+var compassBearing = map[string]float64{
+	"N": 0, "NNE": 22.5, "NE": 45, "ENE": 67.5,
+	"E": 90, "ESE": 112.5, "SE": 135, "SSE": 157.5,
+	"S": 180, "SSW": 202.5, "SW": 225, "WSW": 247.5,
+	"W": 270, "WNW": 292.5, "NW": 315, "NNW": 337.5,
+}
+
+// enrichPlace projects the NWS relative location ("8 ESE Chappel") from the
+// reference place's gazetteer coordinates and compares it with Geo. When Geo
+// is far off, transposed and sign-flipped variants are tried to name the
+// likely collector error. With repair set, events without coordinates get
+// the projected point, recorded as inferred in Quality.
+func enrichPlace(g Gazetteer, repair bool) func(*StormEvent) {
+	return func(e *StormEvent) {
+		loc := &e.Location
+		if loc.Name == "" {
+			return
+		}
+		lat, lon, ok := g.LookupPlace(loc.Name, loc.State)
+		if !ok {
+			return
+		}
+
+		if loc.Distance != nil && loc.Direction != nil {
+			bearing, known := compassBearing[*loc.Direction]
+			if !known {
+				return
+			}
+			lat, lon = destination(lat, lon, bearing, *loc.Distance*kmPerMile)
+		}
+		loc.ProjectedGeo = &Geo{Lat: roundTo(lat, 4), Lon: roundTo(lon, 4)}
+
+		if e.Geo.Lat == 0 && e.Geo.Lon == 0 {
+			if repair {
+				e.Geo = *loc.ProjectedGeo
+				e.Quality.reclassify("geo.lat", fieldInferred)
+				e.Quality.reclassify("geo.lon", fieldInferred)
+			}
+			return
+		}
+
+		d := roundTo(haversineKm(e.Geo.Lat, e.Geo.Lon, lat, lon), 1)
+		loc.GeoDiscrepancyKm = &d
+		if d > geoSuspectKm {
+			loc.GeoIssue = diagnoseGeo(e.Geo, lat, lon)
+		}
+	}
+}
+
+// diagnoseGeo returns the geo issue whose correction lands reported closest
+// to the projected point, or "" if none lands within geoRepairMatchKm.
+func diagnoseGeo(reported Geo, lat, lon float64) string {
+	candidates := []struct {
+		issue    string
+		lat, lon float64
+	}{
+		{GeoIssueLonSignFlipped, reported.Lat, -reported.Lon},
+		{GeoIssueLatSignFlipped, -reported.Lat, reported.Lon},
+		{GeoIssueTransposed, reported.Lon, reported.Lat},
+		{GeoIssueTransposedFlips, -reported.Lon, -reported.Lat},
+		{GeoIssueTransposedFlips, reported.Lon, -reported.Lat},
+		{GeoIssueTransposedFlips, -reported.Lon, reported.Lat},
+	}
+
+	best, bestKm := "", geoRepairMatchKm
+	for _, c := range candidates {
+		if km := haversineKm(c.lat, c.lon, lat, lon); km < bestKm {
+			best, bestKm = c.issue, km
+		}
+	}
+	return best
+}
+
+// destination returns the point reached by travelling distKm from (lat, lon)
+// on the given initial bearing along a great circle.
+func destination(lat, lon, bearingDeg, distKm float64) (float64, float64) {
+	phi1, lambda1 := radians(lat), radians(lon)
+	theta := radians(bearingDeg)
+	delta := distKm / earthRadiusKm
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi1), math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2))
+
+	return degrees(phi2), math.Mod(degrees(lambda2)+540, 360) - 180
+}
+
+// haversineKm is the great-circle distance between two points.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dPhi, dLambda := radians(lat2-lat1), radians(lon2-lon1)
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
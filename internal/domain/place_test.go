@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubGazetteer map[string][2]float64
+
+func (s stubGazetteer) LookupPlace(name, state string) (float64, float64, bool) {
+	p, ok := s[name+","+state]
+	return p[0], p[1], ok
+}
+
+func TestDestination(t *testing.T) {
+	// One degree of latitude is ~111.2 km.
+	lat, lon := destination(35, -97, 0, 111.195)
+	assert.InDelta(t, 36.0, lat, 0.001)
+	assert.InDelta(t, -97.0, lon, 0.001)
+
+	lat, lon = destination(35, -97, 90, 10)
+	assert.InDelta(t, 35.0, lat, 0.01)
+	assert.Greater(t, lon, -97.0)
+	assert.InDelta(t, 10.0, haversineKm(35, -97, lat, lon), 0.001)
+
+	_, lon = destination(52, 179.9, 90, 50)
+	assert.Less(t, lon, -179.0, "wraps across the antimeridian")
+}
+
+func TestEnrichPlace(t *testing.T) {
+	gaz := stubGazetteer{"Chappel,TX": {31.07, -98.57}}
+	placeStep := func(t *testing.T, repair bool) Enricher {
+		t.Helper()
+		chain, err := Enrichers([]string{"location", "place"}, EnricherDeps{Gazetteer: gaz, RepairGeo: repair})
+		require.NoError(t, err)
+		return enricherFunc{"chain", func(e *StormEvent) {
+			for _, step := range chain {
+				require.NoError(t, step.Enrich(t.Context(), e))
+			}
+		}}
+	}
+	newEvent := func(lat, lon float64) StormEvent {
+		return StormEvent{Geo: Geo{Lat: lat, Lon: lon}, Location: Location{Raw: "8 ESE Chappel", State: "TX"}}
+	}
+
+	t.Run("projects relative location and measures discrepancy", func(t *testing.T) {
+		event := newEvent(31.02, -98.44)
+		require.NoError(t, placeStep(t, false).Enrich(t.Context(), &event))
+
+		require.NotNil(t, event.Location.ProjectedGeo)
+		assert.Less(t, event.Location.ProjectedGeo.Lat, 31.07, "ESE is south of east")
+		assert.Greater(t, event.Location.ProjectedGeo.Lon, -98.57)
+		assert.InDelta(t, 12.87, haversineKm(31.07, -98.57, event.Location.ProjectedGeo.Lat, event.Location.ProjectedGeo.Lon), 0.05)
+		require.NotNil(t, event.Location.GeoDiscrepancyKm)
+		assert.Less(t, *event.Location.GeoDiscrepancyKm, 5.0)
+		assert.Empty(t, event.Location.GeoIssue)
+	})
+
+	issues := []struct {
+		name     string
+		lat, lon float64
+		issue    string
+	}{
+		{"sign-flipped longitude", 31.02, 98.44, GeoIssueLonSignFlipped},
+		{"transposed", -98.44, 31.02, GeoIssueTransposed},
+		{"transposed and flipped", 98.44, -31.02, GeoIssueTransposedFlips},
+		{"wrong but not a known error", 35.0, -90.0, ""},
+	}
+	for _, tt := range issues {
+		t.Run(tt.name, func(t *testing.T) {
+			event := newEvent(tt.lat, tt.lon)
+			require.NoError(t, placeStep(t, false).Enrich(t.Context(), &event))
+			require.NotNil(t, event.Location.GeoDiscrepancyKm)
+			assert.Greater(t, *event.Location.GeoDiscrepancyKm, geoSuspectKm)
+			assert.Equal(t, tt.issue, event.Location.GeoIssue)
+			assert.Equal(t, Geo{Lat: tt.lat, Lon: tt.lon}, event.Geo, "reported coordinates are never rewritten")
+		})
+	}
+
+	t.Run("repairs missing coordinates when enabled", func(t *testing.T) {
+		event := newEvent(0, 0)
+		event.EventType = "hail"
+		event.EventTime = time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
+		event.Quality = Quality{Defaulted: []string{"geo.lat"}, Unparseable: []string{"geo.lon"}}
+		require.NoError(t, placeStep(t, true).Enrich(t.Context(), &event))
+
+		assert.Equal(t, *event.Location.ProjectedGeo, event.Geo)
+		assert.Nil(t, event.Location.GeoDiscrepancyKm)
+		assert.Equal(t, Quality{Inferred: []string{"geo.lat", "geo.lon"}}, event.Quality)
+		assert.Empty(t, Validate(event))
+	})
+
+	t.Run("leaves missing coordinates when repair disabled", func(t *testing.T) {
+		event := newEvent(0, 0)
+		require.NoError(t, placeStep(t, false).Enrich(t.Context(), &event))
+		assert.Equal(t, Geo{}, event.Geo)
+		assert.NotNil(t, event.Location.ProjectedGeo)
+	})
+
+	t.Run("place at the reference point", func(t *testing.T) {
+		event := StormEvent{Geo: Geo{Lat: 31.07, Lon: -98.57}, Location: Location{Raw: "Chappel", State: "TX"}}
+		require.NoError(t, placeStep(t, false).Enrich(t.Context(), &event))
+		assert.Equal(t, &Geo{Lat: 31.07, Lon: -98.57}, event.Location.ProjectedGeo)
+		assert.Equal(t, float64Ptr(0), event.Location.GeoDiscrepancyKm)
+	})
+
+	t.Run("unknown place", func(t *testing.T) {
+		event := StormEvent{Geo: Geo{Lat: 34.94, Lon: -95.59}, Location: Location{Raw: "4 N Dow", State: "OK"}}
+		require.NoError(t, placeStep(t, true).Enrich(t.Context(), &event))
+		assert.Nil(t, event.Location.ProjectedGeo)
+		assert.Nil(t, event.Location.GeoDiscrepancyKm)
+	})
+}
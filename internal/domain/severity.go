@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed severity_rules.yaml
+var defaultSeverityRulesYAML []byte
+
+// defaultSeverityRules is the embedded rule set used when no rules file is configured.
+var defaultSeverityRules = mustParseSeverityRules(defaultSeverityRulesYAML)
+
+// SeverityTier is one step of a severity scale. Magnitudes strictly below
+// Below get Label. A nil Below matches any magnitude and is only allowed on
+// the last tier.
+type SeverityTier struct {
+	Below *float64 `yaml:"below" json:"below,omitempty"`
+	Label string   `yaml:"label" json:"label"`
+}
+
+// SeverityRules maps event types to ordered severity tiers. Version is
+// stamped on every event classified with these rules.
+type SeverityRules struct {
+	Version string                    `yaml:"version" json:"version"`
+	Rules   map[string][]SeverityTier `yaml:"rules" json:"rules"`
+}
+
+// DefaultSeverityRules returns the embedded NWS-based rule set.
+func DefaultSeverityRules() *SeverityRules {
+	return defaultSeverityRules
+}
+
+// LoadSeverityRules reads and validates a YAML or JSON rules file.
+func LoadSeverityRules(path string) (*SeverityRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read severity rules: %w", err)
+	}
+	return ParseSeverityRules(data)
+}
+
+// ParseSeverityRules decodes and validates a YAML or JSON rule set. Unknown
+// keys are rejected so typos don't silently fall back to defaults.
+func ParseSeverityRules(data []byte) (*SeverityRules, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var r SeverityRules
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("parse severity rules: %w", err)
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func mustParseSeverityRules(data []byte) *SeverityRules {
+	r, err := ParseSeverityRules(data)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Validate checks that the rule set has a version, covers only known event
+// types, and that each scale is non-empty with labelled, strictly increasing
+// thresholds.
+func (r *SeverityRules) Validate() error {
+	if r.Version == "" {
+		return errors.New("severity rules: version is required")
+	}
+	if len(r.Rules) == 0 {
+		return errors.New("severity rules: no rules defined")
+	}
+	for eventType, tiers := range r.Rules {
+		if normalizeEventType(eventType) == "" {
+			return fmt.Errorf("severity rules: unknown event type %q", eventType)
+		}
+		if len(tiers) == 0 {
+			return fmt.Errorf("severity rules: %s has no tiers", eventType)
+		}
+		for i, tier := range tiers {
+			if tier.Label == "" {
+				return fmt.Errorf("severity rules: %s tier %d has no label", eventType, i)
+			}
+			if tier.Below == nil {
+				if i != len(tiers)-1 {
+					return fmt.Errorf("severity rules: %s tier %d has no threshold but is not the last tier", eventType, i)
+				}
+				continue
+			}
+			if i > 0 && *tier.Below <= *tiers[i-1].Below {
+				return fmt.Errorf("severity rules: %s thresholds must be strictly increasing (tier %d)", eventType, i)
+			}
+		}
+	}
+	return nil
+}
+
+// Classify maps a magnitude to a severity label. Returns nil when magnitude
+// is 0 (unmeasured), the event type has no rules, or the magnitude is above
+// every bounded tier and there is no catch-all.
+func (r *SeverityRules) Classify(eventType string, magnitude float64) *string {
+	if magnitude == 0 {
+		return nil
+	}
+	for _, tier := range r.Rules[eventType] {
+		if tier.Below == nil || magnitude < *tier.Below {
+			label := tier.Label
+			return &label
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultSeverityRules(t *testing.T) {
+	rules := DefaultSeverityRules()
+	assert.Equal(t, "nws-default-1", rules.Version)
+	assert.ElementsMatch(t, []string{"hail", "wind", "tornado"}, keys(rules.Rules))
+}
+
+func keys(m map[string][]SeverityTier) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func TestParseSeverityRules(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		rules, err := ParseSeverityRules([]byte(`{"version":"cust-a","rules":{"hail":[{"below":1,"label":"small"},{"label":"large"}]}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "cust-a", rules.Version)
+		assert.Equal(t, stringPtr("small"), rules.Classify("hail", 0.5))
+		assert.Equal(t, stringPtr("large"), rules.Classify("hail", 4))
+		assert.Nil(t, rules.Classify("wind", 80), "event types without rules get no severity")
+	})
+
+	t.Run("no catch-all tier", func(t *testing.T) {
+		rules, err := ParseSeverityRules([]byte("version: v\nrules:\n  wind:\n    - {below: 58, label: sub-severe}\n"))
+		require.NoError(t, err)
+		assert.Equal(t, stringPtr("sub-severe"), rules.Classify("wind", 40))
+		assert.Nil(t, rules.Classify("wind", 70))
+	})
+
+	invalid := []struct {
+		name string
+		yaml string
+		msg  string
+	}{
+		{"missing version", "rules:\n  hail:\n    - {label: any}\n", "version is required"},
+		{"no rules", "version: v\n", "no rules defined"},
+		{"unknown event type", "version: v\nrules:\n  snow:\n    - {label: any}\n", `unknown event type "snow"`},
+		{"empty tiers", "version: v\nrules:\n  hail: []\n", "hail has no tiers"},
+		{"missing label", "version: v\nrules:\n  hail:\n    - {below: 1}\n", "has no label"},
+		{"catch-all not last", "version: v\nrules:\n  hail:\n    - {label: a}\n    - {below: 1, label: b}\n", "not the last tier"},
+		{"decreasing thresholds", "version: v\nrules:\n  hail:\n    - {below: 2, label: a}\n    - {below: 1, label: b}\n", "strictly increasing"},
+		{"unknown key", "version: v\nrule:\n  hail: []\n", "parse severity rules"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSeverityRules([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
+
+func TestLoadSeverityRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("version: strict-hail\nrules:\n  hail:\n    - {below: 1, label: minor}\n    - {label: severe}\n"), 0o600))
+
+	rules, err := LoadSeverityRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, "strict-hail", rules.Version)
+
+	_, err = LoadSeverityRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestSeverityEnricher_UsesConfiguredRules(t *testing.T) {
+	rules, err := ParseSeverityRules([]byte("version: two-tier\nrules:\n  hail:\n    - {below: 2, label: low}\n    - {label: high}\n"))
+	require.NoError(t, err)
+
+	chain, err := Enrichers([]string{"severity"}, EnricherDeps{SeverityRules: rules})
+	require.NoError(t, err)
+
+	event := StormEvent{EventType: "hail", Measurement: Measurement{Magnitude: 1.75}}
+	require.NoError(t, chain[0].Enrich(t.Context(), &event))
+	assert.Equal(t, stringPtr("low"), event.Measurement.Severity)
+	assert.Equal(t, "two-tier", event.SeverityRulesVersion)
+
+	event = EnrichStormEvent(StormEvent{EventType: "hail", Measurement: Measurement{Magnitude: 1.75}})
+	assert.Equal(t, stringPtr("severe"), event.Measurement.Severity)
+	assert.Equal(t, "nws-default-1", event.SeverityRulesVersion)
+}
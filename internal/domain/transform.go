@@ -1,11 +1,13 @@
 package domain
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,16 +25,23 @@ var (
 
 // ParseRawEvent deserializes a RawEvent's value into a StormEvent.
 // It expects the flat CSV-style JSON produced by the collector service.
+// Empty, unknown, and unparseable coordinates, magnitudes, and times fall back
+// to their defaults and are recorded in the event's Quality.
 func ParseRawEvent(raw RawEvent) (StormEvent, error) {
 	var rec RawCSVRecord
 	if err := json.Unmarshal(raw.Value, &rec); err != nil {
 		return StormEvent{}, fmt.Errorf("parse raw event: %w", err)
 	}
 
-	lat := parseFloatOrZero(rec.Lat)
-	lon := parseFloatOrZero(rec.Lon)
-	magnitude := parseMagnitudeField(rec.EventType, rec.Size, rec.FScale, rec.Speed)
-	eventTime := parseEventTime(raw.Timestamp, rec.Time)
+	var q Quality
+	lat, st := parseFloatField(rec.Lat)
+	q.record("geo.lat", st)
+	lon, st := parseFloatField(rec.Lon)
+	q.record("geo.lon", st)
+	magnitude, st := parseMagnitudeField(rec.EventType, rec.Size, rec.FScale, rec.Speed)
+	q.record("measurement.magnitude", st)
+	eventTime, st := parseEventTime(raw.Timestamp, rec.Time)
+	q.record("event_time", st)
 
 	return StormEvent{
 		ID:          generateID(rec.EventType, rec.State, lat, lon, rec.Time, magnitude),
@@ -42,27 +51,76 @@ func ParseRawEvent(raw RawEvent) (StormEvent, error) {
 		EventTime:   eventTime,
 		Location:    Location{Raw: rec.Location, State: rec.State, County: rec.County},
 		Comments:    rec.Comments,
+		Quality:     q,
 
 		RawPayload: raw.Value,
 	}, nil
 }
 
-// parseFloatOrZero parses a string as float64, returning 0 on failure.
-func parseFloatOrZero(s string) float64 {
+// fieldStatus describes how a parsed field's value was obtained.
+type fieldStatus int
+
+const (
+	fieldParsed fieldStatus = iota
+	fieldEmpty
+	fieldUnknown
+	fieldInferred
+	fieldCorrected
+	fieldUnparseable
+)
+
+// record adds field to the Quality list matching st. Parsed fields are not
+// recorded, and recording the same field twice is a no-op.
+func (q *Quality) record(field string, st fieldStatus) {
+	var list *[]string
+	switch st {
+	case fieldEmpty:
+		list = &q.Defaulted
+	case fieldUnknown:
+		list = &q.Unknown
+	case fieldInferred:
+		list = &q.Inferred
+	case fieldCorrected:
+		list = &q.Corrected
+	case fieldUnparseable:
+		list = &q.Unparseable
+	default:
+		return
+	}
+	if !slices.Contains(*list, field) {
+		*list = append(*list, field)
+	}
+}
+
+// reclassify moves field out of every Quality list and records it under st.
+func (q *Quality) reclassify(field string, st fieldStatus) {
+	for _, list := range []*[]string{&q.Defaulted, &q.Unknown, &q.Inferred, &q.Corrected, &q.Unparseable} {
+		*list = slices.DeleteFunc(*list, func(f string) bool { return f == field })
+		if len(*list) == 0 {
+			*list = nil
+		}
+	}
+	q.record(field, st)
+}
+
+// parseFloatField parses a string as float64, returning 0 for empty or
+// unparseable input.
+func parseFloatField(s string) (float64, fieldStatus) {
 	s = strings.TrimSpace(s)
 	if s == "" {
-		return 0
+		return 0, fieldEmpty
 	}
 	v, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		return 0
+		return 0, fieldUnparseable
 	}
-	return v
+	return v, fieldParsed
 }
 
 // parseMagnitudeField selects and parses the correct magnitude column based on event type.
-// Returns 0 for unknown values like "UNK".
-func parseMagnitudeField(eventType, size, fScale, speed string) float64 {
+// Returns 0 for empty columns, unknown values like "UNK", unparseable values,
+// and unrecognized event types (which have no magnitude column).
+func parseMagnitudeField(eventType, size, fScale, speed string) (float64, fieldStatus) {
 	var raw string
 	switch eventType {
 	case "hail":
@@ -72,28 +130,32 @@ func parseMagnitudeField(eventType, size, fScale, speed string) float64 {
 	case "wind":
 		raw = speed
 	default:
-		return 0
+		return 0, fieldEmpty
 	}
 
 	raw = strings.TrimSpace(raw)
-	if raw == "" || strings.EqualFold(raw, "UNK") {
-		return 0
+	if raw == "" {
+		return 0, fieldEmpty
+	}
+	if strings.EqualFold(raw, "UNK") {
+		return 0, fieldUnknown
 	}
 	raw = strings.TrimPrefix(raw, "EF")
 	raw = strings.TrimPrefix(raw, "F")
 
 	v, err := strconv.ParseFloat(raw, 64)
 	if err != nil {
-		return 0
+		return 0, fieldUnparseable
 	}
-	return v
+	return v, fieldParsed
 }
 
 // parseHHMM combines a base date with an HHMM time string (e.g. "1510" → 15:10).
-func parseHHMM(baseDate time.Time, hhmm string) time.Time {
+// Invalid input returns the base date and false.
+func parseHHMM(baseDate time.Time, hhmm string) (time.Time, bool) {
 	hhmm = strings.TrimSpace(hhmm)
-	if len(hhmm) < 3 {
-		return baseDate
+	if len(hhmm) < 3 || len(hhmm) > 4 {
+		return baseDate, false
 	}
 	if len(hhmm) == 3 {
 		hhmm = "0" + hhmm
@@ -102,30 +164,34 @@ func parseHHMM(baseDate time.Time, hhmm string) time.Time {
 	hour, errH := strconv.Atoi(hhmm[:2])
 	mins, errM := strconv.Atoi(hhmm[2:])
 	if errH != nil || errM != nil || hour < 0 || hour > 23 || mins < 0 || mins > 59 {
-		return baseDate
+		return baseDate, false
 	}
 
 	return time.Date(
 		baseDate.Year(), baseDate.Month(), baseDate.Day(),
 		hour, mins, 0, 0, time.UTC,
-	)
+	), true
 }
 
 // parseEventTime parses the Time field from the collector payload.
 // New-format payloads contain a full RFC 3339 timestamp (e.g. "2024-04-26T15:10:00Z")
 // set by the collector's expandHHMMToISO. Legacy payloads contain bare HHMM (e.g. "1510")
 // which is combined with the Kafka message timestamp as the base date.
-func parseEventTime(kafkaTimestamp time.Time, timeStr string) time.Time {
+// Empty and unparseable fields fall back to the Kafka timestamp.
+func parseEventTime(kafkaTimestamp time.Time, timeStr string) (time.Time, fieldStatus) {
 	timeStr = strings.TrimSpace(timeStr)
 	if timeStr == "" {
-		return kafkaTimestamp
+		return kafkaTimestamp, fieldEmpty
 	}
 
 	if t, err := time.Parse(time.RFC3339, timeStr); err == nil {
-		return t
+		return t, fieldParsed
 	}
 
-	return parseHHMM(kafkaTimestamp, timeStr)
+	if t, ok := parseHHMM(kafkaTimestamp, timeStr); ok {
+		return t, fieldInferred
+	}
+	return kafkaTimestamp, fieldUnparseable
 }
 
 // generateID produces a deterministic ID from the event's key fields.
@@ -141,22 +207,48 @@ func generateID(eventType, state string, lat, lon float64, timeStr string, magni
 	return eventType + "-" + short
 }
 
-// EnrichStormEvent normalizes, classifies, and enriches a parsed storm event.
+// ContentHash returns a SHA-256 hex digest of the event's serialized content.
+// Unlike the ID, which covers only the identifying fields, it changes when any
+// output field changes, such as comments or location. Fields that can differ
+// between deliveries of the same payload are excluded: ProcessedAt, Duplicate,
+// Revision, SeverityRulesVersion, and whatever EventTime and TimeBucket take
+// from the message timestamp. Of an inferred event_time only the clock time
+// came from the payload, and a defaulted or unparseable one none of it.
+func ContentHash(event StormEvent) (string, error) {
+	event.ProcessedAt = time.Time{}
+	event.Duplicate = false
+	event.Revision = 0
+	event.SeverityRulesVersion = ""
+	event.TimeBucket = time.Time{}
+	switch {
+	case slices.Contains(event.Quality.Inferred, "event_time"):
+		h, m, s := event.EventTime.Clock()
+		event.EventTime = time.Date(0, time.January, 1, h, m, s, 0, time.UTC)
+	case slices.Contains(event.Quality.Defaulted, "event_time"),
+		slices.Contains(event.Quality.Unparseable, "event_time"):
+		event.EventTime = time.Time{}
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("hash storm event: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// EnrichStormEvent normalizes, classifies, and enriches a parsed storm event
+// by running the default enrichment chain (see [DefaultEnrichers]).
 // It validates the event type, infers default units, corrects magnitude encoding
 // issues, derives a severity label, extracts the NWS source office from comments,
 // parses structured location fields, and assigns an hourly time bucket.
+//
+// Unrecognized event types, inferred units, and corrected magnitudes are
+// recorded in the event's Quality.
 func EnrichStormEvent(event StormEvent) StormEvent {
-	event.EventType = normalizeEventType(event.EventType)
-	event.Measurement.Unit = normalizeUnit(event.EventType, event.Measurement.Unit)
-	event.Measurement.Magnitude = normalizeMagnitude(event.EventType, event.Measurement.Magnitude, event.Measurement.Unit)
-	event.Measurement.Severity = deriveSeverity(event.EventType, event.Measurement.Magnitude)
-	event.SourceOffice = extractSourceOffice(event.Comments)
-	locationName, locationDistance, locationDirection := parseLocation(event.Location.Raw)
-	event.Location.Name = locationName
-	event.Location.Distance = locationDistance
-	event.Location.Direction = locationDirection
-	event.TimeBucket = deriveTimeBucket(event.EventTime)
-	event.ProcessedAt = clock.Now()
+	for _, e := range DefaultEnrichers() {
+		// Built-in enrichers never fail.
+		_ = e.Enrich(context.Background(), &event)
+	}
 	return event
 }
 
@@ -207,8 +299,9 @@ func normalizeMagnitude(eventType string, magnitude float64, unit string) float6
 	return magnitude
 }
 
-// deriveSeverity maps magnitude to a severity label based on operational thresholds
-// informed by NWS Severe Weather Criteria and the Enhanced Fujita Scale:
+// deriveSeverity maps magnitude to a severity label using the embedded
+// default rules (severity_rules.yaml), informed by NWS Severe Weather Criteria
+// and the Enhanced Fujita Scale:
 //   - hail: <0.75in minor, <1.5in moderate, <2.5in severe, else extreme
 //   - wind: <50mph minor, <74mph moderate (tropical storm threshold), <96mph severe (hurricane Cat 2), else extreme
 //   - tornado: EF0-1 minor, EF2 moderate, EF3-4 severe, EF5 extreme
@@ -216,49 +309,7 @@ func normalizeMagnitude(eventType string, magnitude float64, unit string) float6
 // The four-level scale is a project-specific simplification for user-facing queries.
 // Returns nil when magnitude is 0 or the event type is unrecognized.
 func deriveSeverity(eventType string, magnitude float64) *string {
-	if magnitude == 0 {
-		return nil
-	}
-
-	var s string
-	switch eventType {
-	case "hail":
-		switch {
-		case magnitude < 0.75:
-			s = "minor"
-		case magnitude < 1.5:
-			s = "moderate"
-		case magnitude < 2.5:
-			s = "severe"
-		default:
-			s = "extreme"
-		}
-	case "wind":
-		switch {
-		case magnitude < 50:
-			s = "minor"
-		case magnitude < 74:
-			s = "moderate"
-		case magnitude < 96:
-			s = "severe"
-		default:
-			s = "extreme"
-		}
-	case "tornado":
-		switch {
-		case magnitude <= 1:
-			s = "minor"
-		case magnitude == 2:
-			s = "moderate"
-		case magnitude <= 4:
-			s = "severe"
-		default:
-			s = "extreme"
-		}
-	default:
-		return nil
-	}
-	return &s
+	return defaultSeverityRules.Classify(eventType, magnitude)
 }
 
 // extractSourceOffice pulls the NWS Weather Forecast Office (WFO) code from the
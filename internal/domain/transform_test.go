@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"encoding/json"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -74,6 +76,7 @@ func TestParseRawEvent(t *testing.T) {
 
 		require.NoError(t, err)
 		assert.Equal(t, time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC), result.EventTime)
+		assert.Equal(t, Quality{}, result.Quality)
 	})
 
 	t.Run("UNK magnitude", func(t *testing.T) {
@@ -83,6 +86,21 @@ func TestParseRawEvent(t *testing.T) {
 
 		require.NoError(t, err)
 		assert.InDelta(t, 0.0, result.Measurement.Magnitude, 0.0001)
+		assert.Equal(t, []string{"measurement.magnitude"}, result.Quality.Unknown)
+		assert.Equal(t, []string{"event_time"}, result.Quality.Inferred)
+	})
+
+	t.Run("empty and unparseable fields", func(t *testing.T) {
+		data := []byte(`{"Time":"noon","Size":"","State":"TX","Lat":"N31","Lon":"","EventType":"hail"}`)
+		raw := RawEvent{Value: data, Timestamp: baseDate}
+		result, err := ParseRawEvent(raw)
+
+		require.NoError(t, err)
+		assert.Equal(t, baseDate, result.EventTime)
+		assert.Equal(t, Quality{
+			Defaulted:   []string{"geo.lon", "measurement.magnitude"},
+			Unparseable: []string{"geo.lat", "event_time"},
+		}, result.Quality)
 	})
 
 	t.Run("invalid JSON", func(t *testing.T) {
@@ -122,20 +140,22 @@ func TestParseHHMM(t *testing.T) {
 		name     string
 		hhmm     string
 		expected time.Time
+		ok       bool
 	}{
-		{"four digits", "1510", time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)},
-		{"three digits", "930", time.Date(2024, 4, 26, 9, 30, 0, 0, time.UTC)},
-		{"midnight", "0000", time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)},
-		{testEmptyStr, "", baseDate},
-		{"too short", "12", baseDate},
-		{"invalid hour", "2510", baseDate},
-		{"invalid minute", "1299", baseDate},
+		{"four digits", "1510", time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC), true},
+		{"three digits", "930", time.Date(2024, 4, 26, 9, 30, 0, 0, time.UTC), true},
+		{"midnight", "0000", time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC), true},
+		{testEmptyStr, "", baseDate, false},
+		{"too short", "12", baseDate, false},
+		{"invalid hour", "2510", baseDate, false},
+		{"invalid minute", "1299", baseDate, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseHHMM(baseDate, tt.hhmm)
+			result, ok := parseHHMM(baseDate, tt.hhmm)
 			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.ok, ok)
 		})
 	}
 }
@@ -147,18 +167,20 @@ func TestParseEventTime(t *testing.T) {
 		name     string
 		timeStr  string
 		expected time.Time
+		status   fieldStatus
 	}{
-		{"RFC 3339 timestamp", "2024-04-26T15:10:00Z", time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)},
-		{"HHMM fallback", "1510", time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)},
-		{"three digit HHMM fallback", "930", time.Date(2024, 4, 26, 9, 30, 0, 0, time.UTC)},
-		{testEmptyStr, "", baseDate},
-		{"invalid string", "not-a-time", baseDate},
+		{"RFC 3339 timestamp", "2024-04-26T15:10:00Z", time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC), fieldParsed},
+		{"HHMM fallback", "1510", time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC), fieldInferred},
+		{"three digit HHMM fallback", "930", time.Date(2024, 4, 26, 9, 30, 0, 0, time.UTC), fieldInferred},
+		{testEmptyStr, "", baseDate, fieldEmpty},
+		{"invalid string", "not-a-time", baseDate, fieldUnparseable},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseEventTime(baseDate, tt.timeStr)
+			result, status := parseEventTime(baseDate, tt.timeStr)
 			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.status, status)
 		})
 	}
 }
@@ -171,20 +193,23 @@ func TestParseMagnitudeField(t *testing.T) {
 		fScale   string
 		speed    string
 		expected float64
+		status   fieldStatus
 	}{
-		{"hail size", "hail", "125", "", "", 125},
-		{"tornado EF scale", "tornado", "", "EF2", "", 2},
-		{"tornado F prefix", "tornado", "", "F3", "", 3},
-		{"wind speed", "wind", "", "", "65", 65},
-		{"UNK magnitude", "wind", "", "", "UNK", 0},
-		{"empty magnitude", "hail", "", "", "", 0},
-		{testUnknown, "snow", "", "", "", 0},
+		{"hail size", "hail", "125", "", "", 125, fieldParsed},
+		{"tornado EF scale", "tornado", "", "EF2", "", 2, fieldParsed},
+		{"tornado F prefix", "tornado", "", "F3", "", 3, fieldParsed},
+		{"wind speed", "wind", "", "", "65", 65, fieldParsed},
+		{"UNK magnitude", "wind", "", "", "UNK", 0, fieldUnknown},
+		{"empty magnitude", "hail", "", "", "", 0, fieldEmpty},
+		{testUnknown, "snow", "", "", "", 0, fieldEmpty},
+		{"unparseable magnitude", "hail", "1.x5", "", "", 0, fieldUnparseable},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseMagnitudeField(tt.typ, tt.size, tt.fScale, tt.speed)
+			result, status := parseMagnitudeField(tt.typ, tt.size, tt.fScale, tt.speed)
 			assert.InDelta(t, tt.expected, result, 0.0001)
+			assert.Equal(t, tt.status, status)
 		})
 	}
 }
@@ -221,6 +246,77 @@ func TestGenerateID(t *testing.T) {
 	})
 }
 
+func TestContentHash(t *testing.T) {
+	base := StormEvent{
+		ID:          "hail-abc",
+		EventType:   "hail",
+		Comments:    "Quarter hail. (FWD)",
+		ProcessedAt: time.Date(2024, 4, 26, 12, 0, 0, 0, time.UTC),
+	}
+	h, err := ContentHash(base)
+	require.NoError(t, err)
+	assert.Len(t, h, 64)
+
+	t.Run("ignores delivery fields", func(t *testing.T) {
+		ev := base
+		ev.ProcessedAt = ev.ProcessedAt.Add(time.Hour)
+		ev.Duplicate = true
+		ev.Revision = 3
+		got, err := ContentHash(ev)
+		require.NoError(t, err)
+		assert.Equal(t, h, got)
+	})
+
+	t.Run("ignores the severity rules version", func(t *testing.T) {
+		ev := base
+		ev.SeverityRulesVersion = "2"
+		got, err := ContentHash(ev)
+		require.NoError(t, err)
+		assert.Equal(t, h, got)
+	})
+
+	t.Run("changes with non-key fields", func(t *testing.T) {
+		ev := base
+		ev.Comments = "Golf ball hail. (FWD)"
+		got, err := ContentHash(ev)
+		require.NoError(t, err)
+		assert.NotEqual(t, h, got)
+	})
+}
+
+func TestContentHash_RedeliveryWithNewTimestamp(t *testing.T) {
+	first := time.Date(2024, 4, 26, 23, 0, 0, 0, time.UTC)
+	later := time.Date(2024, 4, 28, 6, 0, 0, 0, time.UTC)
+	hashOf := func(t *testing.T, rec RawCSVRecord, ts time.Time) string {
+		t.Helper()
+		payload, err := json.Marshal(rec)
+		require.NoError(t, err)
+		ev, err := ParseRawEvent(RawEvent{Value: payload, Timestamp: ts})
+		require.NoError(t, err)
+		h, err := ContentHash(EnrichStormEvent(ev))
+		require.NoError(t, err)
+		return h
+	}
+
+	for _, timeStr := range []string{"1510", "", "afternoon", "2024-04-26T15:10:00Z"} {
+		t.Run("time "+strconv.Quote(timeStr), func(t *testing.T) {
+			rec := RawCSVRecord{
+				Time: timeStr, Size: "175", Location: testLocationNW, State: "TX",
+				Lat: "30.32", Lon: "-97.80", Comments: "Quarter hail. (EWX)", EventType: "hail",
+			}
+			assert.Equal(t, hashOf(t, rec, first), hashOf(t, rec, later),
+				"a redelivery of the same payload is not a revision")
+		})
+	}
+
+	t.Run("changed clock time is a revision", func(t *testing.T) {
+		rec := RawCSVRecord{Time: "1510", Size: "175", State: "TX", EventType: "hail"}
+		h := hashOf(t, rec, first)
+		rec.Time = "1520"
+		assert.NotEqual(t, h, hashOf(t, rec, first))
+	})
+}
+
 func TestEnrichStormEvent(t *testing.T) {
 	fixedTime := time.Date(2024, 4, 26, 12, 30, 45, 0, time.UTC)
 	mockClock := clockwork.NewFakeClockAt(fixedTime)
@@ -285,6 +381,35 @@ func TestEnrichStormEvent(t *testing.T) {
 	})
 }
 
+func TestEnrichStormEvent_Quality(t *testing.T) {
+	t.Run("inferred unit and corrected hail size", func(t *testing.T) {
+		result := EnrichStormEvent(StormEvent{EventType: "hail", Measurement: Measurement{Magnitude: 175}})
+		assert.Equal(t, []string{"measurement.unit"}, result.Quality.Inferred)
+		assert.Equal(t, []string{"measurement.magnitude"}, result.Quality.Corrected)
+	})
+
+	t.Run("explicit unit and normal magnitude", func(t *testing.T) {
+		result := EnrichStormEvent(StormEvent{EventType: "wind", Measurement: Measurement{Magnitude: 65, Unit: "mph"}})
+		assert.Equal(t, Quality{}, result.Quality)
+	})
+
+	t.Run("unrecognized event type", func(t *testing.T) {
+		result := EnrichStormEvent(StormEvent{EventType: "snow"})
+		assert.Equal(t, []string{"event_type"}, result.Quality.Unparseable)
+	})
+
+	t.Run("missing event type", func(t *testing.T) {
+		result := EnrichStormEvent(StormEvent{})
+		assert.Equal(t, []string{"event_type"}, result.Quality.Defaulted)
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		once := EnrichStormEvent(StormEvent{EventType: "hail", Measurement: Measurement{Magnitude: 1.75}})
+		twice := EnrichStormEvent(once)
+		assert.Equal(t, once.Quality, twice.Quality)
+	})
+}
+
 func TestNormalizeEventType(t *testing.T) {
 	tests := []struct {
 		name     string
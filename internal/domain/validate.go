@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ValidationError describes why a single field of a StormEvent is invalid.
+// Field uses the JSON path of the output message, e.g. "geo.lat".
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// ValidationErrors is the error form of a non-empty Validate result.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, ve := range e {
+		parts[i] = ve.Error()
+	}
+	return "invalid storm event: " + strings.Join(parts, "; ")
+}
+
+// boundingBox is a lat/lon rectangle used for coarse coverage checks.
+type boundingBox struct {
+	name                           string
+	minLat, maxLat, minLon, maxLon float64
+}
+
+// usBounds covers the areas SPC storm reports can originate from: CONUS,
+// Alaska (including the Aleutians west of the antimeridian), Hawaii, and the
+// inhabited territories. The boxes are deliberately generous; they exist to
+// catch zeroed, swapped, or sign-flipped coordinates, not to geocode.
+var usBounds = []boundingBox{
+	{name: "CONUS", minLat: 24.0, maxLat: 50.0, minLon: -125.0, maxLon: -66.5},
+	{name: "Alaska", minLat: 51.0, maxLat: 71.6, minLon: -180.0, maxLon: -129.9},
+	{name: "Aleutians", minLat: 51.0, maxLat: 55.5, minLon: 172.0, maxLon: 180.0},
+	{name: "Hawaii", minLat: 18.5, maxLat: 22.5, minLon: -161.0, maxLon: -154.5},
+	{name: "Puerto Rico and USVI", minLat: 17.5, maxLat: 18.6, minLon: -67.5, maxLon: -64.5},
+	{name: "Guam and Northern Mariana Islands", minLat: 13.2, maxLat: 20.6, minLon: 144.5, maxLon: 146.1},
+	{name: "American Samoa", minLat: -14.6, maxLat: -11.0, minLon: -171.2, maxLon: -168.1},
+}
+
+func withinUSBounds(lat, lon float64) bool {
+	for _, b := range usBounds {
+		if lat >= b.minLat && lat <= b.maxLat && lon >= b.minLon && lon <= b.maxLon {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks an enriched event for structural problems that parsing and
+// enrichment paper over: unparseable source fields (from Quality), coordinates
+// outside the US and its territories, an unrecognized event type, and a
+// missing event time. Each field is reported at most once. It returns nil
+// when the event is valid.
+func Validate(event StormEvent) []ValidationError {
+	var errs []ValidationError
+	reported := func(field string) bool {
+		return slices.ContainsFunc(errs, func(ve ValidationError) bool { return ve.Field == field })
+	}
+	add := func(field, reason string) {
+		if !reported(field) {
+			errs = append(errs, ValidationError{Field: field, Reason: reason})
+		}
+	}
+
+	if event.EventType == "" {
+		add("event_type", "must be one of hail, wind, tornado")
+	}
+	for _, field := range event.Quality.Unparseable {
+		add(field, "unparseable source value")
+	}
+	if event.EventTime.IsZero() {
+		add("event_time", "missing")
+	}
+	// Unparseable coordinates are already reported above; don't pile on.
+	if !reported("geo.lat") && !reported("geo.lon") && !withinUSBounds(event.Geo.Lat, event.Geo.Lon) {
+		add("geo", fmt.Sprintf("(%g, %g) is outside the US and its territories", event.Geo.Lat, event.Geo.Lon))
+	}
+
+	return errs
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validEvent() StormEvent {
+	return StormEvent{
+		ID:          "hail-abc",
+		EventType:   "hail",
+		Geo:         Geo{Lat: 31.02, Lon: -98.44},
+		Measurement: Measurement{Magnitude: 1.25, Unit: "in"},
+		EventTime:   time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC),
+	}
+}
+
+func fields(errs []ValidationError) []string {
+	out := make([]string, len(errs))
+	for i, e := range errs {
+		out[i] = e.Field
+	}
+	return out
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid event", func(t *testing.T) {
+		assert.Empty(t, Validate(validEvent()))
+	})
+
+	t.Run("unrecognized event type is reported once", func(t *testing.T) {
+		ev := validEvent()
+		ev.EventType = "snow"
+		ev = EnrichStormEvent(ev)
+		errs := Validate(ev)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "event_type", errs[0].Field)
+		assert.Equal(t, "must be one of hail, wind, tornado", errs[0].Reason)
+	})
+
+	t.Run("empty event type", func(t *testing.T) {
+		ev := validEvent()
+		ev.EventType = ""
+		assert.Equal(t, []string{"event_type"}, fields(Validate(ev)))
+	})
+
+	t.Run("zero event time", func(t *testing.T) {
+		ev := validEvent()
+		ev.EventTime = time.Time{}
+		assert.Equal(t, []string{"event_time"}, fields(Validate(ev)))
+	})
+
+	t.Run("territories are in bounds", func(t *testing.T) {
+		for _, geo := range []Geo{
+			{Lat: 61.2, Lon: -149.9},  // Anchorage
+			{Lat: 52.9, Lon: 173.2},   // Attu
+			{Lat: 21.3, Lon: -157.8},  // Honolulu
+			{Lat: 18.4, Lon: -66.1},   // San Juan
+			{Lat: 13.4, Lon: 144.8},   // Hagåtña
+			{Lat: -14.3, Lon: -170.7}, // Pago Pago
+		} {
+			ev := validEvent()
+			ev.Geo = geo
+			assert.Empty(t, Validate(ev), "%v", geo)
+		}
+	})
+
+	t.Run("out of range coordinates", func(t *testing.T) {
+		for _, geo := range []Geo{
+			{Lat: 0, Lon: 0},
+			{Lat: 31.02, Lon: 98.44},  // sign-flipped longitude
+			{Lat: -98.44, Lon: 31.02}, // transposed
+			{Lat: 51.5, Lon: -0.1},    // London
+		} {
+			ev := validEvent()
+			ev.Geo = geo
+			assert.Equal(t, []string{"geo"}, fields(Validate(ev)), "%v", geo)
+		}
+	})
+}
+
+func TestValidate_ParseIssues(t *testing.T) {
+	baseDate := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+
+	t.Run("unparseable coordinates are not double-reported", func(t *testing.T) {
+		raw := RawEvent{Value: []byte(`{"Time":"1510","Size":"125","State":"TX","Lat":"abc","Lon":"-98.44","EventType":"hail"}`), Timestamp: baseDate}
+		ev, err := ParseRawEvent(raw)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"geo.lat"}, fields(Validate(ev)))
+	})
+
+	t.Run("unparseable magnitude and time", func(t *testing.T) {
+		raw := RawEvent{Value: []byte(`{"Time":"25:99","Speed":"sixty","State":"OK","Lat":"34.94","Lon":"-95.59","EventType":"wind"}`), Timestamp: baseDate}
+		ev, err := ParseRawEvent(raw)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"measurement.magnitude", "event_time"}, fields(Validate(ev)))
+	})
+}
+
+func TestValidationErrors_Error(t *testing.T) {
+	err := ValidationErrors{
+		{Field: "geo", Reason: "(0, 0) is outside the US and its territories"},
+		{Field: "event_type", Reason: "must be one of hail, wind, tornado"},
+	}
+	assert.Equal(t, "invalid storm event: geo: (0, 0) is outside the US and its territories; event_type: must be one of hail, wind, tornado", err.Error())
+}
@@ -0,0 +1,33 @@
+package geo
+
+import (
+	"slices"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// LoadEnricherDeps loads the data for the enrichment steps in cfg and returns
+// the dependencies for domain.Enrichers. Data for a step that is not enabled
+// is not loaded and leaves its dependency nil.
+func LoadEnricherDeps(cfg *config.Config) (domain.EnricherDeps, error) {
+	deps := domain.EnricherDeps{
+		SeverityRules: cfg.SeverityRules,
+		RepairGeo:     cfg.RepairGeo,
+	}
+	if slices.Contains(cfg.EnrichmentSteps, "geocode") {
+		geocoder, err := Load(cfg.CountyBoundariesFile, cfg.ForecastZonesFile)
+		if err != nil {
+			return domain.EnricherDeps{}, err
+		}
+		deps.Geocoder = geocoder
+	}
+	if slices.Contains(cfg.EnrichmentSteps, "place") {
+		gazetteer, err := LoadGazetteer(cfg.PlacesGazetteerFile)
+		if err != nil {
+			return domain.EnricherDeps{}, err
+		}
+		deps.Gazetteer = gazetteer
+	}
+	return deps, nil
+}
@@ -0,0 +1,67 @@
+package geo
+
+import (
+	"compress/gzip"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// defaultData holds the gzipped files generated by scripts/geodata.sh. They
+// are not checked in, so a build that skipped the script has none.
+//
+//go:embed data
+var defaultData embed.FS
+
+const (
+	defaultCounties  = "data/counties.geojson.gz"
+	defaultZones     = "data/zones.geojson.gz"
+	defaultGazetteer = "data/places.txt.gz"
+)
+
+// errNoDefault is returned when a default data file was not embedded.
+var errNoDefault = errors.New("not embedded in this build; run make geodata or set the file override")
+
+// dataFile is an open data file and the name to report it by.
+type dataFile struct {
+	io.ReadCloser
+	name string
+}
+
+// openData opens path, or the embedded default when path is empty.
+func openData(path, embedded string) (*dataFile, error) {
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", path, err)
+		}
+		return &dataFile{ReadCloser: f, name: path}, nil
+	}
+
+	f, err := defaultData.Open(embedded)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", embedded, errNoDefault)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", embedded, err)
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open %s: %w", embedded, err)
+	}
+	return &dataFile{ReadCloser: gzipFile{zr, f}, name: embedded}, nil
+}
+
+// gzipFile closes both the decompressor and the file under it.
+type gzipFile struct {
+	*gzip.Reader
+	f fs.File
+}
+
+func (g gzipFile) Close() error {
+	return errors.Join(g.Reader.Close(), g.f.Close())
+}
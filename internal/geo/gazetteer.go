@@ -0,0 +1,125 @@
+package geo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// placeSuffixes are the legal/statistical area descriptors the Census
+// appends to place names ("Chappel city", "Dow CDP") and storm reports omit.
+var placeSuffixes = []string{
+	" city and borough", " consolidated government", " metropolitan government",
+	" unified government", " urban county", " city", " town", " township",
+	" village", " borough", " municipality", " cdp", " comunidad", " zona urbana",
+}
+
+type placeKey struct{ name, state string }
+
+type point struct{ lat, lon float64 }
+
+// Gazetteer looks up populated places by name and state. It implements
+// domain.Gazetteer and is safe for concurrent use once loaded.
+type Gazetteer struct {
+	places map[placeKey]point
+}
+
+// LoadGazetteer reads a Census Gazetteer places file, or the embedded default
+// when path is empty: tab-separated with a header row containing USPS, NAME,
+// INTPTLAT, and INTPTLONG columns. When a name occurs more than once in a
+// state the first entry wins. A row without a name and state or with
+// coordinates outside latitude and longitude bounds fails the load.
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	f, err := openData(path, defaultGazetteer)
+	if err != nil {
+		return nil, fmt.Errorf("load gazetteer: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = '\t'
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read gazetteer header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.TrimSpace(h)] = i
+	}
+	for _, name := range []string{"USPS", "NAME", "INTPTLAT", "INTPTLONG"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("gazetteer: missing %s column", name)
+		}
+	}
+
+	g := &Gazetteer{places: make(map[placeKey]point)}
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			if i := col[name]; i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		lat, errLat := strconv.ParseFloat(field("INTPTLAT"), 64)
+		lon, errLon := strconv.ParseFloat(field("INTPTLONG"), 64)
+		if errLat != nil || errLon != nil {
+			return nil, fmt.Errorf("gazetteer line %d: invalid coordinates", line)
+		}
+		if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("gazetteer line %d: coordinates %g, %g out of range", line, lat, lon)
+		}
+		if field("NAME") == "" || field("USPS") == "" {
+			return nil, fmt.Errorf("gazetteer line %d: missing place name or state", line)
+		}
+
+		key := newPlaceKey(field("NAME"), field("USPS"))
+		if _, dup := g.places[key]; !dup {
+			g.places[key] = point{lat, lon}
+		}
+	}
+	if len(g.places) == 0 {
+		return nil, errors.New("gazetteer: no places")
+	}
+	return g, nil
+}
+
+// LookupPlace returns the internal point of the named place in state.
+// Matching ignores case, punctuation, "Saint"/"St." spelling, and Census
+// area suffixes such as "city" and "CDP".
+func (g *Gazetteer) LookupPlace(name, state string) (float64, float64, bool) {
+	p, ok := g.places[newPlaceKey(name, state)]
+	return p.lat, p.lon, ok
+}
+
+func newPlaceKey(name, state string) placeKey {
+	s := strings.ToLower(strings.TrimSpace(name))
+	for _, suffix := range placeSuffixes {
+		if trimmed, ok := strings.CutSuffix(s, suffix); ok {
+			s = trimmed
+			break
+		}
+	}
+	s = strings.ReplaceAll(s, "saint ", "st ")
+	s = strings.ReplaceAll(s, "sainte ", "ste ")
+	s = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	return placeKey{name: s, state: strings.ToUpper(strings.TrimSpace(state))}
+}
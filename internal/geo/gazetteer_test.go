@@ -0,0 +1,81 @@
+package geo
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupPlace(t *testing.T) {
+	g, err := LoadGazetteer("testdata/places.txt")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name, place, state string
+		lat, lon           float64
+		ok                 bool
+	}{
+		{"census suffix stripped", "Abbott", "TX", 31.884, -97.074, true},
+		{"case insensitive", "MCALESTER", "OK", 34.926, -95.769, true},
+		{"saint spelled out", "Saint Louis", "MO", 38.635, -90.244, true},
+		{"lowercase state", "St. Louis", "mo", 38.635, -90.244, true},
+		{"first duplicate wins", "Dow", "OK", 34.88, -95.59, true},
+		{"wrong state", "Abbott", "OK", 0, 0, false},
+		{"unknown place", "Chappel", "TX", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lon, ok := g.LookupPlace(tt.place, tt.state)
+			assert.Equal(t, tt.ok, ok)
+			assert.InDelta(t, tt.lat, lat, 0.0001)
+			assert.InDelta(t, tt.lon, lon, 0.0001)
+		})
+	}
+}
+
+func TestLoadGazetteer_Errors(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "places.txt")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		msg     string
+	}{
+		{"empty file", "", "header"},
+		{"missing column", "USPS\tNAME\tINTPTLAT\n", "missing INTPTLONG column"},
+		{"invalid coordinates", "USPS\tNAME\tINTPTLAT\tINTPTLONG\nTX\tAbbott city\tnorth\t-97.07\n", "line 2: invalid coordinates"},
+		{"coordinates out of range", "USPS\tNAME\tINTPTLAT\tINTPTLONG\nTX\tAbbott city\t-97.07\t31.88\n", "line 2: coordinates -97.07, 31.88 out of range"},
+		{"missing name", "USPS\tNAME\tINTPTLAT\tINTPTLONG\nTX\t\t31.88\t-97.07\n", "line 2: missing place name or state"},
+		{"no places", "USPS\tNAME\tINTPTLAT\tINTPTLONG\n", "no places"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadGazetteer(write(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
+
+func TestLoadGazetteer_EmbeddedDefault(t *testing.T) {
+	g, err := LoadGazetteer("")
+	if _, statErr := fs.Stat(defaultData, defaultGazetteer); statErr != nil {
+		require.ErrorIs(t, err, errNoDefault, "a build without make geodata has no default")
+		return
+	}
+	require.NoError(t, err)
+
+	lat, lon, ok := g.LookupPlace("McAlester", "OK")
+	require.True(t, ok)
+	assert.InDelta(t, 34.93, lat, 0.05)
+	assert.InDelta(t, -95.77, lon, 0.05)
+}
@@ -0,0 +1,145 @@
+package geo
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCounties = "testdata/counties.geojson"
+	testZones    = "testdata/zones.geojson"
+)
+
+func TestReverseGeocode(t *testing.T) {
+	g, err := Load(testCounties, testZones)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		expected domain.GeocodeResult
+		ok       bool
+	}{
+		{
+			name: "county with zone",
+			lat:  38.2, lon: -90.2,
+			expected: domain.GeocodeResult{CountyFIPS: "29189", CountyName: "St. Louis", StateFIPS: "29", State: "MO", ForecastZone: "MOZ063"},
+			ok:       true,
+		},
+		{
+			name: "hole resolves to the enclosed independent city",
+			lat:  38.5, lon: -90.5,
+			expected: domain.GeocodeResult{CountyFIPS: "29510", CountyName: "St. Louis", StateFIPS: "29", State: "MO", ForecastZone: "MOZ063"},
+			ok:       true,
+		},
+		{
+			name: "multipolygon west of the antimeridian",
+			lat:  52.9, lon: 173.2,
+			expected: domain.GeocodeResult{CountyFIPS: "02016", CountyName: "Aleutians West", StateFIPS: "02", State: "AK", ForecastZone: "AKZ191"},
+			ok:       true,
+		},
+		{
+			name: "multipolygon east of the antimeridian without zone",
+			lat:  52.0, lon: -178.0,
+			expected: domain.GeocodeResult{CountyFIPS: "02016", CountyName: "Aleutians West", StateFIPS: "02", State: "AK"},
+			ok:       true,
+		},
+		{
+			name: "outside every boundary",
+			lat:  31.0, lon: -98.4,
+			ok: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ok := g.ReverseGeocode(tt.lat, tt.lon)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestNewGeocoder_CountiesOnly(t *testing.T) {
+	counties, err := loadFeatures(testCounties, "")
+	require.NoError(t, err)
+	g, err := newGeocoder(counties, nil)
+	require.NoError(t, err)
+
+	res, ok := g.ReverseGeocode(38.2, -90.2)
+	require.True(t, ok)
+	assert.Equal(t, "29189", res.CountyFIPS)
+	assert.Empty(t, res.ForecastZone)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "b.geojson")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		msg     string
+	}{
+		{"not a feature collection", `{"type":"Feature"}`, "expected a FeatureCollection"},
+		{"no polygons", `{"type":"FeatureCollection","features":[]}`, "no polygon features"},
+		{"unsupported geometry", `{"type":"FeatureCollection","features":[{"properties":{},"geometry":{"type":"Point","coordinates":[0,0]}}]}`, "unsupported geometry"},
+		{"projected coordinates", `{"type":"FeatureCollection","features":[{"properties":{"GEOID":"48411","NAME":"San Saba"},"geometry":{"type":"Polygon","coordinates":[[[-1095000,3450000],[-1090000,3450000],[-1090000,3455000],[-1095000,3450000]]]}}]}`, "reproject to EPSG:4326"},
+		{"missing county name", `{"type":"FeatureCollection","features":[{"properties":{"GEOID":"48411"},"geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}}]}`, "missing county FIPS or name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(write(t, tt.content), "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.geojson"), "")
+		require.Error(t, err)
+	})
+
+	t.Run("zone without id", func(t *testing.T) {
+		zones := write(t, `{"type":"FeatureCollection","features":[{"properties":{"NAME":"x"},"geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}}]}`)
+		_, err := Load(testCounties, zones)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing zone id")
+	})
+}
+
+func TestRingContains(t *testing.T) {
+	// Concave "L" shape.
+	l := ring{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}, {0, 0}}
+
+	assert.True(t, ringContains(l, 0.5, 0.5))
+	assert.True(t, ringContains(l, 1.5, 0.5))
+	assert.True(t, ringContains(l, 0.5, 1.5))
+	assert.False(t, ringContains(l, 1.5, 1.5), "notch of the L")
+	assert.False(t, ringContains(l, 3, 0.5))
+}
+
+func TestLoad_EmbeddedDefault(t *testing.T) {
+	g, err := Load("", "")
+	if _, statErr := fs.Stat(defaultData, defaultCounties); statErr != nil {
+		require.ErrorIs(t, err, errNoDefault, "a build without make geodata has no default")
+		return
+	}
+	require.NoError(t, err)
+
+	// Downtown St. Louis, an independent city outside St. Louis County.
+	res, ok := g.ReverseGeocode(38.627, -90.199)
+	require.True(t, ok)
+	assert.Equal(t, "29510", res.CountyFIPS)
+	assert.Equal(t, "MO", res.State)
+}
@@ -0,0 +1,138 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// stateAbbr maps state FIPS codes to USPS abbreviations, used when the
+// boundary file lacks a STUSPS property.
+var stateAbbr = map[string]string{
+	"01": "AL", "02": "AK", "04": "AZ", "05": "AR", "06": "CA", "08": "CO", "09": "CT",
+	"10": "DE", "11": "DC", "12": "FL", "13": "GA", "15": "HI", "16": "ID", "17": "IL",
+	"18": "IN", "19": "IA", "20": "KS", "21": "KY", "22": "LA", "23": "ME", "24": "MD",
+	"25": "MA", "26": "MI", "27": "MN", "28": "MS", "29": "MO", "30": "MT", "31": "NE",
+	"32": "NV", "33": "NH", "34": "NJ", "35": "NM", "36": "NY", "37": "NC", "38": "ND",
+	"39": "OH", "40": "OK", "41": "OR", "42": "PA", "44": "RI", "45": "SC", "46": "SD",
+	"47": "TN", "48": "TX", "49": "UT", "50": "VT", "51": "VA", "53": "WA", "54": "WV",
+	"55": "WI", "56": "WY", "60": "AS", "66": "GU", "69": "MP", "72": "PR", "78": "VI",
+}
+
+type county struct {
+	fips, name, stateFIPS, state string
+}
+
+// Geocoder reverse geocodes coordinates to counties and, optionally, NWS
+// public forecast zones. It implements domain.Geocoder and is safe for
+// concurrent use once loaded.
+type Geocoder struct {
+	counties  []county
+	countyIdx *index
+	zones     []string
+	zoneIdx   *index
+}
+
+// Load builds a Geocoder from a county boundary GeoJSON file (e.g. a Census
+// cartographic boundary file converted with ogr2ogr) and an NWS public
+// forecast zone GeoJSON file. An empty path uses the embedded default; if no
+// zones are embedded either, zone lookup is disabled.
+//
+// County features need GEOID (or STATEFP and COUNTYFP) and NAME properties;
+// STUSPS is used when present. Zone features need either an id like "TXZ155"
+// or STATE and ZONE properties.
+func Load(countiesPath, zonesPath string) (*Geocoder, error) {
+	counties, err := loadFeatures(countiesPath, defaultCounties)
+	if err != nil {
+		return nil, fmt.Errorf("load counties: %w", err)
+	}
+	zones, err := loadFeatures(zonesPath, defaultZones)
+	if errors.Is(err, errNoDefault) {
+		zones = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("load forecast zones: %w", err)
+	}
+	return newGeocoder(counties, zones)
+}
+
+// newGeocoder indexes county and zone features. No zones disables zone
+// lookup.
+func newGeocoder(counties, zones []feature) (*Geocoder, error) {
+	g := &Geocoder{counties: make([]county, len(counties)), countyIdx: newIndex(counties)}
+	for i, f := range counties {
+		c, err := countyFromProps(f.props)
+		if err != nil {
+			return nil, fmt.Errorf("load counties: feature %d: %w", i, err)
+		}
+		g.counties[i] = c
+	}
+
+	if len(zones) == 0 {
+		return g, nil
+	}
+	g.zones = make([]string, len(zones))
+	g.zoneIdx = newIndex(zones)
+	for i, f := range zones {
+		z := zoneFromProps(f.props)
+		if z == "" {
+			return nil, fmt.Errorf("load forecast zones: feature %d: missing zone id", i)
+		}
+		g.zones[i] = z
+	}
+	return g, nil
+}
+
+func countyFromProps(props map[string]any) (county, error) {
+	c := county{
+		fips:      stringProp(props, "GEOID"),
+		name:      stringProp(props, "NAME"),
+		stateFIPS: stringProp(props, "STATEFP"),
+		state:     stringProp(props, "STUSPS"),
+	}
+	if c.fips == "" {
+		if countyFP := stringProp(props, "COUNTYFP"); c.stateFIPS != "" && countyFP != "" {
+			c.fips = c.stateFIPS + countyFP
+		}
+	}
+	if len(c.fips) != 5 || c.name == "" {
+		return county{}, fmt.Errorf("missing county FIPS or name")
+	}
+	if c.stateFIPS == "" {
+		c.stateFIPS = c.fips[:2]
+	}
+	if c.state == "" {
+		c.state = stateAbbr[c.stateFIPS]
+	}
+	return c, nil
+}
+
+func zoneFromProps(props map[string]any) string {
+	if id := stringProp(props, "id", "ID"); id != "" {
+		return id
+	}
+	state, zone := stringProp(props, "STATE"), stringProp(props, "ZONE")
+	if state == "" || zone == "" {
+		return ""
+	}
+	return fmt.Sprintf("%sZ%03s", state, zone)
+}
+
+// ReverseGeocode returns the county and forecast zone containing the point.
+// ok is false when the point is in neither.
+func (g *Geocoder) ReverseGeocode(lat, lon float64) (domain.GeocodeResult, bool) {
+	var res domain.GeocodeResult
+	found := false
+	if i := g.countyIdx.lookup(lon, lat); i >= 0 {
+		c := g.counties[i]
+		res.CountyFIPS, res.CountyName, res.StateFIPS, res.State = c.fips, c.name, c.stateFIPS, c.state
+		found = true
+	}
+	if g.zoneIdx != nil {
+		if i := g.zoneIdx.lookup(lon, lat); i >= 0 {
+			res.ForecastZone = g.zones[i]
+			found = true
+		}
+	}
+	return res, found
+}
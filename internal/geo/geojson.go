@@ -0,0 +1,116 @@
+// Package geo provides offline reverse geocoding of storm report coordinates
+// against county and NWS forecast zone boundaries loaded from GeoJSON, and
+// place lookups in a Census gazetteer. Each data set is read from the file
+// named in the config or, by default, from the copy embedded at build time.
+// The loaders fail on anything that would leave an enabled step silently
+// matching nothing: an empty file, a feature without its identifying
+// properties, or coordinates that are not longitude and latitude.
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ring is a closed sequence of [lon, lat] positions.
+type ring [][2]float64
+
+// polygon is an outer ring followed by zero or more holes.
+type polygon []ring
+
+// feature is a boundary with its GeoJSON properties.
+type feature struct {
+	props    map[string]any
+	polygons []polygon
+}
+
+type featureCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Properties map[string]any `json:"properties"`
+		Geometry   *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// loadFeatures reads a GeoJSON FeatureCollection of Polygon and MultiPolygon
+// features. Features with no geometry are skipped.
+func loadFeatures(path, embedded string) ([]feature, error) {
+	file, err := openData(path, embedded)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	path = file.name
+
+	var fc featureCollection
+	if err := json.NewDecoder(file).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("parse %s: expected a FeatureCollection, got %q", path, fc.Type)
+	}
+
+	features := make([]feature, 0, len(fc.Features))
+	for i, f := range fc.Features {
+		if f.Geometry == nil {
+			continue
+		}
+		var polys []polygon
+		switch f.Geometry.Type {
+		case "Polygon":
+			var p polygon
+			err = json.Unmarshal(f.Geometry.Coordinates, &p)
+			polys = []polygon{p}
+		case "MultiPolygon":
+			err = json.Unmarshal(f.Geometry.Coordinates, &polys)
+		default:
+			err = fmt.Errorf("unsupported geometry type %q", f.Geometry.Type)
+		}
+		if err == nil {
+			err = checkLonLat(polys)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: feature %d: %w", path, i, err)
+		}
+		features = append(features, feature{props: f.Properties, polygons: polys})
+	}
+	if len(features) == 0 {
+		return nil, fmt.Errorf("parse %s: %w", path, errors.New("no polygon features"))
+	}
+	return features, nil
+}
+
+// checkLonLat rejects positions outside longitude and latitude bounds, as
+// left by a shapefile converted without reprojecting to EPSG:4326.
+func checkLonLat(polys []polygon) error {
+	for _, p := range polys {
+		for _, r := range p {
+			for _, pos := range r {
+				if pos[0] < -180 || pos[0] > 180 || pos[1] < -90 || pos[1] > 90 {
+					return fmt.Errorf("position [%g, %g] is not [lon, lat]; reproject to EPSG:4326", pos[0], pos[1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// stringProp returns the first non-empty string property among keys.
+// Numeric properties are formatted without a fractional part.
+func stringProp(props map[string]any, keys ...string) string {
+	for _, k := range keys {
+		switch v := props[k].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
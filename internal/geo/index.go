@@ -0,0 +1,107 @@
+package geo
+
+import "math"
+
+// cellSize is the grid resolution in degrees. US counties average well under
+// a degree across, so most cells reference only a handful of polygons.
+const cellSize = 1.0
+
+type bbox struct {
+	minLon, minLat, maxLon, maxLat float64
+}
+
+func (b bbox) contains(lon, lat float64) bool {
+	return lon >= b.minLon && lon <= b.maxLon && lat >= b.minLat && lat <= b.maxLat
+}
+
+type cell struct{ x, y int }
+
+func cellOf(lon, lat float64) cell {
+	return cell{int(math.Floor(lon / cellSize)), int(math.Floor(lat / cellSize))}
+}
+
+// indexedPolygon is a polygon with its owning feature and precomputed bounds.
+type indexedPolygon struct {
+	feature int
+	poly    polygon
+	bounds  bbox
+}
+
+// index is a uniform-grid spatial index over feature polygons. Each grid cell
+// lists the polygons whose bounding box overlaps it; lookups test only those.
+type index struct {
+	polygons []indexedPolygon
+	grid     map[cell][]int
+}
+
+func newIndex(features []feature) *index {
+	idx := &index{grid: make(map[cell][]int)}
+	for fi, f := range features {
+		for _, p := range f.polygons {
+			if len(p) == 0 || len(p[0]) == 0 {
+				continue
+			}
+			b := ringBounds(p[0])
+			pi := len(idx.polygons)
+			idx.polygons = append(idx.polygons, indexedPolygon{feature: fi, poly: p, bounds: b})
+
+			lo, hi := cellOf(b.minLon, b.minLat), cellOf(b.maxLon, b.maxLat)
+			for x := lo.x; x <= hi.x; x++ {
+				for y := lo.y; y <= hi.y; y++ {
+					c := cell{x, y}
+					idx.grid[c] = append(idx.grid[c], pi)
+				}
+			}
+		}
+	}
+	return idx
+}
+
+// lookup returns the index of the first feature containing the point, or -1.
+func (idx *index) lookup(lon, lat float64) int {
+	for _, pi := range idx.grid[cellOf(lon, lat)] {
+		p := idx.polygons[pi]
+		if p.bounds.contains(lon, lat) && polygonContains(p.poly, lon, lat) {
+			return p.feature
+		}
+	}
+	return -1
+}
+
+func ringBounds(r ring) bbox {
+	b := bbox{minLon: math.Inf(1), minLat: math.Inf(1), maxLon: math.Inf(-1), maxLat: math.Inf(-1)}
+	for _, pt := range r {
+		b.minLon = math.Min(b.minLon, pt[0])
+		b.maxLon = math.Max(b.maxLon, pt[0])
+		b.minLat = math.Min(b.minLat, pt[1])
+		b.maxLat = math.Max(b.maxLat, pt[1])
+	}
+	return b
+}
+
+// polygonContains reports whether the point is inside the outer ring and
+// outside every hole.
+func polygonContains(p polygon, lon, lat float64) bool {
+	if !ringContains(p[0], lon, lat) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, lon, lat) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains is the even-odd ray casting test.
+func ringContains(r ring, lon, lat float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -22,6 +22,7 @@ import (
 const (
 	testSourceTopic = "test-source"
 	testSinkTopic   = "test-sink"
+	testDLQTopic    = "test-dlq"
 )
 
 // transformedMessage holds a deserialized message read from the sink topic.
@@ -360,3 +361,86 @@ func TestPipelineTransformError(t *testing.T) {
 	pipelineCancel()
 	require.NoError(t, <-errCh)
 }
+
+// TestPipelineDeadLetter verifies that a message failing transformation is
+// republished to the dead-letter topic with its original payload and failure headers.
+func TestPipelineDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	broker := startKafka(ctx, t)
+
+	createTopic(t, broker, testSourceTopic)
+	createTopic(t, broker, testSinkTopic)
+	createTopic(t, broker, testDLQTopic)
+
+	cfg := &config.Config{
+		KafkaBrokers:       []string{broker},
+		KafkaSourceTopic:   testSourceTopic,
+		KafkaSinkTopic:     testSinkTopic,
+		KafkaDLQTopic:      testDLQTopic,
+		KafkaGroupID:       fmt.Sprintf("test-dlq-%d", time.Now().UnixNano()),
+		BatchFlushInterval: 5 * time.Second,
+	}
+
+	baseDate := time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
+
+	producer := &kafkago.Writer{
+		Addr:  kafkago.TCP(broker),
+		Topic: testSourceTopic,
+	}
+	t.Cleanup(func() { _ = producer.Close() })
+
+	require.NoError(t, producer.WriteMessages(ctx, kafkago.Message{
+		Key:     []byte("bad"),
+		Value:   []byte("not-json{{{"),
+		Time:    baseDate,
+		Headers: []kafkago.Header{{Key: "source", Value: []byte("collector")}},
+	}))
+
+	reader := kafka.NewReader(cfg, discardLogger())
+	t.Cleanup(func() { _ = reader.Close() })
+
+	writer := kafka.NewWriter(cfg, discardLogger())
+	t.Cleanup(func() { _ = writer.Close() })
+
+	dlq := kafka.NewDeadLetterWriter(cfg, discardLogger())
+	t.Cleanup(func() { _ = dlq.Close() })
+
+	metrics := observability.NewMetricsForTesting()
+	p := pipeline.New(reader, pipeline.NewTransformer(discardLogger()), writer, discardLogger(), metrics, 50,
+		pipeline.WithDeadLetter(dlq))
+
+	pipelineCtx, pipelineCancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Run(pipelineCtx) }()
+
+	consumer := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:     []string{broker},
+		Topic:       testDLQTopic,
+		GroupID:     fmt.Sprintf("test-dlq-consumer-%d", time.Now().UnixNano()),
+		StartOffset: kafkago.FirstOffset,
+	})
+	t.Cleanup(func() { _ = consumer.Close() })
+
+	readCtx, readCancel := context.WithTimeout(ctx, 30*time.Second)
+	msg, err := consumer.ReadMessage(readCtx)
+	readCancel()
+	require.NoError(t, err, "read from dead-letter topic")
+
+	pipelineCancel()
+	require.NoError(t, <-errCh)
+
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Equal(t, []byte("bad"), msg.Key)
+	assert.Equal(t, []byte("not-json{{{"), msg.Value)
+	assert.Equal(t, "collector", headers["source"])
+	assert.Contains(t, headers["dlq_error"], "parse raw event")
+	assert.Equal(t, testSourceTopic, headers["dlq_source_topic"])
+	assert.Equal(t, "0", headers["dlq_source_partition"])
+	assert.Equal(t, "0", headers["dlq_source_offset"])
+	assert.Contains(t, headers, "dlq_failed_at")
+}
@@ -0,0 +1,137 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// killedTxnWriter simulates the process dying mid-batch: once the first
+// transaction has committed, the next one produces its messages and then the
+// pipeline is killed before the transaction commits. Nothing is aborted,
+// since a killed process cannot abort.
+type killedTxnWriter struct {
+	*kafka.TransactionalWriter
+	kill    context.CancelFunc
+	commits int
+}
+
+func (w *killedTxnWriter) CommitTxn(ctx context.Context, events []domain.RawEvent) error {
+	if w.commits == 1 {
+		w.kill()
+		return errors.New("killed")
+	}
+	w.commits++
+	return w.TransactionalWriter.CommitTxn(ctx, events)
+}
+
+func (w *killedTxnWriter) AbortTxn(context.Context) error { return nil }
+
+// TestPipelineExactlyOnce kills the pipeline between producing a batch and
+// committing its transaction, restarts it with the same transactional ID, and
+// verifies that a read_committed consumer sees every record exactly once.
+func TestPipelineExactlyOnce(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	broker := startKafka(ctx, t)
+
+	createTopic(t, broker, testSourceTopic)
+	createTopic(t, broker, testSinkTopic)
+
+	cfg := &config.Config{
+		KafkaBrokers:         []string{broker},
+		KafkaSourceTopic:     testSourceTopic,
+		KafkaSinkTopic:       testSinkTopic,
+		KafkaGroupID:         fmt.Sprintf("test-eos-%d", time.Now().UnixNano()),
+		KafkaTransactionalID: "test-eos",
+		DeliveryGuarantee:    "exactly-once",
+		BatchFlushInterval:   2 * time.Second,
+	}
+
+	records := loadMockData(t)
+	baseDate := time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
+	producer := &kafkago.Writer{Addr: kafkago.TCP(broker), Topic: testSourceTopic}
+	t.Cleanup(func() { _ = producer.Close() })
+	msgs := make([]kafkago.Message, 0, len(records))
+	expected := make([]string, 0, len(records))
+	for i, rec := range records {
+		payload, err := json.Marshal(rec)
+		require.NoError(t, err)
+		msgs = append(msgs, kafkago.Message{Key: []byte(fmt.Sprintf("record-%d", i)), Value: payload, Time: baseDate})
+		ev, err := domain.ParseRawEvent(domain.RawEvent{Value: payload, Timestamp: baseDate})
+		require.NoError(t, err)
+		expected = append(expected, ev.ID)
+	}
+	require.NoError(t, producer.WriteMessages(ctx, msgs...))
+
+	const batchSize = 50
+
+	// First run: the second batch is produced but never committed.
+	killCtx, kill := context.WithCancel(ctx)
+	reader, err := kafka.NewGroupReader(cfg, discardLogger())
+	require.NoError(t, err)
+	killed := &killedTxnWriter{TransactionalWriter: kafka.NewTransactionalWriter(cfg, discardLogger()), kill: kill}
+	p := pipeline.New(reader, pipeline.NewTransformer(discardLogger()), killed, discardLogger(),
+		observability.NewMetricsForTesting(), batchSize, pipeline.WithTransactions(killed))
+	require.NoError(t, p.Run(killCtx))
+	require.Equal(t, 1, killed.commits, "first batch committed before the kill")
+	require.NoError(t, reader.Close())
+
+	// Restart with the same transactional ID, which aborts the dangling
+	// transaction, and resume from the committed offsets.
+	reader, err = kafka.NewGroupReader(cfg, discardLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = reader.Close() })
+	writer := kafka.NewTransactionalWriter(cfg, discardLogger())
+	t.Cleanup(func() { _ = writer.Close() })
+	p = pipeline.New(reader, pipeline.NewTransformer(discardLogger()), writer, discardLogger(),
+		observability.NewMetricsForTesting(), batchSize, pipeline.WithTransactions(writer))
+	pipelineCtx, pipelineCancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Run(pipelineCtx) }()
+
+	consumer := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:        []string{broker},
+		Topic:          testSinkTopic,
+		GroupID:        fmt.Sprintf("test-sink-%d", time.Now().UnixNano()),
+		StartOffset:    kafkago.FirstOffset,
+		IsolationLevel: kafkago.ReadCommitted,
+	})
+	t.Cleanup(func() { _ = consumer.Close() })
+
+	got := make([]string, 0, len(records))
+	for range records {
+		got = append(got, readTransformed(ctx, t, consumer).Event.ID)
+	}
+	// Every source record arrives once: no ID is missing and none repeats.
+	assert.ElementsMatch(t, expected, got)
+	counts := make(map[string]int, len(got))
+	for _, id := range got {
+		counts[id]++
+		assert.Equal(t, 1, counts[id], "event %s delivered more than once", id)
+	}
+
+	// Nothing beyond the source records: the killed batch was not delivered twice.
+	readCtx, readCancel := context.WithTimeout(ctx, 10*time.Second)
+	_, err = consumer.ReadMessage(readCtx)
+	readCancel()
+	assert.Error(t, err, "expected no duplicate messages on the sink topic")
+
+	pipelineCancel()
+	require.NoError(t, <-errCh)
+}
@@ -11,9 +11,56 @@ type Metrics struct {
 	TransformErrors  prometheus.Counter
 	PipelineRunning  prometheus.Gauge
 
-	// Batch processing metrics.
+	// DeadLetterMessages counts transform failures parked on the dead-letter topic.
+	DeadLetterMessages prometheus.Counter
+
+	// Duplicates counts events whose ID was already loaded within the dedup window.
+	Duplicates prometheus.Counter
+	// Revisions counts already loaded IDs re-emitted because their content changed.
+	Revisions prometheus.Counter
+
+	// LoadEventFailures counts events that failed to load on their own,
+	// labelled by outcome: retried, dead_lettered, or dropped when no
+	// dead-letter loader is configured.
+	LoadEventFailures *prometheus.CounterVec
+
+	// LoadBreakerState is the load circuit breaker state: 0 closed, 1 half-open, 2 open.
+	LoadBreakerState prometheus.Gauge
+
+	// CommittedOffset is the last committed source offset, labelled by partition.
+	CommittedOffset *prometheus.GaugeVec
+
+	// CommitDuration is the time taken to commit one batch's offsets.
+	CommitDuration prometheus.Histogram
+
+	// TransactionsAborted counts sink transactions aborted and retried in
+	// exactly-once mode.
+	TransactionsAborted prometheus.Counter
+
+	// EndToEndLatency is the time from a source message's Kafka timestamp to
+	// its successful load, labelled by source partition.
+	EndToEndLatency *prometheus.HistogramVec
+
+	// Kafka client metrics, exported periodically from kafka-go's reader and
+	// writer statistics.
+	ConsumerLag        *prometheus.GaugeVec
+	FetchedOffset      *prometheus.GaugeVec
+	KafkaFetchErrors   prometheus.Counter
+	KafkaRebalances    prometheus.Counter
+	KafkaConsumedBytes prometheus.Counter
+	KafkaWriteErrors   prometheus.Counter
+	KafkaProducedBytes prometheus.Counter
+
+	// Batch processing metrics. BatchSizeLimit and BatchFlushInterval are the
+	// current settings, which can change at runtime.
+	BatchSizeLimit          prometheus.Gauge
+	BatchFlushInterval      prometheus.Gauge
 	BatchSize               prometheus.Histogram
 	BatchProcessingDuration prometheus.Histogram
+
+	// Enrichment step metrics, labelled by step name.
+	EnrichmentStepDuration *prometheus.HistogramVec
+	EnrichmentStepErrors   *prometheus.CounterVec
 }
 
 // NewMetrics creates and registers all pipeline metrics with the default Prometheus registry.
@@ -39,6 +86,98 @@ func NewMetrics() *Metrics {
 			Name:      "pipeline_running",
 			Help:      "1 when the pipeline is active, 0 when shut down.",
 		}),
+		DeadLetterMessages: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "dead_letter_messages_total",
+			Help:      "Total messages written to the dead-letter topic.",
+		}),
+		Duplicates: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "duplicates_total",
+			Help:      "Total events dropped or tagged as repeats of an already loaded ID.",
+		}),
+		Revisions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "revisions_total",
+			Help:      "Total events re-emitted as updates because an already loaded ID changed content.",
+		}),
+		LoadEventFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "load_event_failures_total",
+			Help:      "Total per-event load failures, by whether the event was retried, dead-lettered, or dropped.",
+		}, []string{"outcome"}),
+		LoadBreakerState: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "load_breaker_state",
+			Help:      "Load circuit breaker state: 0 closed, 1 half-open, 2 open.",
+		}),
+		CommittedOffset: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "committed_offset",
+			Help:      "Last source offset committed, per partition.",
+		}, []string{"partition"}),
+		CommitDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "storm_etl",
+			Name:      "commit_duration_seconds",
+			Help:      "Duration of committing one batch's source offsets.",
+			Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		}),
+		TransactionsAborted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "transactions_aborted_total",
+			Help:      "Total sink transactions aborted, whose batch was retried.",
+		}),
+		EndToEndLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "storm_etl",
+			Name:      "end_to_end_latency_seconds",
+			Help:      "Time from a source message's Kafka timestamp to its successful load, per partition.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+		}, []string{"partition"}),
+		ConsumerLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "consumer_lag",
+			Help:      "Messages behind the high-water mark as of the last fetch, per partition.",
+		}, []string{"partition"}),
+		FetchedOffset: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "fetched_offset",
+			Help:      "Last source offset fetched, per partition.",
+		}, []string{"partition"}),
+		KafkaFetchErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "kafka_fetch_errors_total",
+			Help:      "Total errors reported by the Kafka reader.",
+		}),
+		KafkaRebalances: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "kafka_rebalances_total",
+			Help:      "Total consumer group rebalances.",
+		}),
+		KafkaConsumedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "kafka_consumed_bytes_total",
+			Help:      "Total message bytes read from the source topic.",
+		}),
+		KafkaWriteErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "kafka_write_errors_total",
+			Help:      "Total errors reported by the Kafka writer.",
+		}),
+		KafkaProducedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "kafka_produced_bytes_total",
+			Help:      "Total message bytes written to the sink topic.",
+		}),
+		BatchSizeLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "batch_size_limit",
+			Help:      "Current maximum number of messages per batch.",
+		}),
+		BatchFlushInterval: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "batch_flush_interval_seconds",
+			Help:      "Current maximum wait before flushing a partial batch.",
+		}),
 		BatchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
 			Namespace: "storm_etl",
 			Name:      "batch_size",
@@ -51,6 +190,17 @@ func NewMetrics() *Metrics {
 			Help:      "Duration of a complete batch extract-transform-load cycle.",
 			Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10},
 		}),
+		EnrichmentStepDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "storm_etl",
+			Name:      "enrichment_step_duration_seconds",
+			Help:      "Duration of a single enrichment step for one event.",
+			Buckets:   []float64{0.000001, 0.000005, 0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.01},
+		}, []string{"step"}),
+		EnrichmentStepErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "enrichment_step_errors_total",
+			Help:      "Total enrichment step failures.",
+		}, []string{"step"}),
 	}
 
 	prometheus.MustRegister(
@@ -58,8 +208,28 @@ func NewMetrics() *Metrics {
 		m.MessagesProduced,
 		m.TransformErrors,
 		m.PipelineRunning,
+		m.DeadLetterMessages,
+		m.Duplicates,
+		m.Revisions,
+		m.LoadEventFailures,
+		m.LoadBreakerState,
+		m.CommittedOffset,
+		m.CommitDuration,
+		m.TransactionsAborted,
+		m.EndToEndLatency,
+		m.ConsumerLag,
+		m.FetchedOffset,
+		m.KafkaFetchErrors,
+		m.KafkaRebalances,
+		m.KafkaConsumedBytes,
+		m.KafkaWriteErrors,
+		m.KafkaProducedBytes,
+		m.BatchSizeLimit,
+		m.BatchFlushInterval,
 		m.BatchSize,
 		m.BatchProcessingDuration,
+		m.EnrichmentStepDuration,
+		m.EnrichmentStepErrors,
 	)
 
 	return m
@@ -73,7 +243,27 @@ func NewMetricsForTesting() *Metrics {
 		MessagesProduced:        prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "messages_produced_total"}),
 		TransformErrors:         prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "transform_errors_total"}),
 		PipelineRunning:         prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "pipeline_running"}),
+		DeadLetterMessages:      prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "dead_letter_messages_total"}),
+		Duplicates:              prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "duplicates_total"}),
+		Revisions:               prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "revisions_total"}),
+		LoadEventFailures:       prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "load_event_failures_total"}, []string{"outcome"}),
+		LoadBreakerState:        prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "load_breaker_state"}),
+		CommittedOffset:         prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "committed_offset"}, []string{"partition"}),
+		CommitDuration:          prometheus.NewHistogram(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "commit_duration_seconds"}),
+		TransactionsAborted:     prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "transactions_aborted_total"}),
+		EndToEndLatency:         prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "end_to_end_latency_seconds"}, []string{"partition"}),
+		ConsumerLag:             prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "consumer_lag"}, []string{"partition"}),
+		FetchedOffset:           prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "fetched_offset"}, []string{"partition"}),
+		KafkaFetchErrors:        prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "kafka_fetch_errors_total"}),
+		KafkaRebalances:         prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "kafka_rebalances_total"}),
+		KafkaConsumedBytes:      prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "kafka_consumed_bytes_total"}),
+		KafkaWriteErrors:        prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "kafka_write_errors_total"}),
+		KafkaProducedBytes:      prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "kafka_produced_bytes_total"}),
+		BatchSizeLimit:          prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "batch_size_limit"}),
+		BatchFlushInterval:      prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "batch_flush_interval_seconds"}),
 		BatchSize:               prometheus.NewHistogram(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "batch_size"}),
 		BatchProcessingDuration: prometheus.NewHistogram(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "batch_processing_duration_seconds"}),
+		EnrichmentStepDuration:  prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "enrichment_step_duration_seconds"}, []string{"step"}),
+		EnrichmentStepErrors:    prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "enrichment_step_errors_total"}, []string{"step"}),
 	}
 }
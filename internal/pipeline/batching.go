@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bounds for runtime batching changes.
+const (
+	MinBatchSize     = 1
+	MaxBatchSize     = 1000
+	MinFlushInterval = 10 * time.Millisecond
+	MaxFlushInterval = time.Minute
+)
+
+// FlushIntervalAdjuster is optionally implemented by a BatchExtractor whose
+// partial-batch flush interval can be changed while it runs.
+type FlushIntervalAdjuster interface {
+	FlushInterval() time.Duration
+	SetFlushInterval(d time.Duration)
+}
+
+// SetBatching changes the batch size and, if the extractor is a
+// FlushIntervalAdjuster, its flush interval. Both take effect from the next
+// extract. A zero value leaves that setting unchanged. Nothing changes if
+// either value is out of bounds.
+func (p *Pipeline) SetBatching(batchSize int, flushInterval time.Duration) error {
+	if batchSize != 0 && (batchSize < MinBatchSize || batchSize > MaxBatchSize) {
+		return fmt.Errorf("batch size must be %d-%d, got %d", MinBatchSize, MaxBatchSize, batchSize)
+	}
+	adjuster, adjustable := p.extractor.(FlushIntervalAdjuster)
+	if flushInterval != 0 {
+		if !adjustable {
+			return fmt.Errorf("extractor does not support changing the flush interval")
+		}
+		if flushInterval < MinFlushInterval || flushInterval > MaxFlushInterval {
+			return fmt.Errorf("flush interval must be %s-%s, got %s", MinFlushInterval, MaxFlushInterval, flushInterval)
+		}
+	}
+
+	if batchSize != 0 {
+		if old := p.batchSize.Swap(int64(batchSize)); old != int64(batchSize) {
+			p.logger.Info("batch size changed", "old", old, "new", batchSize)
+		}
+		p.metrics.BatchSizeLimit.Set(float64(batchSize))
+	}
+	if flushInterval != 0 {
+		if old := adjuster.FlushInterval(); old != flushInterval {
+			adjuster.SetFlushInterval(flushInterval)
+			p.logger.Info("batch flush interval changed", "old", old, "new", flushInterval)
+		}
+		p.metrics.BatchFlushInterval.Set(flushInterval.Seconds())
+	}
+	return nil
+}
+
+// flushInterval returns the extractor's flush interval, or 0 if it has none.
+func (p *Pipeline) flushInterval() time.Duration {
+	if adjuster, ok := p.extractor.(FlushIntervalAdjuster); ok {
+		return adjuster.FlushInterval()
+	}
+	return 0
+}
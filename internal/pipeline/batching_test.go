@@ -0,0 +1,87 @@
+package pipeline_test
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adjustableExtractor is a chanExtractor that records the batch size it was
+// asked for and has an adjustable flush interval.
+type adjustableExtractor struct {
+	chanExtractor
+	requested atomic.Int64
+	flush     atomic.Int64
+}
+
+func (m *adjustableExtractor) ExtractBatch(ctx context.Context, n int) ([]domain.RawEvent, error) {
+	m.requested.Store(int64(n))
+	return m.chanExtractor.ExtractBatch(ctx, n)
+}
+
+func (m *adjustableExtractor) FlushInterval() time.Duration { return time.Duration(m.flush.Load()) }
+
+func (m *adjustableExtractor) SetFlushInterval(d time.Duration) { m.flush.Store(int64(d)) }
+
+func TestPipeline_SetBatching(t *testing.T) {
+	ext := &adjustableExtractor{chanExtractor: make(chanExtractor, 1)}
+	ext.SetFlushInterval(500 * time.Millisecond)
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), metrics, testBatchSize)
+	assert.InDelta(t, testBatchSize, testutil.ToFloat64(metrics.BatchSizeLimit), 0)
+	assert.InDelta(t, 0.5, testutil.ToFloat64(metrics.BatchFlushInterval), 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+	require.Eventually(t, func() bool { return ext.requested.Load() == testBatchSize }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, p.SetBatching(200, 2*time.Second))
+	assert.Equal(t, 2*time.Second, ext.FlushInterval())
+	assert.InDelta(t, 200, testutil.ToFloat64(metrics.BatchSizeLimit), 0)
+	assert.InDelta(t, 2, testutil.ToFloat64(metrics.BatchFlushInterval), 0)
+	assert.Equal(t, 200, p.Status().BatchSize)
+	assert.Equal(t, "2s", p.Status().FlushInterval)
+
+	// The next extract uses the new size.
+	ext.chanExtractor <- []domain.RawEvent{makeRawEvent(t, "evt-1", "hail")}
+	require.Eventually(t, func() bool { return ext.requested.Load() == 200 }, time.Second, 5*time.Millisecond)
+
+	// Zero leaves a setting unchanged.
+	require.NoError(t, p.SetBatching(0, time.Second))
+	assert.Equal(t, 200, p.Status().BatchSize)
+	assert.Equal(t, time.Second, ext.FlushInterval())
+}
+
+func TestPipeline_SetBatching_Bounds(t *testing.T) {
+	ext := &adjustableExtractor{chanExtractor: make(chanExtractor)}
+	ext.SetFlushInterval(500 * time.Millisecond)
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize)
+
+	assert.Error(t, p.SetBatching(1001, 0))
+	assert.Error(t, p.SetBatching(-1, 0))
+	assert.Error(t, p.SetBatching(0, time.Millisecond))
+	assert.Error(t, p.SetBatching(0, 2*time.Minute))
+	// An invalid flush interval rejects the whole change.
+	assert.Error(t, p.SetBatching(100, time.Hour))
+
+	assert.Equal(t, testBatchSize, p.Status().BatchSize)
+	assert.Equal(t, 500*time.Millisecond, ext.FlushInterval())
+}
+
+func TestPipeline_SetBatching_FixedFlushInterval(t *testing.T) {
+	p := pipeline.New(make(chanExtractor), &mockTransformer{}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize)
+
+	assert.Error(t, p.SetBatching(0, time.Second))
+	require.NoError(t, p.SetBatching(10, 0))
+	assert.Equal(t, 10, p.Status().BatchSize)
+	assert.Empty(t, p.Status().FlushInterval)
+}
@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-shared/retry"
+)
+
+// BreakerState is the state of the load circuit breaker. The numeric values
+// are exported as the storm_etl_load_breaker_state gauge.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every load through.
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single probe load through after the open period.
+	BreakerHalfOpen
+	// BreakerOpen blocks loads until the open period has elapsed.
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half_open"
+	case BreakerOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// probePoll is how often a load waiting on another worker's half-open probe
+// checks the breaker again.
+const probePoll = 100 * time.Millisecond
+
+// breaker opens after threshold consecutive whole-batch load failures, blocks
+// loads for openFor, then lets one probe through: success closes it, failure
+// opens it again.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	openFor   time.Duration
+	failures  int
+	state     BreakerState
+	openedAt  time.Time
+	probing   bool
+	onChange  func(BreakerState)
+}
+
+// WithLoadBreaker wraps the loader in a circuit breaker that opens after
+// threshold consecutive failed loads and probes again after openFor. While it
+// is not closed, CheckReadiness reports not ready. threshold <= 0 disables it.
+func WithLoadBreaker(threshold int, openFor time.Duration) Option {
+	return func(p *Pipeline) {
+		if threshold <= 0 {
+			return
+		}
+		p.breaker = &breaker{
+			threshold: threshold,
+			openFor:   openFor,
+			onChange: func(s BreakerState) {
+				p.metrics.LoadBreakerState.Set(float64(s))
+			},
+		}
+	}
+}
+
+// acquire returns 0 if a load may proceed now, or how long to wait before
+// asking again.
+func (b *breaker) acquire() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if wait := b.openFor - time.Since(b.openedAt); wait > 0 {
+			return wait
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return 0
+	case BreakerHalfOpen:
+		if b.probing {
+			return probePoll
+		}
+		b.probing = true
+		return 0
+	}
+	return 0
+}
+
+// record reports the outcome of a load that acquire let through.
+func (b *breaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if ok {
+		b.failures = 0
+		b.setState(BreakerClosed)
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *breaker) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *breaker) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	b.onChange(s)
+}
+
+// awaitBreaker blocks until the breaker lets a load through. Returns false if
+// the context is cancelled first.
+func (p *Pipeline) awaitBreaker(ctx context.Context) bool {
+	if p.breaker == nil {
+		return true
+	}
+	for {
+		wait := p.breaker.acquire()
+		if wait == 0 {
+			return true
+		}
+		if !retry.SleepWithContext(ctx, wait) {
+			return false
+		}
+	}
+}
+
+// recordLoad reports a load outcome to the breaker, if there is one.
+func (p *Pipeline) recordLoad(ok bool) {
+	if p.breaker != nil {
+		p.breaker.record(ok)
+	}
+}
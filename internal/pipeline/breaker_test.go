@@ -0,0 +1,105 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// switchableLoader fails every load while failing is set.
+type switchableLoader struct {
+	failing atomic.Bool
+	calls   atomic.Int64
+	loaded  atomic.Int64
+}
+
+func (m *switchableLoader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	m.calls.Add(1)
+	if m.failing.Load() {
+		return errors.New("topic authorization failed")
+	}
+	m.loaded.Add(int64(len(events)))
+	return nil
+}
+
+// chanExtractor returns batches as the test sends them.
+type chanExtractor chan []domain.RawEvent
+
+func (c chanExtractor) ExtractBatch(ctx context.Context, _ int) ([]domain.RawEvent, error) {
+	select {
+	case batch := <-c:
+		return batch, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestPipeline_LoadBreaker_OpensAndRecovers(t *testing.T) {
+	ext := make(chanExtractor, 1)
+	ext <- []domain.RawEvent{makeRawEvent(t, "evt-1", "hail")}
+	loader := &switchableLoader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithLoadBreaker(2, 300*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- p.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return loader.loaded.Load() == 1 }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return p.CheckReadiness(context.Background()) == nil }, time.Second, 5*time.Millisecond)
+
+	// The second batch hits a sink that rejects everything.
+	loader.failing.Store(true)
+	ext <- []domain.RawEvent{makeRawEvent(t, "evt-2", "hail")}
+
+	require.Eventually(t, func() bool {
+		err := p.CheckReadiness(context.Background())
+		return err != nil && err.Error() == "load circuit breaker is open"
+	}, 3*time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(pipeline.BreakerOpen), testutil.ToFloat64(metrics.LoadBreakerState))
+
+	// No loads are attempted while the breaker is open.
+	calls := loader.calls.Load()
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, calls, loader.calls.Load())
+
+	loader.failing.Store(false)
+	require.Eventually(t, func() bool { return loader.loaded.Load() == 2 }, 3*time.Second, 5*time.Millisecond)
+	assert.NoError(t, p.CheckReadiness(context.Background()))
+	assert.Equal(t, float64(pipeline.BreakerClosed), testutil.ToFloat64(metrics.LoadBreakerState))
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestPipeline_LoadBreaker_FailedProbeReopens(t *testing.T) {
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{makeRawEvent(t, "evt-1", "hail")}}}
+	loader := &switchableLoader{}
+	loader.failing.Store(true)
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithLoadBreaker(1, 100*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	// One failure opens the breaker; each later call is a single half-open
+	// probe per open period (plus the load backoff), not a tight retry loop.
+	assert.GreaterOrEqual(t, loader.calls.Load(), int64(2))
+	assert.LessOrEqual(t, loader.calls.Load(), int64(8))
+	assert.Equal(t, float64(pipeline.BreakerOpen), testutil.ToFloat64(metrics.LoadBreakerState))
+}
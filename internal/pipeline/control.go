@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pipeline states reported by Status.
+const (
+	StateStopped = "stopped"
+	StateRunning = "running"
+	StatePaused  = "paused"
+)
+
+// Status is a snapshot of the pipeline for operators.
+type Status struct {
+	State     string `json:"state"`
+	BatchSize int    `json:"batch_size"`
+	// FlushInterval is the extractor's partial-batch flush interval, if it
+	// has one.
+	FlushInterval string `json:"flush_interval,omitempty"`
+	Workers       int    `json:"workers"`
+	// InFlight is the number of batches extracted but not yet committed.
+	InFlight int `json:"in_flight"`
+	// Backoff is the retry delay currently being waited out, or "0s".
+	Backoff     string     `json:"backoff"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// CommittedOffsets is the last committed source offset per partition.
+	CommittedOffsets map[int]int64 `json:"committed_offsets"`
+	LoadBreaker      string        `json:"load_breaker,omitempty"`
+}
+
+// control holds the pipeline's operator-facing state: whether it is paused,
+// and what it last did, for Status.
+type control struct {
+	mu        sync.Mutex
+	running   bool
+	paused    bool
+	resumed   chan struct{} // closed by Resume; replaced by Pause
+	backoff   time.Duration
+	lastErr   error
+	lastErrAt time.Time
+	committed map[int]int64
+}
+
+func newControl() control {
+	return control{committed: make(map[int]int64)}
+}
+
+// Pause stops the pipeline from extracting new batches. Batches already
+// extracted are still loaded and committed. The consumer stays in its group,
+// so pausing does not trigger a rebalance.
+func (p *Pipeline) Pause() {
+	p.control.mu.Lock()
+	defer p.control.mu.Unlock()
+	if p.control.paused {
+		return
+	}
+	p.control.paused = true
+	p.control.resumed = make(chan struct{})
+	p.logger.Info("pipeline paused")
+}
+
+// Resume lets a paused pipeline extract again.
+func (p *Pipeline) Resume() {
+	p.control.mu.Lock()
+	defer p.control.mu.Unlock()
+	if !p.control.paused {
+		return
+	}
+	p.control.paused = false
+	close(p.control.resumed)
+	p.logger.Info("pipeline resumed")
+}
+
+// Status returns a snapshot of the pipeline's state.
+func (p *Pipeline) Status() Status {
+	p.health.mu.Lock()
+	inFlight := p.health.inFlight
+	p.health.mu.Unlock()
+
+	p.control.mu.Lock()
+	defer p.control.mu.Unlock()
+
+	s := Status{
+		State:            StateStopped,
+		BatchSize:        int(p.batchSize.Load()),
+		Workers:          p.workers,
+		InFlight:         inFlight,
+		Backoff:          p.control.backoff.String(),
+		CommittedOffsets: make(map[int]int64, len(p.control.committed)),
+	}
+	switch {
+	case p.control.running && p.control.paused:
+		s.State = StatePaused
+	case p.control.running:
+		s.State = StateRunning
+	}
+	if p.control.lastErr != nil {
+		s.LastError = p.control.lastErr.Error()
+		at := p.control.lastErrAt
+		s.LastErrorAt = &at
+	}
+	for partition, offset := range p.control.committed {
+		s.CommittedOffsets[partition] = offset
+	}
+	if d := p.flushInterval(); d > 0 {
+		s.FlushInterval = d.String()
+	}
+	if p.breaker != nil {
+		s.LoadBreaker = p.breaker.current().String()
+	}
+	return s
+}
+
+// awaitResume blocks while the pipeline is paused. Returns false if ctx is
+// cancelled first.
+func (p *Pipeline) awaitResume(ctx context.Context) bool {
+	p.control.mu.Lock()
+	paused, resumed := p.control.paused, p.control.resumed
+	p.control.mu.Unlock()
+	if !paused {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *control) setRunning(running bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = running
+}
+
+func (c *control) setBackoff(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.backoff = d
+}
+
+func (c *control) recordError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastErr = err
+	c.lastErrAt = time.Now()
+}
+
+func (c *control) recordCommitted(partition int, offset int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.committed[partition] = offset
+}
@@ -0,0 +1,97 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingExtractor wraps a chanExtractor and counts ExtractBatch calls.
+type countingExtractor struct {
+	chanExtractor
+	calls atomic.Int64
+}
+
+func (m *countingExtractor) ExtractBatch(ctx context.Context, n int) ([]domain.RawEvent, error) {
+	m.calls.Add(1)
+	return m.chanExtractor.ExtractBatch(ctx, n)
+}
+
+// flakyExtractor fails once with err, then returns batch, then waits.
+type flakyExtractor struct {
+	err   error
+	batch []domain.RawEvent
+	calls atomic.Int64
+}
+
+func (m *flakyExtractor) ExtractBatch(ctx context.Context, _ int) ([]domain.RawEvent, error) {
+	switch m.calls.Add(1) {
+	case 1:
+		return nil, m.err
+	case 2:
+		return m.batch, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestPipeline_PauseAndResume(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		ext := &countingExtractor{chanExtractor: make(chanExtractor, 1)}
+		loader := &switchableLoader{}
+		p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+			pipeline.WithWorkers(workers))
+		assert.Equal(t, pipeline.StateStopped, p.Status().State)
+
+		p.Pause()
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- p.Run(ctx) }()
+
+		require.Eventually(t, func() bool { return p.Status().State == pipeline.StatePaused }, time.Second, 5*time.Millisecond)
+		ext.chanExtractor <- []domain.RawEvent{makeRawEvent(t, "evt-1", "hail")}
+		time.Sleep(50 * time.Millisecond)
+		assert.Zero(t, ext.calls.Load(), "a paused pipeline does not extract")
+
+		p.Resume()
+		require.Eventually(t, func() bool { return loader.loaded.Load() == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, pipeline.StateRunning, p.Status().State)
+
+		// Cancelling while paused still stops the pipeline.
+		p.Pause()
+		cancel()
+		require.NoError(t, <-done)
+		assert.Equal(t, pipeline.StateStopped, p.Status().State)
+	}
+}
+
+func TestPipeline_Status(t *testing.T) {
+	raw := makeRawEvent(t, "evt-1", "hail")
+	raw.Partition = 2
+	raw.Offset = 41
+	raw.Commit = func(context.Context) error { return nil }
+	ext := &flakyExtractor{err: errors.New("broker unavailable"), batch: []domain.RawEvent{raw}}
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithLoadBreaker(5, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	s := p.Status()
+	assert.Equal(t, testBatchSize, s.BatchSize)
+	assert.Equal(t, 1, s.Workers)
+	assert.Equal(t, "0s", s.Backoff)
+	assert.Equal(t, "extract: broker unavailable", s.LastError)
+	require.NotNil(t, s.LastErrorAt)
+	assert.Equal(t, map[int]int64{2: 41}, s.CommittedOffsets)
+	assert.Equal(t, "closed", s.LoadBreaker)
+}
@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// DedupMode selects what the pipeline does with an event whose ID was already
+// loaded within the dedup window.
+type DedupMode string
+
+const (
+	// DedupOff forwards repeats unchanged. Revisions are still numbered.
+	DedupOff DedupMode = "off"
+	// DedupDrop skips repeats; their offsets are still committed.
+	DedupDrop DedupMode = "drop"
+	// DedupTag forwards repeats with StormEvent.Duplicate set.
+	DedupTag DedupMode = "tag"
+)
+
+// SeenEntry records the latest loaded version of an event ID.
+type SeenEntry struct {
+	// SeenAt is when this version was loaded.
+	SeenAt time.Time `json:"seen_at"`
+	// ContentHash is domain.ContentHash of the loaded event.
+	ContentHash string `json:"content_hash,omitempty"`
+	// Revision is the StormEvent.Revision the version was loaded with.
+	Revision int `json:"revision,omitempty"`
+}
+
+// SeenStore remembers event IDs that have already been loaded. Implementations
+// own the dedup window: Get reports an ID as absent once its entry is older
+// than the window.
+type SeenStore interface {
+	Get(ctx context.Context, id string) (SeenEntry, bool, error)
+	Put(ctx context.Context, id string, entry SeenEntry) error
+}
+
+// WithDedup checks each transformed event against store. An ID loaded with
+// different content is a revision and is forwarded with StormEvent.Revision
+// incremented, whatever the mode. An ID already loaded with the same content
+// is dropped, tagged, or forwarded unchanged according to mode. Versions are
+// recorded only after their batch loads successfully, so a failed load never
+// suppresses the retry.
+func WithDedup(store SeenStore, mode DedupMode) Option {
+	return func(p *Pipeline) {
+		if mode == "" {
+			mode = DedupOff
+		}
+		p.seen = store
+		p.dedupMode = mode
+	}
+}
+
+// seenUpdate is a version to record once its batch has loaded.
+type seenUpdate struct {
+	id    string
+	entry SeenEntry
+}
+
+// dedupBatch numbers revisions and drops or tags repeats in events, comparing against the
+// store and earlier events in the same batch. It returns the kept events, their
+// raw messages (raws is parallel to events), and the version to record for
+// each kept event once it loads; a nil version means nothing to record. Store
+// and hash errors fail open: the event is treated as new.
+func (p *Pipeline) dedupBatch(ctx context.Context, events []domain.StormEvent, raws []domain.RawEvent) ([]domain.StormEvent, []domain.RawEvent, []*SeenEntry) {
+	if p.seen == nil {
+		return events, raws, nil
+	}
+
+	now := time.Now()
+	kept := events[:0]
+	keptRaw := raws[:0]
+	versions := make([]*SeenEntry, 0, len(events))
+	inBatch := make(map[string]SeenEntry, len(events))
+	for i, ev := range events {
+		var version *SeenEntry
+		hash, err := domain.ContentHash(ev)
+		if err != nil {
+			p.logger.Warn("dedup hash failed, treating event as new", "error", err, "id", ev.ID)
+			kept = append(kept, ev)
+			keptRaw = append(keptRaw, raws[i])
+			versions = append(versions, nil)
+			continue
+		}
+
+		prev, found := inBatch[ev.ID]
+		if !found {
+			prev, found, err = p.seen.Get(ctx, ev.ID)
+			if err != nil {
+				p.logger.Warn("dedup lookup failed, treating event as new", "error", err, "id", ev.ID)
+				found = false
+			}
+		}
+
+		switch {
+		case found && prev.ContentHash == hash:
+			p.metrics.Duplicates.Inc()
+			switch p.dedupMode {
+			case DedupDrop:
+				continue
+			case DedupTag:
+				ev.Duplicate = true
+			}
+			ev.Revision = prev.Revision
+		case found:
+			ev.Revision = prev.Revision + 1
+			p.metrics.Revisions.Inc()
+			fallthrough
+		default:
+			version = &SeenEntry{SeenAt: now, ContentHash: hash, Revision: ev.Revision}
+			inBatch[ev.ID] = *version
+		}
+		kept = append(kept, ev)
+		keptRaw = append(keptRaw, raws[i])
+		versions = append(versions, version)
+	}
+	return kept, keptRaw, versions
+}
+
+// recordSeen stores the version of each loaded event, in batch order so the
+// latest revision of an ID wins. versions and loaded are parallel to events.
+func (p *Pipeline) recordSeen(ctx context.Context, events []domain.StormEvent, versions []*SeenEntry, loaded []bool) {
+	for i, version := range versions {
+		if version == nil || !loaded[i] {
+			continue
+		}
+		if err := p.seen.Put(ctx, events[i].ID, *version); err != nil {
+			p.logger.Warn("dedup record failed", "error", err, "id", events[i].ID)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// defaultLoadAttempts bounds how often an event that fails on its own is
+// written before it is dead-lettered.
+const defaultLoadAttempts = 5
+
+// BatchLoadError is returned by a BatchLoader when some events in the batch
+// were not written. Errs is parallel to the events passed to LoadBatch; a nil
+// entry means that event was written. Any other error from LoadBatch means
+// no event was written; loaders should return it, rather than a BatchLoadError
+// with every entry set, when the cause is not specific to the events (e.g. the
+// sink is unreachable) so the pipeline does not count it against them.
+type BatchLoadError struct {
+	Errs []error
+}
+
+func (e *BatchLoadError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errs {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d events failed to load: %v", failed, len(e.Errs), first)
+}
+
+// permanentError marks a load error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a per-event load failure that will not succeed on
+// retry, e.g. a message larger than the sink accepts. The pipeline
+// dead-letters such events immediately instead of spending retry attempts.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// WithLoadAttempts sets how many times an event that fails on its own, while
+// others in its batch load, is written before it is dead-lettered. Failures
+// that affect the whole batch are retried without limit. Defaults to 5.
+func WithLoadAttempts(n int) Option {
+	return func(p *Pipeline) {
+		p.loadAttempts = max(n, 1)
+	}
+}
+
+// errStopped is returned by the load stages when the pipeline is stopping.
+var errStopped = errors.New("pipeline stopping")
+
+// load writes events and retries failed ones with backoff. Events whose
+// failure is permanent, or that keep failing after loadAttempts, are
+// dead-lettered using their raw message from raws, which is parallel to
+// events, unless parked already holds it. Returns whether each event was loaded, and errStopped if the
+// pipeline should stop. Inside a transaction a whole-batch failure is
+// returned instead of retried, since only an abort undoes a partial write.
+func (p *Pipeline) load(ctx context.Context, events []domain.StormEvent, raws []domain.RawEvent, parked parkedSet, maxBackoff time.Duration) ([]bool, error) {
+	loaded := make([]bool, len(events))
+	attempts := make([]int, len(events))
+	pending := make([]int, len(events))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	backoff := 200 * time.Millisecond
+	for len(pending) > 0 {
+		batch := make([]domain.StormEvent, len(pending))
+		for j, i := range pending {
+			batch[j] = events[i]
+		}
+
+		if !p.awaitBreaker(ctx) {
+			return loaded, errStopped
+		}
+		err := p.loader.LoadBatch(ctx, batch)
+		if err == nil {
+			p.recordLoad(true)
+			for _, i := range pending {
+				loaded[i] = true
+			}
+			return loaded, nil
+		}
+
+		// An error that is not a BatchLoadError failed the whole batch, e.g.
+		// the sink is unreachable. That says nothing about particular events,
+		// so it does not use up their attempts.
+		var ble *BatchLoadError
+		outage := !errors.As(err, &ble) || len(ble.Errs) != len(batch)
+		var errs []error
+		if outage {
+			errs = make([]error, len(batch))
+			for j := range errs {
+				errs[j] = err
+			}
+		} else {
+			errs = ble.Errs
+		}
+		p.recordLoad(!outage)
+		if outage && p.txn != nil {
+			return loaded, err
+		}
+
+		retry := pending[:0]
+		for j, i := range pending {
+			err := errs[j]
+			switch {
+			case err == nil:
+				loaded[i] = true
+				continue
+			case outage:
+				retry = append(retry, i)
+				continue
+			}
+
+			attempts[i]++
+			if !IsPermanent(err) && attempts[i] < p.loadAttempts {
+				p.metrics.LoadEventFailures.WithLabelValues("retried").Inc()
+				retry = append(retry, i)
+				continue
+			}
+			if p.deadLetter == nil {
+				// Nowhere to park it: the event is lost once its offset commits.
+				p.logger.Error("load failed, dropping event", "error", err, "id", events[i].ID,
+					"attempts", attempts[i], "topic", raws[i].Topic, "partition", raws[i].Partition, "offset", raws[i].Offset)
+				p.metrics.LoadEventFailures.WithLabelValues("dropped").Inc()
+				continue
+			}
+			p.logger.Warn("load failed, dead-lettering event", "error", err, "id", events[i].ID,
+				"attempts", attempts[i], "topic", raws[i].Topic, "partition", raws[i].Partition, "offset", raws[i].Offset)
+			p.metrics.LoadEventFailures.WithLabelValues("dead_lettered").Inc()
+			if !p.sendToDeadLetter(ctx, raws[i], fmt.Errorf("load: %w", err), parked, maxBackoff) {
+				return loaded, errStopped
+			}
+		}
+		pending = retry
+
+		if len(pending) > 0 {
+			p.logger.Error("load batch failed", "error", err,
+				"failed", len(pending), "batch_size", len(batch))
+			p.control.recordError(fmt.Errorf("load: %w", err))
+			if !p.backoffOrStop(ctx, &backoff, maxBackoff) {
+				return loaded, errStopped
+			}
+		}
+	}
+	return loaded, nil
+}
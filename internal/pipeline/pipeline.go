@@ -3,20 +3,34 @@ package pipeline
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"strconv"
 	"sync/atomic"
 	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
 	"github.com/couchcryptid/storm-data-etl/internal/observability"
 	"github.com/couchcryptid/storm-data-shared/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// BatchExtractor reads up to batchSize raw events from the source.
+// BatchExtractor reads up to batchSize raw events from the source. A finite
+// source returns io.EOF once it has no more events; the pipeline then finishes
+// the batches in flight and Run returns.
 type BatchExtractor interface {
 	ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error)
 }
 
+// BatchCommitter is optionally implemented by a BatchExtractor that can
+// commit the offsets of a whole batch in one call, e.g. the highest offset per
+// partition. The pipeline prefers it over the per-message Commit callbacks.
+type BatchCommitter interface {
+	CommitBatch(ctx context.Context, events []domain.RawEvent) error
+}
+
 // Transformer converts a raw event into a domain storm event.
 type Transformer interface {
 	Transform(ctx context.Context, raw domain.RawEvent) (domain.StormEvent, error)
@@ -27,43 +41,84 @@ type BatchLoader interface {
 	LoadBatch(ctx context.Context, events []domain.StormEvent) error
 }
 
+// DeadLetterLoader parks a raw event that could not be transformed, together
+// with the reason it failed, so it can be inspected and replayed later.
+type DeadLetterLoader interface {
+	LoadDeadLetter(ctx context.Context, raw domain.RawEvent, cause error) error
+}
+
+// Option configures optional Pipeline behavior.
+type Option func(*Pipeline)
+
+// WithDeadLetter routes transform failures to dl instead of dropping them.
+// Offsets of failed messages are committed only after the dead-letter write
+// succeeds.
+func WithDeadLetter(dl DeadLetterLoader) Option {
+	return func(p *Pipeline) {
+		p.deadLetter = dl
+	}
+}
+
 // Pipeline orchestrates the extract-transform-load loop.
 type Pipeline struct {
-	extractor   BatchExtractor
-	transformer Transformer
-	loader      BatchLoader
-	logger      *slog.Logger
-	metrics     *observability.Metrics
-	ready       atomic.Bool
-	batchSize   int
+	extractor    BatchExtractor
+	transformer  Transformer
+	loader       BatchLoader
+	txn          TransactionalLoader
+	deadLetter   DeadLetterLoader
+	seen         SeenStore
+	dedupMode    DedupMode
+	logger       *slog.Logger
+	metrics      *observability.Metrics
+	health       health
+	batchSize    atomic.Int64 // read each extract; see SetBatching
+	workers      int
+	loadAttempts int
+	breaker      *breaker
+	tracer       trace.Tracer
+	control      control
 }
 
 // New creates a Pipeline with the given stages and observability.
-func New(e BatchExtractor, t Transformer, l BatchLoader, logger *slog.Logger, metrics *observability.Metrics, batchSize int) *Pipeline {
-	return &Pipeline{
-		extractor:   e,
-		transformer: t,
-		loader:      l,
-		logger:      logger,
-		metrics:     metrics,
-		batchSize:   batchSize,
+func New(e BatchExtractor, t Transformer, l BatchLoader, logger *slog.Logger, metrics *observability.Metrics, batchSize int, opts ...Option) *Pipeline {
+	p := &Pipeline{
+		extractor:    e,
+		transformer:  t,
+		loader:       l,
+		logger:       logger,
+		metrics:      metrics,
+		workers:      1,
+		loadAttempts: defaultLoadAttempts,
+		health:       health{staleAfter: defaultStaleAfter},
+		tracer:       defaultTracer(),
+		control:      newControl(),
 	}
-}
-
-// CheckReadiness returns nil if the pipeline has processed at least one message,
-// or an error describing why the service is not yet ready.
-func (p *Pipeline) CheckReadiness(_ context.Context) error {
-	if !p.ready.Load() {
-		return errors.New("pipeline has not processed any messages yet")
+	p.batchSize.Store(int64(batchSize))
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.txn != nil {
+		p.workers = 1
+	}
+	metrics.BatchSizeLimit.Set(float64(batchSize))
+	if d := p.flushInterval(); d > 0 {
+		metrics.BatchFlushInterval.Set(d.Seconds())
 	}
-	return nil
+	return p
 }
 
-// Run executes the batch ETL loop until the context is cancelled.
+// Run executes the batch ETL loop until the context is cancelled or the
+// extractor reports io.EOF.
 func (p *Pipeline) Run(ctx context.Context) error {
-	p.logger.Info("pipeline started", "batch_size", p.batchSize)
+	p.logger.Info("pipeline started", "batch_size", p.batchSize.Load(), "workers", p.workers)
 	p.metrics.PipelineRunning.Set(1)
 	defer p.metrics.PipelineRunning.Set(0)
+	p.control.setRunning(true)
+	defer p.control.setRunning(false)
+
+	if p.workers > 1 {
+		return p.runWorkers(ctx)
+	}
 
 	// Exponential backoff: start at 200ms, double each retry, cap at 5s.
 	// Keeps retry storms short while avoiding tight loops during Kafka outages.
@@ -71,11 +126,9 @@ func (p *Pipeline) Run(ctx context.Context) error {
 	maxBackoff := 5 * time.Second
 
 	for {
-		select {
-		case <-ctx.Done():
+		if !p.awaitResume(ctx) || ctx.Err() != nil {
 			p.logger.Info("pipeline stopping", "reason", ctx.Err())
 			return nil
-		default:
 		}
 
 		if !p.processBatch(ctx, &backoff, maxBackoff) {
@@ -88,44 +141,88 @@ func (p *Pipeline) Run(ctx context.Context) error {
 func (p *Pipeline) processBatch(ctx context.Context, backoff *time.Duration, maxBackoff time.Duration) bool {
 	start := time.Now()
 
-	rawBatch, err := p.extractor.ExtractBatch(ctx, p.batchSize)
+	rawBatch, ok := p.extract(ctx, backoff, maxBackoff)
+	if !ok || len(rawBatch) == 0 {
+		return ok
+	}
+
+	p.health.startBatch()
+	return p.runBatch(ctx, rawBatch, start, time.Now(), maxBackoff)
+}
+
+// runBatch transforms, loads, and commits a batch the caller has marked in
+// flight with startBatch, and finishes it on every path. A batch whose
+// context is already cancelled is abandoned without processing. Returns
+// false if the pipeline should stop.
+func (p *Pipeline) runBatch(ctx context.Context, rawBatch []domain.RawEvent, started, extracted time.Time, maxBackoff time.Duration) (ok bool) {
+	defer func() { p.health.finishBatch(ok) }()
+	if ctx.Err() != nil {
+		return false
+	}
+
+	ctx, span := p.startBatchSpan(ctx, rawBatch, started, extracted)
+	loaded, ok := p.transformAndLoad(ctx, rawBatch, maxBackoff)
+	endBatchSpan(span, loaded, ok)
+	if ok && loaded > 0 {
+		p.metrics.BatchProcessingDuration.Observe(time.Since(started).Seconds())
+	}
+	return ok
+}
+
+// extract reads the next batch, backing off on failure. An empty batch with
+// true means there was nothing to read or the extract failed and should be
+// retried. Returns false if the pipeline should stop, including when the
+// source is exhausted.
+func (p *Pipeline) extract(ctx context.Context, backoff *time.Duration, maxBackoff time.Duration) ([]domain.RawEvent, bool) {
+	rawBatch, err := p.extractor.ExtractBatch(ctx, int(p.batchSize.Load()))
+	if errors.Is(err, io.EOF) {
+		p.logger.Info("source exhausted")
+		return nil, false
+	}
+	if ctx.Err() == nil {
+		p.health.recordExtract(err)
+	}
 	if err != nil {
 		if ctx.Err() != nil {
-			return false
+			return nil, false
 		}
 		p.logger.Error("extract batch failed", "error", err)
-		return p.backoffOrStop(ctx, backoff, maxBackoff)
+		p.control.recordError(fmt.Errorf("extract: %w", err))
+		return nil, p.backoffOrStop(ctx, backoff, maxBackoff)
 	}
 
 	if len(rawBatch) == 0 {
-		return ctx.Err() == nil
+		return nil, ctx.Err() == nil
 	}
 
 	p.metrics.MessagesConsumed.Add(float64(len(rawBatch)))
 	p.metrics.BatchSize.Observe(float64(len(rawBatch)))
 	*backoff = 200 * time.Millisecond
-
-	loaded, ok := p.transformAndLoad(ctx, rawBatch, backoff, maxBackoff)
-	if !ok {
-		return false
-	}
-
-	if loaded > 0 {
-		p.metrics.BatchProcessingDuration.Observe(time.Since(start).Seconds())
-		p.ready.Store(true)
-	}
-	return true
+	return rawBatch, true
 }
 
 // transformAndLoad transforms each message in the batch, loads the successes,
-// and commits offsets. Returns the number of successfully loaded messages and
-// false if the pipeline should stop.
-func (p *Pipeline) transformAndLoad(ctx context.Context, rawBatch []domain.RawEvent, backoff *time.Duration, maxBackoff time.Duration) (int, bool) {
+// dead-letters the failures, and commits offsets. Returns the number of
+// successfully loaded messages and false if the pipeline should stop.
+func (p *Pipeline) transformAndLoad(ctx context.Context, rawBatch []domain.RawEvent, maxBackoff time.Duration) (int, bool) {
 	outBatch := make([]domain.StormEvent, 0, len(rawBatch))
-	successfulRaws := make([]domain.RawEvent, 0, len(rawBatch))
+	outRaw := make([]domain.RawEvent, 0, len(rawBatch))
 
+	// Offsets are committed in source order after the load so a dead-lettered
+	// message never commits past an earlier message that has not been loaded.
+	toCommit := make([]domain.RawEvent, 0, len(rawBatch))
+
+	// Messages that fail to transform are dead-lettered with the commit, once
+	// the load has succeeded, so a load that fails until shutdown leaves
+	// nothing parked for the redelivered batch to park again.
+	var rejected []rejection
+
+	transformCtx, transformSpan := p.tracer.Start(ctx, "pipeline.transform")
 	for _, raw := range rawBatch {
-		out, err := p.transformer.Transform(ctx, raw)
+		eventCtx, eventSpan := p.startEventSpan(transformCtx, raw)
+		out, err := p.transformer.Transform(eventCtx, raw)
+		out.TraceContext = eventSpan.SpanContext()
+		endSpan(eventSpan, err)
 		if err != nil {
 			p.logger.Warn("transform failed, skipping message",
 				"error", err,
@@ -134,29 +231,140 @@ func (p *Pipeline) transformAndLoad(ctx context.Context, rawBatch []domain.RawEv
 				"offset", raw.Offset,
 			)
 			p.metrics.TransformErrors.Inc()
-			p.commitOffset(ctx, raw)
+			rejected = append(rejected, rejection{raw: raw, cause: err})
+			toCommit = append(toCommit, raw)
 			continue
 		}
 		outBatch = append(outBatch, out)
-		successfulRaws = append(successfulRaws, raw)
+		outRaw = append(outRaw, raw)
+		toCommit = append(toCommit, raw)
 	}
+	transformSpan.SetAttributes(attribute.Int("storm_etl.transformed", len(outBatch)))
+	transformSpan.End()
+
+	outBatch, outRaw, versions := p.dedupBatch(ctx, outBatch, outRaw)
 
-	if len(outBatch) == 0 {
-		return 0, true
+	parked := make(parkedSet)
+	if p.txn != nil {
+		return p.loadInTxn(ctx, outBatch, outRaw, versions, rejected, parked, toCommit, maxBackoff)
 	}
 
-	if err := p.loader.LoadBatch(ctx, outBatch); err != nil {
-		p.logger.Error("load batch failed", "error", err, "batch_size", len(outBatch))
-		return 0, p.backoffOrStop(ctx, backoff, maxBackoff)
+	produced := 0
+	if len(outBatch) > 0 {
+		loaded, err := p.tracedLoad(ctx, outBatch, outRaw, parked, maxBackoff)
+		if err != nil {
+			return 0, false
+		}
+		produced = p.recordLoaded(ctx, outBatch, outRaw, versions, loaded)
+	}
+	if !p.parkRejected(ctx, rejected, parked, maxBackoff) {
+		return 0, false
 	}
 
-	p.metrics.MessagesProduced.Add(float64(len(outBatch)))
+	commitCtx, commitSpan := p.tracer.Start(ctx, "pipeline.commit")
+	p.commitOffsets(commitCtx, toCommit)
+	commitSpan.End()
 
-	for _, raw := range successfulRaws {
-		p.commitOffset(ctx, raw)
+	return produced, true
+}
+
+// tracedLoad runs load in a pipeline.load span.
+func (p *Pipeline) tracedLoad(ctx context.Context, events []domain.StormEvent, raws []domain.RawEvent, parked parkedSet, maxBackoff time.Duration) ([]bool, error) {
+	loadCtx, loadSpan := p.tracer.Start(ctx, "pipeline.load",
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(events))))
+	defer loadSpan.End()
+	return p.load(loadCtx, events, raws, parked, maxBackoff)
+}
+
+// recordLoaded updates the produced and latency metrics and the seen store
+// for the events that loaded, and returns how many did. raws, versions, and
+// loaded are parallel to events.
+func (p *Pipeline) recordLoaded(ctx context.Context, events []domain.StormEvent, raws []domain.RawEvent, versions []*SeenEntry, loaded []bool) int {
+	produced := 0
+	for _, l := range loaded {
+		if l {
+			produced++
+		}
+	}
+	p.metrics.MessagesProduced.Add(float64(produced))
+	p.observeLatency(raws, loaded)
+	p.recordSeen(ctx, events, versions, loaded)
+	return produced
+}
+
+// observeLatency records the end-to-end latency of each loaded event, from its
+// source message timestamp. Messages without a timestamp are skipped.
+func (p *Pipeline) observeLatency(raws []domain.RawEvent, loaded []bool) {
+	now := time.Now()
+	for i, raw := range raws {
+		if !loaded[i] || raw.Timestamp.IsZero() {
+			continue
+		}
+		p.metrics.EndToEndLatency.WithLabelValues(strconv.Itoa(raw.Partition)).Observe(now.Sub(raw.Timestamp).Seconds())
 	}
+}
+
+// rejection is a message that failed to transform and why.
+type rejection struct {
+	raw   domain.RawEvent
+	cause error
+}
+
+// parkedSet records the messages of one batch already written to the
+// dead-letter loader, by partition and offset, so that a retried transaction
+// does not park them again.
+type parkedSet map[parkedKey]struct{}
+
+type parkedKey struct {
+	topic     string
+	partition int
+	offset    int64
+}
 
-	return len(outBatch), true
+func newParkedKey(raw domain.RawEvent) parkedKey {
+	return parkedKey{topic: raw.Topic, partition: raw.Partition, offset: raw.Offset}
+}
+
+// parkRejected dead-letters the messages that failed to transform. Returns
+// false if the pipeline should stop.
+func (p *Pipeline) parkRejected(ctx context.Context, rejected []rejection, parked parkedSet, maxBackoff time.Duration) bool {
+	for _, r := range rejected {
+		if !p.sendToDeadLetter(ctx, r.raw, r.cause, parked, maxBackoff) {
+			return false
+		}
+	}
+	return true
+}
+
+// sendToDeadLetter writes a failed message to the dead-letter loader, retrying
+// with backoff until it succeeds so the offset is never committed for a
+// message that was not parked. A message already in parked is skipped.
+// Returns false if the pipeline should stop. Without a dead-letter loader the
+// message is dropped, as before.
+func (p *Pipeline) sendToDeadLetter(ctx context.Context, raw domain.RawEvent, cause error, parked parkedSet, maxBackoff time.Duration) bool {
+	if p.deadLetter == nil {
+		return true
+	}
+	key := newParkedKey(raw)
+	if _, ok := parked[key]; ok {
+		return true
+	}
+
+	backoff := 200 * time.Millisecond
+	for {
+		err := p.deadLetter.LoadDeadLetter(ctx, raw, cause)
+		if err == nil {
+			p.metrics.DeadLetterMessages.Inc()
+			parked[key] = struct{}{}
+			return true
+		}
+		p.logger.Error("dead-letter write failed", "error", err,
+			"topic", raw.Topic, "partition", raw.Partition, "offset", raw.Offset)
+		p.control.recordError(fmt.Errorf("dead-letter: %w", err))
+		if !p.backoffOrStop(ctx, &backoff, maxBackoff) {
+			return false
+		}
+	}
 }
 
 // backoffOrStop checks for context cancellation, sleeps with the current backoff,
@@ -165,6 +373,8 @@ func (p *Pipeline) backoffOrStop(ctx context.Context, backoff *time.Duration, ma
 	if ctx.Err() != nil {
 		return false
 	}
+	p.control.setBackoff(*backoff)
+	defer p.control.setBackoff(0)
 	if !retry.SleepWithContext(ctx, *backoff) {
 		return false
 	}
@@ -172,6 +382,36 @@ func (p *Pipeline) backoffOrStop(ctx context.Context, backoff *time.Duration, ma
 	return true
 }
 
+// commitOffsets commits the offsets of a processed batch, in one call when the
+// extractor implements BatchCommitter and message by message otherwise.
+// Commit failures are logged; the messages are redelivered after a restart or
+// rebalance.
+func (p *Pipeline) commitOffsets(ctx context.Context, batch []domain.RawEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	start := time.Now()
+	defer func() {
+		p.metrics.CommitDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	if bc, ok := p.extractor.(BatchCommitter); ok {
+		if err := bc.CommitBatch(ctx, batch); err != nil {
+			p.logger.Warn("commit batch failed", "error", err, "batch_size", len(batch))
+			p.control.recordError(fmt.Errorf("commit: %w", err))
+			return
+		}
+		for _, raw := range batch {
+			p.trackCommitted(raw)
+		}
+		return
+	}
+
+	for _, raw := range batch {
+		p.commitOffset(ctx, raw)
+	}
+}
+
 // commitOffset commits the message offset if a commit function is available.
 func (p *Pipeline) commitOffset(ctx context.Context, raw domain.RawEvent) {
 	if raw.Commit == nil {
@@ -180,5 +420,15 @@ func (p *Pipeline) commitOffset(ctx context.Context, raw domain.RawEvent) {
 	if err := raw.Commit(ctx); err != nil {
 		p.logger.Warn("commit offset failed", "error", err,
 			"topic", raw.Topic, "partition", raw.Partition, "offset", raw.Offset)
+		p.control.recordError(fmt.Errorf("commit: %w", err))
+		return
 	}
+	p.trackCommitted(raw)
+}
+
+// trackCommitted records raw's offset as the partition's last committed offset.
+// Batches are in source order, so the last call per partition wins.
+func (p *Pipeline) trackCommitted(raw domain.RawEvent) {
+	p.metrics.CommittedOffset.WithLabelValues(strconv.Itoa(raw.Partition)).Set(float64(raw.Offset))
+	p.control.recordCommitted(raw.Partition, raw.Offset)
 }
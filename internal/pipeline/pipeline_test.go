@@ -1,10 +1,14 @@
 package pipeline_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -13,6 +17,8 @@ import (
 	"github.com/couchcryptid/storm-data-etl/internal/observability"
 	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
 	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -22,10 +28,14 @@ import (
 type mockBatchExtractor struct {
 	batches [][]domain.RawEvent
 	index   atomic.Int64
+	finite  bool // return io.EOF after the last batch instead of blocking
 }
 
 func (m *mockBatchExtractor) ExtractBatch(ctx context.Context, _ int) ([]domain.RawEvent, error) {
 	i := int(m.index.Add(1) - 1)
+	if i >= len(m.batches) && m.finite {
+		return nil, io.EOF
+	}
 	if i >= len(m.batches) {
 		// block until context cancelled to simulate waiting for messages
 		<-ctx.Done()
@@ -88,6 +98,32 @@ func TestPipeline_Run_HappyPath(t *testing.T) {
 	assert.NoError(t, p.CheckReadiness(context.Background()))
 }
 
+func TestPipeline_Run_ObservesEndToEndLatency(t *testing.T) {
+	loadedRaw := makeRawEvent(t, "evt-1", "hail")
+	loadedRaw.Partition = 3
+	loadedRaw.Timestamp = time.Now().Add(-2 * time.Second)
+	untimed := makeRawEvent(t, "evt-2", "hail")
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{loadedRaw, untimed}}}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), metrics, testBatchSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.EndToEndLatency), "only the timestamped message is observed")
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(metrics.EndToEndLatency)
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	sample := families[0].GetMetric()[0]
+	assert.Equal(t, "3", sample.GetLabel()[0].GetValue())
+	assert.Equal(t, uint64(1), sample.GetHistogram().GetSampleCount())
+	assert.GreaterOrEqual(t, sample.GetHistogram().GetSampleSum(), 2.0)
+}
+
 func TestPipeline_Run_BatchMultipleMessages(t *testing.T) {
 	raw1 := makeRawEvent(t, "evt-1", "hail")
 	raw2 := makeRawEvent(t, "evt-2", "tornado")
@@ -124,6 +160,32 @@ func TestPipeline_Run_ContextCancellation(t *testing.T) {
 	assert.Empty(t, loader.batches)
 }
 
+func TestPipeline_Run_SourceExhausted(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			var batches [][]domain.RawEvent
+			for i := range 3 {
+				raw := makeRawEvent(t, fmt.Sprintf("evt-%d", i), "hail")
+				raw.Offset = int64(i)
+				batches = append(batches, []domain.RawEvent{raw})
+			}
+			ext := &mockBatchExtractor{batches: batches, finite: true}
+			loader := &syncBatchLoader{}
+			metrics := newTestMetrics()
+
+			p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+				pipeline.WithWorkers(workers))
+
+			// Run returns on its own once every batch is loaded.
+			require.NoError(t, p.Run(context.Background()))
+			assert.Len(t, loader.events, 3)
+			assert.Equal(t, pipeline.StateStopped, p.Status().State)
+			assert.Empty(t, p.Status().LastError)
+			assert.NoError(t, p.CheckReadiness(context.Background()))
+		})
+	}
+}
+
 func TestPipeline_Run_TransformError(t *testing.T) {
 	raw := makeRawEvent(t, "evt-2", "hail")
 
@@ -140,7 +202,7 @@ func TestPipeline_Run_TransformError(t *testing.T) {
 	err := p.Run(ctx)
 	require.NoError(t, err)
 	assert.Empty(t, loader.batches)
-	assert.Error(t, p.CheckReadiness(context.Background()))
+	assert.NoError(t, p.CheckReadiness(context.Background()), "a batch of dropped messages still counts as progress")
 }
 
 func TestPipeline_Run_PartialTransformFailure(t *testing.T) {
@@ -271,19 +333,27 @@ func (m *failingBatchLoader) LoadBatch(_ context.Context, events []domain.StormE
 func TestPipeline_Run_LoadError_Backoff(t *testing.T) {
 	raw := makeRawEvent(t, "evt-backoff", "hail")
 
-	ext := &retryBatchExtractor{event: raw, max: 2}
+	var commitCount atomic.Int64
+	raw.Commit = func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+
+	ext := &retryBatchExtractor{event: raw, max: 1}
 	transformer := &mockTransformer{}
-	loader := &failingBatchLoader{failUntil: 1}
+	loader := &failingBatchLoader{failUntil: 3}
 	metrics := newTestMetrics()
 
-	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
+	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize, pipeline.WithLoadAttempts(2))
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	err := p.Run(ctx)
 	require.NoError(t, err)
-	assert.Len(t, loader.batches, 1, "second attempt should succeed after backoff")
+	assert.Len(t, loader.batches, 1, "batch is retried in-process until it loads")
+	assert.Equal(t, int64(4), loader.callCount.Load(), "whole-batch failures do not use up load attempts")
+	assert.Equal(t, int64(1), commitCount.Load())
 }
 
 func TestPipeline_Run_CommitError(t *testing.T) {
@@ -308,6 +378,545 @@ func TestPipeline_Run_CommitError(t *testing.T) {
 	assert.Len(t, loader.batches[0], 1)
 }
 
+type committingBatchExtractor struct {
+	mockBatchExtractor
+	mu      sync.Mutex
+	commits [][]domain.RawEvent
+	err     error
+}
+
+func (m *committingBatchExtractor) CommitBatch(_ context.Context, events []domain.RawEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commits = append(m.commits, events)
+	return m.err
+}
+
+func TestPipeline_Run_PrefersBatchCommitter(t *testing.T) {
+	var perMessage atomic.Int64
+	commit := func(_ context.Context) error {
+		perMessage.Add(1)
+		return nil
+	}
+	raw1 := makeRawEvent(t, "evt-1", "hail")
+	raw1.Commit = commit
+	raw1.Offset = 7
+	raw2 := makeRawEvent(t, "evt-2", "wind")
+	raw2.Commit = commit
+	raw2.Offset = 8
+
+	ext := &committingBatchExtractor{}
+	ext.batches = [][]domain.RawEvent{{raw1, raw2}}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), metrics, testBatchSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, ext.commits, 1)
+	assert.Len(t, ext.commits[0], 2)
+	assert.Equal(t, int64(0), perMessage.Load(), "per-message callbacks are not used")
+	assert.Equal(t, float64(8), testutil.ToFloat64(metrics.CommittedOffset.WithLabelValues("0")))
+}
+
+func TestPipeline_Run_BatchCommitError(t *testing.T) {
+	ext := &committingBatchExtractor{err: errors.New("coordinator unavailable")}
+	ext.batches = [][]domain.RawEvent{{makeRawEvent(t, "evt-1", "hail")}}
+	loader := &mockBatchLoader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.batches, 1)
+	require.Len(t, ext.commits, 1)
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.CommittedOffset), "failed commit is not tracked")
+}
+
+type mockDeadLetter struct {
+	mu        sync.Mutex
+	failUntil int
+	calls     int
+	raws      []domain.RawEvent
+	causes    []error
+}
+
+func (m *mockDeadLetter) LoadDeadLetter(_ context.Context, raw domain.RawEvent, cause error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.calls <= m.failUntil {
+		return errors.New("dlq unavailable")
+	}
+	m.raws = append(m.raws, raw)
+	m.causes = append(m.causes, cause)
+	return nil
+}
+
+func TestPipeline_Run_DeadLettersTransformFailures(t *testing.T) {
+	var commits []string
+	var mu sync.Mutex
+	commitAs := func(id string) func(context.Context) error {
+		return func(_ context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			commits = append(commits, id)
+			return nil
+		}
+	}
+
+	raw1 := makeRawEvent(t, "evt-1", "hail")
+	raw1.Commit = commitAs("evt-1")
+	raw2 := makeRawEvent(t, "evt-2", "tornado")
+	raw2.Commit = commitAs("evt-2")
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw1, raw2}}}
+	transformer := &partialFailTransformer{failOn: 1}
+	loader := &mockBatchLoader{}
+	dlq := &mockDeadLetter{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize, pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, dlq.raws, 1)
+	assert.Equal(t, raw1.Value, dlq.raws[0].Value)
+	assert.EqualError(t, dlq.causes[0], "transform failure")
+	require.Len(t, loader.batches, 1)
+	assert.Equal(t, "evt-2", loader.batches[0][0].ID)
+	assert.Equal(t, []string{"evt-1", "evt-2"}, commits, "offsets commit in source order after load")
+}
+
+func TestPipeline_Run_DeadLetterRetriesBeforeCommit(t *testing.T) {
+	var commitCount atomic.Int64
+	raw := makeRawEvent(t, "evt-dlq", "hail")
+	raw.Commit = func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}}}
+	transformer := &mockTransformer{err: errors.New("bad data")}
+	dlq := &mockDeadLetter{failUntil: 2}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, transformer, &mockBatchLoader{}, slog.Default(), metrics, testBatchSize, pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	assert.Equal(t, 3, dlq.calls)
+	assert.Len(t, dlq.raws, 1)
+	assert.Equal(t, int64(1), commitCount.Load())
+}
+
+func TestPipeline_Run_DeadLettersOnlyAfterLoad(t *testing.T) {
+	tests := []struct {
+		name      string
+		failUntil int
+		parked    int
+		loaded    int
+	}{
+		{name: "load fails until shutdown", failUntil: 1 << 30, parked: 0, loaded: 0},
+		{name: "load succeeds on retry", failUntil: 2, parked: 1, loaded: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commitCount atomic.Int64
+			commit := func(context.Context) error {
+				commitCount.Add(1)
+				return nil
+			}
+			bad := makeRawEvent(t, "evt-1", "hail")
+			bad.Commit = commit
+			good := makeRawEvent(t, "evt-2", "wind")
+			good.Offset = 1
+			good.Commit = commit
+
+			ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{bad, good}}}
+			loader := &failingBatchLoader{failUntil: tt.failUntil}
+			dlq := &mockDeadLetter{}
+			p := pipeline.New(ext, &partialFailTransformer{failOn: 1}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+				pipeline.WithDeadLetter(dlq))
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			require.NoError(t, p.Run(ctx))
+
+			assert.Len(t, dlq.raws, tt.parked, "nothing is parked for a batch that will be redelivered")
+			assert.Len(t, loader.batches, tt.loaded)
+			assert.Equal(t, int64(2*tt.loaded), commitCount.Load())
+		})
+	}
+}
+
+func TestPipeline_Run_DeadLetterFailureDoesNotCommit(t *testing.T) {
+	var commitCount atomic.Int64
+	raw := makeRawEvent(t, "evt-dlq", "hail")
+	raw.Commit = func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}}}
+	transformer := &mockTransformer{err: errors.New("bad data")}
+	dlq := &mockDeadLetter{failUntil: 1000}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, transformer, &mockBatchLoader{}, slog.Default(), metrics, testBatchSize, pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	assert.Empty(t, dlq.raws)
+	assert.Equal(t, int64(0), commitCount.Load(), "offset must not be committed until the DLQ write succeeds")
+}
+
+// partialBatchLoader fails the events whose IDs map to an error, failing each
+// at most failures[id] times (0 means always), and records what was written.
+type partialBatchLoader struct {
+	mu       sync.Mutex
+	errs     map[string]error
+	failures map[string]int
+	calls    map[string]int
+	loaded   []string
+}
+
+func (m *partialBatchLoader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.calls == nil {
+		m.calls = make(map[string]int)
+	}
+	errs := make([]error, len(events))
+	failed := false
+	for i, ev := range events {
+		m.calls[ev.ID]++
+		if err, ok := m.errs[ev.ID]; ok && (m.failures[ev.ID] == 0 || m.calls[ev.ID] <= m.failures[ev.ID]) {
+			errs[i] = err
+			failed = true
+			continue
+		}
+		m.loaded = append(m.loaded, ev.ID)
+	}
+	if failed {
+		return &pipeline.BatchLoadError{Errs: errs}
+	}
+	return nil
+}
+
+func TestPipeline_Run_PartialLoadFailureRetriesFailedOnly(t *testing.T) {
+	var commitCount atomic.Int64
+	commit := func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+	raw1 := makeRawEvent(t, "evt-1", "hail")
+	raw1.Commit = commit
+	raw2 := makeRawEvent(t, "evt-2", "wind")
+	raw2.Commit = commit
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw1, raw2}}}
+	loader := &partialBatchLoader{
+		errs:     map[string]error{"evt-2": errors.New("leader not available")},
+		failures: map[string]int{"evt-2": 1},
+	}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	assert.Equal(t, []string{"evt-1", "evt-2"}, loader.loaded)
+	assert.Equal(t, 1, loader.calls["evt-1"], "successful event is not rewritten")
+	assert.Equal(t, 2, loader.calls["evt-2"])
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.MessagesProduced))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.LoadEventFailures.WithLabelValues("retried")))
+	assert.Equal(t, int64(2), commitCount.Load())
+}
+
+func TestPipeline_Run_PermanentLoadFailureDeadLetters(t *testing.T) {
+	raw1 := makeRawEvent(t, "evt-1", "hail")
+	raw2 := makeRawEvent(t, "evt-big", "wind")
+	var commitCount atomic.Int64
+	raw2.Commit = func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw1, raw2}}}
+	loader := &partialBatchLoader{
+		errs: map[string]error{"evt-big": pipeline.Permanent(errors.New("message too large"))},
+	}
+	dlq := &mockDeadLetter{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	assert.Equal(t, 1, loader.calls["evt-big"], "permanent failures are not retried")
+	require.Len(t, dlq.raws, 1)
+	assert.Equal(t, raw2.Value, dlq.raws[0].Value)
+	assert.EqualError(t, dlq.causes[0], "load: message too large")
+	assert.Equal(t, int64(1), commitCount.Load(), "offset commits once the event is parked")
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.LoadEventFailures.WithLabelValues("dead_lettered")))
+}
+
+func TestPipeline_Run_LoadAttemptsExhausted(t *testing.T) {
+	raw1 := makeRawEvent(t, "evt-1", "hail")
+	raw2 := makeRawEvent(t, "evt-stuck", "wind")
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw1, raw2}}}
+	loader := &partialBatchLoader{errs: map[string]error{"evt-stuck": errors.New("record rejected")}}
+	dlq := &mockDeadLetter{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDeadLetter(dlq), pipeline.WithLoadAttempts(3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	assert.Equal(t, 3, loader.calls["evt-stuck"])
+	require.Len(t, dlq.raws, 1)
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.LoadEventFailures.WithLabelValues("retried")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.LoadEventFailures.WithLabelValues("dead_lettered")))
+}
+
+func TestPipeline_Run_LoadFailureWithoutDeadLetterDrops(t *testing.T) {
+	raw1 := makeRawEvent(t, "evt-1", "hail")
+	raw2 := makeRawEvent(t, "evt-big", "wind")
+	var commitCount atomic.Int64
+	raw2.Commit = func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw1, raw2}}}
+	loader := &partialBatchLoader{
+		errs: map[string]error{"evt-big": pipeline.Permanent(errors.New("message too large"))},
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, logger, metrics, testBatchSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	assert.Equal(t, int64(1), commitCount.Load(), "offset commits so the partition moves on")
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.LoadEventFailures.WithLabelValues("dropped")))
+	assert.Zero(t, testutil.ToFloat64(metrics.LoadEventFailures.WithLabelValues("dead_lettered")))
+	assert.Zero(t, testutil.ToFloat64(metrics.DeadLetterMessages))
+	assert.Contains(t, buf.String(), `level=ERROR msg="load failed, dropping event"`)
+	assert.NotContains(t, buf.String(), "dead-lettering")
+}
+
+type mockSeenStore struct {
+	mu      sync.Mutex
+	entries map[string]pipeline.SeenEntry
+}
+
+func newMockSeenStore() *mockSeenStore {
+	return &mockSeenStore{entries: make(map[string]pipeline.SeenEntry)}
+}
+
+func (m *mockSeenStore) Get(_ context.Context, id string) (pipeline.SeenEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	return e, ok, nil
+}
+
+func (m *mockSeenStore) Put(_ context.Context, id string, entry pipeline.SeenEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[id] = entry
+	return nil
+}
+
+func TestPipeline_Run_DedupDrop(t *testing.T) {
+	var commitCount atomic.Int64
+	commit := func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+	first := makeRawEvent(t, "evt-1", "hail")
+	first.Commit = commit
+	republished := first
+	fresh := makeRawEvent(t, "evt-2", "hail")
+	fresh.Commit = commit
+	repeat := fresh
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{first}, {republished, fresh, repeat}}}
+	loader := &mockBatchLoader{}
+	store := newMockSeenStore()
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDedup(store, pipeline.DedupDrop))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.batches, 2)
+	require.Len(t, loader.batches[1], 1)
+	assert.Equal(t, "evt-2", loader.batches[1][0].ID)
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.Duplicates))
+	assert.Equal(t, int64(4), commitCount.Load(), "dropped duplicates still commit")
+	_, ok, _ := store.Get(context.Background(), "evt-2")
+	assert.True(t, ok, "loaded ID is recorded")
+}
+
+func TestPipeline_Run_DedupTag(t *testing.T) {
+	raw := makeRawEvent(t, "evt-1", "hail")
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}, {raw}}}
+	loader := &mockBatchLoader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDedup(newMockSeenStore(), pipeline.DedupTag))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.batches, 2)
+	assert.False(t, loader.batches[0][0].Duplicate)
+	assert.True(t, loader.batches[1][0].Duplicate, "repeat in a later batch is tagged")
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Duplicates))
+}
+
+func TestPipeline_Run_DedupRecordsOnlyAfterLoad(t *testing.T) {
+	raw := makeRawEvent(t, "evt-retry", "hail")
+
+	ext := &retryBatchExtractor{event: raw, max: 1}
+	loader := &failingBatchLoader{failUntil: 1}
+	store := newMockSeenStore()
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDedup(store, pipeline.DedupDrop))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.batches, 1, "retry after a failed load is not a duplicate")
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Duplicates))
+	_, ok, _ := store.Get(context.Background(), "evt-retry")
+	assert.True(t, ok)
+}
+
+func TestPipeline_Run_DedupRevision(t *testing.T) {
+	original := makeRawEvent(t, "evt-1", "hail")
+	var event domain.StormEvent
+	require.NoError(t, json.Unmarshal(original.Value, &event))
+	event.Comments = "Corrected location. (OUN)"
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	revised := domain.RawEvent{Key: original.Key, Value: data}
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{
+		{original},
+		{revised, revised},
+		{original},
+	}}
+	loader := &mockBatchLoader{}
+	store := newMockSeenStore()
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDedup(store, pipeline.DedupDrop))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.batches, 3)
+	assert.Equal(t, 0, loader.batches[0][0].Revision)
+	require.Len(t, loader.batches[1], 1, "repeat of the revision in the same batch is dropped")
+	assert.Equal(t, 1, loader.batches[1][0].Revision)
+	assert.Equal(t, "Corrected location. (OUN)", loader.batches[1][0].Comments)
+	assert.Equal(t, 2, loader.batches[2][0].Revision, "reverting the content is another revision")
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.Revisions))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Duplicates))
+
+	entry, ok, _ := store.Get(context.Background(), "evt-1")
+	require.True(t, ok)
+	assert.Equal(t, 2, entry.Revision)
+}
+
+func TestPipeline_Run_DedupOffStillNumbersRevisions(t *testing.T) {
+	original := makeRawEvent(t, "evt-1", "hail")
+	var event domain.StormEvent
+	require.NoError(t, json.Unmarshal(original.Value, &event))
+	event.Comments = "Corrected location. (OUN)"
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	revised := domain.RawEvent{Key: original.Key, Value: data}
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{original}, {original}, {revised}}}
+	loader := &mockBatchLoader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDedup(newMockSeenStore(), pipeline.DedupOff))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.batches, 3, "repeats are forwarded")
+	assert.False(t, loader.batches[1][0].Duplicate, "repeats are not tagged")
+	assert.Equal(t, 0, loader.batches[1][0].Revision)
+	assert.Equal(t, 1, loader.batches[2][0].Revision, "changed content is a revision")
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Revisions))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Duplicates))
+}
+
+func TestPipeline_Run_DedupRedeliveryWithNewTimestamp(t *testing.T) {
+	payload, err := json.Marshal(domain.RawCSVRecord{Time: "1510", Size: "175", State: "TX", Lat: "30.32", Lon: "-97.80", EventType: "hail"})
+	require.NoError(t, err)
+	first := domain.RawEvent{Value: payload, Timestamp: time.Date(2024, 4, 26, 23, 0, 0, 0, time.UTC)}
+	redelivered := domain.RawEvent{Value: payload, Timestamp: time.Date(2024, 4, 28, 6, 0, 0, 0, time.UTC)}
+
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{first}, {redelivered}}}
+	loader := &mockBatchLoader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, pipeline.NewTransformer(slog.Default()), loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDedup(newMockSeenStore(), pipeline.DedupDrop))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.batches, 1, "the redelivery is dropped as a duplicate")
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Duplicates))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Revisions))
+}
+
 // --- domain tests (unchanged) ---
 
 func TestStormTransformer_Transform(t *testing.T) {
@@ -320,6 +929,96 @@ func TestStormTransformer_Transform(t *testing.T) {
 	assert.Equal(t, "tornado", event.EventType)
 }
 
+func TestStormTransformer_ValidationModes(t *testing.T) {
+	raw := makeRawCSVEvent(t, "hail", "125")
+	var row map[string]string
+	require.NoError(t, json.Unmarshal(raw.Value, &row))
+	row["Lat"] = "0"
+	row["Lon"] = "0"
+	row["Size"] = "big"
+	data, err := json.Marshal(row)
+	require.NoError(t, err)
+	raw.Value = data
+
+	t.Run("lenient passes invalid events through", func(t *testing.T) {
+		event, err := pipeline.NewTransformer(slog.Default()).Transform(context.Background(), raw)
+		require.NoError(t, err)
+		assert.Zero(t, event.Measurement.Magnitude)
+	})
+
+	t.Run("warn logs and emits", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, nil))
+		transformer := pipeline.NewTransformer(logger, pipeline.WithValidationMode(pipeline.ValidationWarn))
+
+		event, err := transformer.Transform(context.Background(), raw)
+		require.NoError(t, err)
+		assert.NotEmpty(t, event.ID)
+		assert.Contains(t, buf.String(), "storm event failed validation")
+		assert.Contains(t, buf.String(), "measurement.magnitude")
+	})
+
+	t.Run("strict rejects with field-level reasons", func(t *testing.T) {
+		transformer := pipeline.NewTransformer(slog.Default(), pipeline.WithValidationMode(pipeline.ValidationStrict))
+
+		_, err := transformer.Transform(context.Background(), raw)
+		require.Error(t, err)
+
+		var verr domain.ValidationErrors
+		require.ErrorAs(t, err, &verr)
+		fields := make([]string, len(verr))
+		for i, e := range verr {
+			fields[i] = e.Field
+		}
+		assert.Equal(t, []string{"measurement.magnitude", "geo"}, fields)
+	})
+
+	t.Run("strict accepts valid events", func(t *testing.T) {
+		transformer := pipeline.NewTransformer(slog.Default(), pipeline.WithValidationMode(pipeline.ValidationStrict))
+		_, err := transformer.Transform(context.Background(), makeRawCSVEvent(t, "tornado", "EF3"))
+		require.NoError(t, err)
+	})
+}
+
+type failingEnricher struct{}
+
+func (failingEnricher) Name() string { return "lookup" }
+
+func (failingEnricher) Enrich(context.Context, *domain.StormEvent) error {
+	return errors.New("lookup unavailable")
+}
+
+func TestStormTransformer_EnricherChain(t *testing.T) {
+	raw := makeRawCSVEvent(t, "hail", "175")
+
+	t.Run("runs only configured steps in order", func(t *testing.T) {
+		chain, err := domain.Enrichers([]string{"event_type", "source_office"}, domain.EnricherDeps{})
+		require.NoError(t, err)
+		transformer := pipeline.NewTransformer(slog.Default(), pipeline.WithEnrichers(chain))
+
+		event, err := transformer.Transform(context.Background(), raw)
+		require.NoError(t, err)
+		assert.Equal(t, "SJT", event.SourceOffice)
+		assert.Empty(t, event.Measurement.Unit)
+		assert.InDelta(t, 175.0, event.Measurement.Magnitude, 0.0001)
+		assert.True(t, event.ProcessedAt.IsZero())
+	})
+
+	t.Run("records per-step metrics", func(t *testing.T) {
+		metrics := observability.NewMetricsForTesting()
+		chain := append(domain.DefaultEnrichers(), failingEnricher{})
+		transformer := pipeline.NewTransformer(slog.Default(), pipeline.WithEnrichers(chain), pipeline.WithTransformMetrics(metrics))
+
+		_, err := transformer.Transform(context.Background(), raw)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "enrich lookup")
+
+		assert.Equal(t, 1, testutil.CollectAndCount(metrics.EnrichmentStepErrors))
+		assert.InDelta(t, 1.0, testutil.ToFloat64(metrics.EnrichmentStepErrors.WithLabelValues("lookup")), 0)
+		assert.Equal(t, len(chain), testutil.CollectAndCount(metrics.EnrichmentStepDuration))
+	})
+}
+
 func TestDomain_ParseRawEvent(t *testing.T) {
 	raw := makeRawCSVEvent(t, "wind", "65")
 	event, err := domain.ParseRawEvent(raw)
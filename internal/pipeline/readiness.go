@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ConnectivityChecker is optionally implemented by a BatchExtractor that can
+// check it is able to reach its source.
+type ConnectivityChecker interface {
+	CheckConnectivity(ctx context.Context) error
+}
+
+// LagReporter is optionally implemented by a BatchExtractor that knows how
+// many messages it is behind the source. A negative lag means unknown.
+type LagReporter interface {
+	Lag() int64
+}
+
+// ReadinessCheck is the outcome of one readiness check.
+type ReadinessCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// defaultStaleAfter is how long a batch may stay in flight before the
+// pipeline reports itself stuck.
+const defaultStaleAfter = 5 * time.Minute
+
+// WithReadiness sets the readiness thresholds: staleAfter is how long
+// in-flight batches may go without one loading and committing, and maxLag is the consumer
+// lag above which the pipeline is not ready (0 disables the lag limit).
+func WithReadiness(staleAfter time.Duration, maxLag int64) Option {
+	return func(p *Pipeline) {
+		if staleAfter > 0 {
+			p.health.staleAfter = staleAfter
+		}
+		p.health.maxLag = maxLag
+	}
+}
+
+// health tracks the pipeline's recent state for readiness checks.
+type health struct {
+	mu           sync.Mutex
+	staleAfter   time.Duration
+	maxLag       int64
+	extractErr   error
+	inFlight     int
+	lastProgress time.Time
+}
+
+func (h *health) recordExtract(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.extractErr = err
+}
+
+// startBatch marks a batch as in flight. The stall clock starts when the
+// pipeline goes from idle to busy. Every startBatch must be paired with a
+// finishBatch.
+func (h *health) startBatch() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.inFlight == 0 {
+		h.lastProgress = time.Now()
+	}
+	h.inFlight++
+}
+
+// finishBatch marks a batch as no longer in flight. Only a batch that was
+// loaded and committed (ok) counts as progress; one abandoned on shutdown
+// does not reset the stall clock.
+func (h *health) finishBatch(ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight--
+	if ok {
+		h.lastProgress = time.Now()
+	}
+}
+
+// Readiness runs every readiness check and returns their outcomes.
+func (p *Pipeline) Readiness(ctx context.Context) []ReadinessCheck {
+	p.health.mu.Lock()
+	extractErr := p.health.extractErr
+	inFlight := p.health.inFlight
+	sinceProgress := time.Since(p.health.lastProgress)
+	p.health.mu.Unlock()
+
+	checks := make([]ReadinessCheck, 0, 4)
+
+	extractor := ReadinessCheck{Name: "extractor", OK: true}
+	if cc, ok := p.extractor.(ConnectivityChecker); ok {
+		if err := cc.CheckConnectivity(ctx); err != nil {
+			extractor = ReadinessCheck{Name: "extractor", Reason: fmt.Sprintf("source unreachable: %v", err)}
+		}
+	}
+	if extractor.OK && extractErr != nil {
+		extractor = ReadinessCheck{Name: "extractor", Reason: fmt.Sprintf("last extract failed: %v", extractErr)}
+	}
+	checks = append(checks, extractor)
+
+	progress := ReadinessCheck{Name: "progress", OK: true}
+	if inFlight > 0 && sinceProgress > p.health.staleAfter {
+		progress = ReadinessCheck{Name: "progress", Reason: fmt.Sprintf(
+			"no batch completed in %s with %d in flight", sinceProgress.Truncate(time.Second), inFlight)}
+	}
+	checks = append(checks, progress)
+
+	if lr, ok := p.extractor.(LagReporter); ok {
+		lag := ReadinessCheck{Name: "consumer_lag", OK: true}
+		if n := lr.Lag(); p.health.maxLag > 0 && n > p.health.maxLag {
+			lag = ReadinessCheck{Name: "consumer_lag", Reason: fmt.Sprintf("lag %d exceeds %d", n, p.health.maxLag)}
+		}
+		checks = append(checks, lag)
+	}
+
+	if p.breaker != nil {
+		breaker := ReadinessCheck{Name: "load_breaker", OK: true}
+		if state := p.breaker.current(); state != BreakerClosed {
+			breaker = ReadinessCheck{Name: "load_breaker", Reason: fmt.Sprintf("load circuit breaker is %s", state)}
+		}
+		checks = append(checks, breaker)
+	}
+
+	return checks
+}
+
+// CheckReadiness returns nil if every readiness check passes, or an error
+// joining the reasons of those that fail.
+func (p *Pipeline) CheckReadiness(ctx context.Context) error {
+	var errs []error
+	for _, c := range p.Readiness(ctx) {
+		if !c.OK {
+			errs = append(errs, errors.New(c.Reason))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,178 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probedExtractor is a chanExtractor that also reports connectivity and lag.
+type probedExtractor struct {
+	chanExtractor
+	connErr error
+	lag     int64
+}
+
+func (m *probedExtractor) CheckConnectivity(context.Context) error { return m.connErr }
+
+func (m *probedExtractor) Lag() int64 { return m.lag }
+
+// blockingLoader holds every load until release is closed.
+type blockingLoader struct {
+	release chan struct{}
+}
+
+func (m *blockingLoader) LoadBatch(ctx context.Context, _ []domain.StormEvent) error {
+	select {
+	case <-m.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func checkByName(checks []pipeline.ReadinessCheck, name string) (pipeline.ReadinessCheck, bool) {
+	for _, c := range checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return pipeline.ReadinessCheck{}, false
+}
+
+func TestPipeline_Readiness_IdleTopicIsReady(t *testing.T) {
+	ext := &probedExtractor{chanExtractor: make(chanExtractor)}
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithReadiness(10*time.Millisecond, 100))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+
+	// Nothing arrives, which is normal for a quiet topic.
+	time.Sleep(50 * time.Millisecond)
+
+	checks := p.Readiness(context.Background())
+	for _, c := range checks {
+		assert.True(t, c.OK, "%s: %s", c.Name, c.Reason)
+	}
+	_, ok := checkByName(checks, "consumer_lag")
+	assert.True(t, ok)
+	assert.NoError(t, p.CheckReadiness(context.Background()))
+}
+
+func TestPipeline_Readiness_SourceUnreachable(t *testing.T) {
+	ext := &probedExtractor{chanExtractor: make(chanExtractor), connErr: errors.New("dial tcp: connection refused")}
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize)
+
+	c, ok := checkByName(p.Readiness(context.Background()), "extractor")
+	require.True(t, ok)
+	assert.False(t, c.OK)
+	assert.Equal(t, "source unreachable: dial tcp: connection refused", c.Reason)
+	assert.Error(t, p.CheckReadiness(context.Background()))
+}
+
+func TestPipeline_Readiness_StalledBatch(t *testing.T) {
+	ext := &probedExtractor{chanExtractor: make(chanExtractor, 1)}
+	ext.chanExtractor <- []domain.RawEvent{makeRawEvent(t, "evt-1", "hail")}
+	loader := &blockingLoader{release: make(chan struct{})}
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithReadiness(50*time.Millisecond, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		c, _ := checkByName(p.Readiness(context.Background()), "progress")
+		return !c.OK
+	}, time.Second, 5*time.Millisecond)
+
+	// Once the load completes the pipeline is making progress again.
+	close(loader.release)
+	require.Eventually(t, func() bool {
+		return p.CheckReadiness(context.Background()) == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestPipeline_Readiness_FailedLoadsAreNotProgress(t *testing.T) {
+	ext := &probedExtractor{chanExtractor: make(chanExtractor, 1)}
+	ext.chanExtractor <- []domain.RawEvent{makeRawEvent(t, "evt-1", "hail")}
+	loader := &switchableLoader{}
+	loader.failing.Store(true)
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithReadiness(50*time.Millisecond, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+
+	// The loader keeps being retried, but no load succeeds.
+	require.Eventually(t, func() bool {
+		c, _ := checkByName(p.Readiness(context.Background()), "progress")
+		return !c.OK && loader.calls.Load() > 1
+	}, 2*time.Second, 5*time.Millisecond)
+
+	loader.failing.Store(false)
+	require.Eventually(t, func() bool {
+		return p.CheckReadiness(context.Background()) == nil
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestPipeline_Readiness_AbandonedBatchesLeaveNothingInFlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		batches int
+	}{
+		// One batch loading.
+		{name: "single worker", workers: 1, batches: 1},
+		// One batch loading, one queued, and one held by the dispatcher.
+		{name: "partition workers", workers: 2, batches: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := make(chanExtractor, tt.batches)
+			for i := range tt.batches {
+				ext <- []domain.RawEvent{makeRawEvent(t, fmt.Sprintf("evt-%d", i), "hail")}
+			}
+			p := pipeline.New(ext, &mockTransformer{}, &blockingLoader{release: make(chan struct{})}, slog.Default(),
+				newTestMetrics(), testBatchSize, pipeline.WithWorkers(tt.workers), pipeline.WithReadiness(time.Minute, 0))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- p.Run(ctx) }()
+
+			require.Eventually(t, func() bool {
+				return p.Status().InFlight == tt.batches
+			}, time.Second, 5*time.Millisecond)
+			cancel()
+			require.NoError(t, <-done)
+
+			assert.Zero(t, p.Status().InFlight, "every started batch is finished")
+			assert.NoError(t, p.CheckReadiness(context.Background()))
+		})
+	}
+}
+
+func TestPipeline_Readiness_LagOverLimit(t *testing.T) {
+	ext := &probedExtractor{chanExtractor: make(chanExtractor), lag: 5000}
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithReadiness(time.Minute, 1000))
+
+	c, ok := checkByName(p.Readiness(context.Background()), "consumer_lag")
+	require.True(t, ok)
+	assert.False(t, c.OK)
+	assert.Equal(t, "lag 5000 exceeds 1000", c.Reason)
+
+	ext.lag = 10
+	assert.NoError(t, p.CheckReadiness(context.Background()))
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/couchcryptid/storm-data-etl/internal/pipeline"
+
+// WithTracerProvider sets the provider of the pipeline's spans. Defaults to
+// the global provider, which is a no-op unless tracing is configured.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(p *Pipeline) {
+		p.tracer = tp.Tracer(tracerName)
+	}
+}
+
+func defaultTracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// startBatchSpan starts the span for one batch, backdated to when its extract
+// began, and records the extract as its first child. Spans are only created
+// for non-empty batches, so idle polls do not produce traces. Each message
+// that arrived with a trace context is linked to the batch.
+func (p *Pipeline) startBatchSpan(ctx context.Context, batch []domain.RawEvent, started, extracted time.Time) (context.Context, trace.Span) {
+	var links []trace.Link
+	for _, raw := range batch {
+		if raw.TraceContext.IsValid() {
+			links = append(links, trace.Link{SpanContext: raw.TraceContext})
+		}
+	}
+	ctx, span := p.tracer.Start(ctx, "pipeline.batch",
+		trace.WithTimestamp(started),
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(batch))),
+	)
+	_, extract := p.tracer.Start(ctx, "pipeline.extract", trace.WithTimestamp(started))
+	extract.End(trace.WithTimestamp(extracted))
+	return ctx, span
+}
+
+// endBatchSpan ends a batch span, marking it failed if the pipeline stopped
+// before the batch was committed.
+func endBatchSpan(span trace.Span, loaded int, ok bool) {
+	span.SetAttributes(attribute.Int("storm_etl.loaded", loaded))
+	if !ok {
+		span.SetStatus(codes.Error, "pipeline stopped before the batch was committed")
+	}
+	span.End()
+}
+
+// startEventSpan starts the span for transforming one message. A message that
+// arrived with a trace context continues that trace, linked to the batch, so a
+// report can be followed from the producer to the sink; other messages get a
+// child of the current stage span.
+func (p *Pipeline) startEventSpan(ctx context.Context, raw domain.RawEvent) (context.Context, trace.Span) {
+	attrs := trace.WithAttributes(
+		attribute.Int("messaging.kafka.partition", raw.Partition),
+		attribute.Int64("messaging.kafka.offset", raw.Offset),
+	)
+	if !raw.TraceContext.IsValid() {
+		return p.tracer.Start(ctx, "pipeline.event", attrs)
+	}
+	parent := trace.ContextWithRemoteSpanContext(ctx, raw.TraceContext)
+	return p.tracer.Start(parent, "pipeline.event", attrs,
+		trace.WithLinks(trace.LinkFromContext(ctx)))
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,71 @@
+package pipeline_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spansByName(spans []sdktrace.ReadOnlySpan) map[string][]sdktrace.ReadOnlySpan {
+	byName := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, s := range spans {
+		byName[s.Name()] = append(byName[s.Name()], s)
+	}
+	return byName
+}
+
+func TestPipeline_Run_Tracing(t *testing.T) {
+	upstream := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	traced := makeRawEvent(t, "evt-1", "hail")
+	traced.TraceContext = upstream
+	untraced := makeRawEvent(t, "evt-2", "hail")
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{traced, untraced}}}
+	loader := &mockBatchLoader{}
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithTracerProvider(tp))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	byName := spansByName(recorder.Ended())
+	require.Len(t, byName["pipeline.batch"], 1, "idle polls do not produce batch spans")
+	batch := byName["pipeline.batch"][0]
+	require.Len(t, batch.Links(), 1)
+	assert.Equal(t, upstream, batch.Links()[0].SpanContext)
+
+	for _, stage := range []string{"pipeline.extract", "pipeline.transform", "pipeline.load", "pipeline.commit"} {
+		require.Len(t, byName[stage], 1, stage)
+		assert.Equal(t, batch.SpanContext().SpanID(), byName[stage][0].Parent().SpanID(), stage)
+	}
+
+	// The traced message continues its upstream trace; the other is a child
+	// of the transform stage.
+	events := byName["pipeline.event"]
+	require.Len(t, events, 2)
+	assert.Equal(t, upstream.TraceID(), events[0].SpanContext().TraceID())
+	assert.Equal(t, upstream.SpanID(), events[0].Parent().SpanID())
+	assert.Equal(t, byName["pipeline.transform"][0].SpanContext().SpanID(), events[1].Parent().SpanID())
+
+	// Loaded events carry their event span so the sink can propagate it.
+	require.Len(t, loader.batches, 1)
+	assert.Equal(t, events[0].SpanContext(), loader.batches[0][0].TraceContext)
+	assert.Equal(t, events[1].SpanContext(), loader.batches[0][1].TraceContext)
+}
@@ -2,30 +2,121 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
 )
 
-// StormTransformer implements Transformer using domain transform functions.
+// ValidationMode controls how StormTransformer treats events that fail
+// domain.Validate.
+type ValidationMode string
+
+const (
+	// ValidationLenient skips validation; invalid fields pass through with
+	// their defaulted values.
+	ValidationLenient ValidationMode = "lenient"
+	// ValidationWarn logs validation failures but still emits the event.
+	ValidationWarn ValidationMode = "warn"
+	// ValidationStrict rejects invalid events as transform errors, which
+	// routes them to the dead-letter topic when one is configured.
+	ValidationStrict ValidationMode = "strict"
+)
+
+// StormTransformer implements Transformer by parsing each raw event and
+// running it through an ordered chain of domain enrichers.
 type StormTransformer struct {
-	logger *slog.Logger
+	logger     *slog.Logger
+	metrics    *observability.Metrics
+	enrichers  []domain.Enricher
+	validation ValidationMode
+}
+
+// TransformerOption configures optional StormTransformer behavior.
+type TransformerOption func(*StormTransformer)
+
+// WithValidationMode sets how validation failures are handled. The default
+// is ValidationLenient.
+func WithValidationMode(mode ValidationMode) TransformerOption {
+	return func(t *StormTransformer) {
+		t.validation = mode
+	}
+}
+
+// WithEnrichers replaces the default enrichment chain. Steps run in the
+// given order; an empty chain only parses.
+func WithEnrichers(enrichers []domain.Enricher) TransformerOption {
+	return func(t *StormTransformer) {
+		t.enrichers = enrichers
+	}
 }
 
-// NewTransformer creates a StormTransformer.
-func NewTransformer(logger *slog.Logger) *StormTransformer {
-	return &StormTransformer{
-		logger: logger,
+// WithTransformMetrics records per-step enrichment duration and errors.
+func WithTransformMetrics(metrics *observability.Metrics) TransformerOption {
+	return func(t *StormTransformer) {
+		t.metrics = metrics
 	}
 }
 
+// NewTransformer creates a StormTransformer that runs domain.DefaultEnrichers
+// unless configured otherwise.
+func NewTransformer(logger *slog.Logger, opts ...TransformerOption) *StormTransformer {
+	t := &StormTransformer{
+		logger:     logger,
+		enrichers:  domain.DefaultEnrichers(),
+		validation: ValidationLenient,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
 func (t *StormTransformer) Transform(ctx context.Context, raw domain.RawEvent) (domain.StormEvent, error) {
 	event, err := domain.ParseRawEvent(raw)
 	if err != nil {
 		return domain.StormEvent{}, err
 	}
 
-	event = domain.EnrichStormEvent(event)
+	for _, e := range t.enrichers {
+		if err := t.enrich(ctx, e, &event); err != nil {
+			return domain.StormEvent{}, err
+		}
+	}
+
+	if t.validation == ValidationLenient {
+		return event, nil
+	}
+	if errs := domain.Validate(event); len(errs) > 0 {
+		verr := domain.ValidationErrors(errs)
+		if t.validation == ValidationStrict {
+			return domain.StormEvent{}, fmt.Errorf("event %s: %w", event.ID, verr)
+		}
+		t.logger.Warn("storm event failed validation",
+			"id", event.ID,
+			"topic", raw.Topic,
+			"partition", raw.Partition,
+			"offset", raw.Offset,
+			"error", verr,
+		)
+	}
 
 	return event, nil
 }
+
+func (t *StormTransformer) enrich(ctx context.Context, e domain.Enricher, event *domain.StormEvent) error {
+	start := time.Now()
+	err := e.Enrich(ctx, event)
+	if t.metrics != nil {
+		t.metrics.EnrichmentStepDuration.WithLabelValues(e.Name()).Observe(time.Since(start).Seconds())
+		if err != nil {
+			t.metrics.EnrichmentStepErrors.WithLabelValues(e.Name()).Inc()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("enrich %s: %w", e.Name(), err)
+	}
+	return nil
+}
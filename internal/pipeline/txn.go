@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// txnAbortTimeout bounds the abort of a failed transaction, which also runs
+// when the pipeline is stopping and its context is already cancelled.
+const txnAbortTimeout = 10 * time.Second
+
+// ErrRebalanced is wrapped by a TransactionalLoader error when the consumer
+// group rebalanced after the batch was fetched, so this instance may no longer
+// own its partitions and must not commit their offsets. The batch is dropped
+// rather than retried; whichever member owns the partitions now reads it
+// again from the last committed offset.
+var ErrRebalanced = errors.New("consumer group rebalanced")
+
+// TransactionalLoader is a BatchLoader that can write a batch and commit the
+// source offsets it consumed in one sink transaction, so a batch is either
+// delivered and committed exactly once or not at all.
+type TransactionalLoader interface {
+	BatchLoader
+	// BeginTxn opens a transaction for the following LoadBatch calls.
+	BeginTxn(ctx context.Context) error
+	// CommitTxn adds the consumer offsets of events to the open transaction
+	// and commits it.
+	CommitTxn(ctx context.Context, events []domain.RawEvent) error
+	// AbortTxn discards everything written in the open transaction.
+	AbortTxn(ctx context.Context) error
+}
+
+// WithTransactions wraps each batch in a transaction on l, which replaces the
+// loader passed to New. The batch's offsets are committed by l inside the
+// transaction instead of by the extractor, and a failed load or commit aborts
+// the transaction and retries the whole batch. Transactions run one at a
+// time, so WithTransactions overrides WithWorkers.
+func WithTransactions(l TransactionalLoader) Option {
+	return func(p *Pipeline) {
+		p.txn = l
+		p.loader = l
+	}
+}
+
+// loadInTxn loads a transformed batch and commits toCommit in one sink
+// transaction, retrying in a new transaction with backoff until it commits or
+// the consumer group rebalances.
+// Dedup versions are recorded only after the commit, so an aborted attempt
+// never suppresses its retry. Returns the number of loaded events and false
+// if the pipeline should stop.
+func (p *Pipeline) loadInTxn(ctx context.Context, events []domain.StormEvent, raws []domain.RawEvent, versions []*SeenEntry, rejected []rejection, parked parkedSet, toCommit []domain.RawEvent, maxBackoff time.Duration) (int, bool) {
+	backoff := 200 * time.Millisecond
+	for {
+		loaded, err := p.transact(ctx, events, raws, rejected, parked, toCommit, maxBackoff)
+		if err == nil {
+			return p.recordLoaded(ctx, events, raws, versions, loaded), true
+		}
+		if errors.Is(err, errStopped) || ctx.Err() != nil {
+			return 0, false
+		}
+		p.metrics.TransactionsAborted.Inc()
+		if errors.Is(err, ErrRebalanced) {
+			p.logger.Warn("transaction aborted after a rebalance, dropping batch", "error", err, "batch_size", len(toCommit))
+			return 0, true
+		}
+		p.logger.Error("transaction aborted, retrying batch", "error", err, "batch_size", len(toCommit))
+		p.control.recordError(fmt.Errorf("transaction: %w", err))
+		if !p.backoffOrStop(ctx, &backoff, maxBackoff) {
+			return 0, false
+		}
+	}
+}
+
+// transact makes one attempt at loading events and committing toCommit in a
+// transaction, aborting it on failure. The dead-letter writer is not part of
+// the transaction, so rejected messages are parked after the load and before
+// the commit, and parked carries them over to the next attempt so a retry
+// does not park them again.
+func (p *Pipeline) transact(ctx context.Context, events []domain.StormEvent, raws []domain.RawEvent, rejected []rejection, parked parkedSet, toCommit []domain.RawEvent, maxBackoff time.Duration) ([]bool, error) {
+	if err := p.txn.BeginTxn(ctx); err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+
+	loaded := make([]bool, len(events))
+	var err error
+	if len(events) > 0 {
+		loaded, err = p.tracedLoad(ctx, events, raws, parked, maxBackoff)
+	}
+	if err == nil && !p.parkRejected(ctx, rejected, parked, maxBackoff) {
+		err = errStopped
+	}
+	if err == nil {
+		if err = p.commitTxn(ctx, toCommit); err == nil {
+			return loaded, nil
+		}
+	}
+
+	// Abort even when stopping, so the open transaction does not hold back
+	// read_committed consumers until the broker times it out.
+	abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), txnAbortTimeout)
+	defer cancel()
+	if abortErr := p.txn.AbortTxn(abortCtx); abortErr != nil {
+		p.logger.Warn("abort transaction failed", "error", abortErr)
+	}
+	return nil, err
+}
+
+// commitTxn commits the open transaction with the offsets of batch.
+func (p *Pipeline) commitTxn(ctx context.Context, batch []domain.RawEvent) error {
+	commitCtx, commitSpan := p.tracer.Start(ctx, "pipeline.commit")
+	defer commitSpan.End()
+	start := time.Now()
+	if err := p.txn.CommitTxn(commitCtx, batch); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	p.metrics.CommitDuration.Observe(time.Since(start).Seconds())
+	for _, raw := range batch {
+		p.trackCommitted(raw)
+	}
+	return nil
+}
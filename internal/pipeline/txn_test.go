@@ -0,0 +1,210 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTxnLoader records the transaction calls it receives. The first
+// len(loadErrs) loads and len(commitErrs) commits fail with the given errors;
+// cancelOnLoad, when set, is called by every load.
+type mockTxnLoader struct {
+	mu           sync.Mutex
+	cancelOnLoad context.CancelFunc
+	calls        []string
+	loaded       [][]domain.StormEvent
+	committed    [][]domain.RawEvent
+	loadErrs     []error
+	commitErrs   []error
+}
+
+func (m *mockTxnLoader) BeginTxn(context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, "begin")
+	return nil
+}
+
+func (m *mockTxnLoader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, "load")
+	m.loaded = append(m.loaded, events)
+	if m.cancelOnLoad != nil {
+		m.cancelOnLoad()
+		return context.Canceled
+	}
+	if len(m.loadErrs) > 0 {
+		err := m.loadErrs[0]
+		m.loadErrs = m.loadErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (m *mockTxnLoader) CommitTxn(_ context.Context, events []domain.RawEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, "commit")
+	if len(m.commitErrs) > 0 {
+		err := m.commitErrs[0]
+		m.commitErrs = m.commitErrs[1:]
+		return err
+	}
+	m.committed = append(m.committed, events)
+	return nil
+}
+
+func (m *mockTxnLoader) AbortTxn(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ctx.Err() != nil {
+		m.calls = append(m.calls, "abort with cancelled context")
+		return ctx.Err()
+	}
+	m.calls = append(m.calls, "abort")
+	return nil
+}
+
+func TestPipeline_Run_Transactions_CommitOffsetsInTransaction(t *testing.T) {
+	var perMessage atomic.Int64
+	commit := func(context.Context) error {
+		perMessage.Add(1)
+		return nil
+	}
+	good := makeRawEvent(t, "evt-1", "hail")
+	good.Offset = 7
+	good.Commit = commit
+	bad := domain.RawEvent{Value: []byte("not-json"), Offset: 8, Commit: commit}
+
+	ext := &committingBatchExtractor{mockBatchExtractor: mockBatchExtractor{batches: [][]domain.RawEvent{{good, bad}}}}
+	loader := &mockTxnLoader{}
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithTransactions(loader))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	assert.Equal(t, []string{"begin", "load", "commit"}, loader.calls)
+	require.Len(t, loader.committed, 1)
+	assert.Equal(t, []int64{7, 8}, offsets(loader.committed[0]), "skipped messages commit with the transaction")
+	assert.Zero(t, perMessage.Load(), "the extractor does not commit")
+	assert.Empty(t, ext.commits)
+}
+
+func TestPipeline_Run_Transactions_AbortAndRetryBatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		loader *mockTxnLoader
+		calls  []string
+	}{
+		{
+			name:   "load fails",
+			loader: &mockTxnLoader{loadErrs: []error{errors.New("broker unavailable")}},
+			calls:  []string{"begin", "load", "abort", "begin", "load", "commit"},
+		},
+		{
+			name:   "commit fails",
+			loader: &mockTxnLoader{commitErrs: []error{errors.New("coordinator moved")}},
+			calls:  []string{"begin", "load", "commit", "abort", "begin", "load", "commit"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := makeRawEvent(t, "evt-1", "hail")
+			ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}}}
+			store := newMockSeenStore()
+			metrics := newTestMetrics()
+			p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), metrics, testBatchSize,
+				pipeline.WithTransactions(tt.loader),
+				pipeline.WithDedup(store, pipeline.DedupDrop))
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			require.NoError(t, p.Run(ctx))
+
+			assert.Equal(t, tt.calls, tt.loader.calls)
+			require.Len(t, tt.loader.loaded, 2)
+			assert.Len(t, tt.loader.loaded[1], 1, "the aborted attempt is not treated as a duplicate")
+			assert.Equal(t, float64(1), testutil.ToFloat64(metrics.TransactionsAborted))
+			assert.Equal(t, float64(1), testutil.ToFloat64(metrics.MessagesProduced))
+			_, ok, _ := store.Get(context.Background(), "evt-1")
+			assert.True(t, ok, "recorded once committed")
+		})
+	}
+}
+
+func TestPipeline_Run_Transactions_RetryDoesNotDeadLetterTwice(t *testing.T) {
+	bad := makeRawEvent(t, "evt-1", "hail")
+	good := makeRawEvent(t, "evt-2", "wind")
+	good.Offset = 1
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{bad, good}}}
+	loader := &mockTxnLoader{commitErrs: []error{errors.New("coordinator moved")}}
+	dlq := &mockDeadLetter{}
+	p := pipeline.New(ext, &partialFailTransformer{failOn: 1}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithTransactions(loader), pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	assert.Equal(t, []string{"begin", "load", "commit", "abort", "begin", "load", "commit"}, loader.calls)
+	require.Len(t, dlq.raws, 1, "the retried transaction skips the message already parked")
+	assert.Equal(t, bad.Value, dlq.raws[0].Value)
+	require.Len(t, loader.committed, 1)
+	assert.Equal(t, []int64{0, 1}, offsets(loader.committed[0]))
+}
+
+func TestPipeline_Run_Transactions_RebalanceDropsBatch(t *testing.T) {
+	first := makeRawEvent(t, "evt-1", "hail")
+	second := makeRawEvent(t, "evt-2", "wind")
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{first}, {second}}}
+	loader := &mockTxnLoader{commitErrs: []error{fmt.Errorf("commit offset: %w", pipeline.ErrRebalanced)}}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), metrics, testBatchSize,
+		pipeline.WithTransactions(loader))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	assert.Equal(t, []string{"begin", "load", "commit", "abort", "begin", "load", "commit"}, loader.calls,
+		"the rejected batch is not retried")
+	require.Len(t, loader.committed, 1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.TransactionsAborted))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.MessagesProduced))
+}
+
+func TestPipeline_Run_Transactions_AbortOnStop(t *testing.T) {
+	raw := makeRawEvent(t, "evt-1", "hail")
+	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	loader := &mockTxnLoader{cancelOnLoad: cancel}
+	p := pipeline.New(ext, &mockTransformer{}, &mockBatchLoader{}, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithTransactions(loader))
+
+	require.NoError(t, p.Run(ctx))
+	assert.Equal(t, []string{"begin", "load", "abort"}, loader.calls, "the open transaction is aborted on shutdown")
+}
+
+func offsets(events []domain.RawEvent) []int64 {
+	out := make([]int64, len(events))
+	for i, raw := range events {
+		out[i] = raw.Offset
+	}
+	return out
+}
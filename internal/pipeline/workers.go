@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// workerQueueDepth is how many partition batches may wait for each worker.
+// With the batch the worker is processing, at most workers*(workerQueueDepth+1)
+// batches are in flight; beyond that the extract loop blocks.
+const workerQueueDepth = 1
+
+// WithWorkers fans each extracted batch out by partition to n workers. A
+// partition is always handled by the same worker, so events within a
+// partition are loaded and committed in order while partitions proceed in
+// parallel. n <= 1 keeps the single-goroutine loop.
+func WithWorkers(n int) Option {
+	return func(p *Pipeline) {
+		p.workers = max(n, 1)
+	}
+}
+
+// partitionBatch is the slice of an extracted batch that belongs to one partition.
+type partitionBatch struct {
+	events    []domain.RawEvent
+	started   time.Time // when the extract began
+	extracted time.Time // when the extract returned
+}
+
+// runWorkers extracts batches on the calling goroutine and dispatches them to
+// the workers until ctx is cancelled or the source is exhausted, then waits
+// for in-flight batches to finish or abandon their loads.
+func (p *Pipeline) runWorkers(ctx context.Context) error {
+	queues := make([]chan partitionBatch, p.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan partitionBatch, workerQueueDepth)
+		wg.Add(1)
+		go func(queue <-chan partitionBatch) {
+			defer wg.Done()
+			p.work(ctx, queue)
+		}(queues[i])
+	}
+	defer func() {
+		for _, q := range queues {
+			close(q)
+		}
+		wg.Wait()
+	}()
+
+	backoff := 200 * time.Millisecond
+	maxBackoff := 5 * time.Second
+
+	for {
+		if !p.awaitResume(ctx) {
+			p.logger.Info("pipeline stopping", "reason", ctx.Err())
+			return nil
+		}
+		start := time.Now()
+		rawBatch, ok := p.extract(ctx, &backoff, maxBackoff)
+		if !ok {
+			if ctx.Err() != nil {
+				p.logger.Info("pipeline stopping", "reason", ctx.Err())
+			}
+			return nil
+		}
+		extracted := time.Now()
+
+		for _, events := range splitByPartition(rawBatch) {
+			queue := queues[events[0].Partition%p.workers]
+			// The batch is in flight from here; the worker that receives it
+			// finishes it, and it is finished here if it is never sent.
+			p.health.startBatch()
+			select {
+			case queue <- partitionBatch{events: events, started: start, extracted: extracted}:
+			case <-ctx.Done():
+				p.health.finishBatch(false)
+				p.logger.Info("pipeline stopping", "reason", ctx.Err())
+				return nil
+			}
+		}
+	}
+}
+
+// work processes partition batches from queue in order. After cancellation it
+// drains the queue without processing so the dispatcher never blocks.
+func (p *Pipeline) work(ctx context.Context, queue <-chan partitionBatch) {
+	maxBackoff := 5 * time.Second
+	for b := range queue {
+		p.runBatch(ctx, b.events, b.started, b.extracted, maxBackoff)
+	}
+}
+
+// splitByPartition groups a batch by partition, preserving source order
+// within each partition and the order in which partitions first appear.
+func splitByPartition(batch []domain.RawEvent) [][]domain.RawEvent {
+	index := make(map[int]int)
+	var groups [][]domain.RawEvent
+	for _, raw := range batch {
+		i, ok := index[raw.Partition]
+		if !ok {
+			i = len(groups)
+			index[raw.Partition] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], raw)
+	}
+	return groups
+}
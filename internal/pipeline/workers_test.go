@@ -0,0 +1,125 @@
+package pipeline_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBatchLoader records loaded events and is safe for concurrent workers.
+type syncBatchLoader struct {
+	mu      sync.Mutex
+	events  []domain.StormEvent
+	release chan struct{} // when non-nil, LoadBatch blocks until closed
+	calls   atomic.Int64
+}
+
+func (m *syncBatchLoader) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	m.calls.Add(1)
+	if m.release != nil {
+		select {
+		case <-m.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func TestPipeline_Run_Workers_PreservesPartitionOrder(t *testing.T) {
+	const partitions, batches, perPartition = 4, 5, 3
+
+	var mu sync.Mutex
+	committed := make(map[int][]int64)
+	var extracted [][]domain.RawEvent
+	offset := make(map[int]int64)
+	for range batches {
+		var batch []domain.RawEvent
+		for range perPartition {
+			for part := range partitions {
+				raw := makeRawEvent(t, fmt.Sprintf("evt-%d-%d", part, offset[part]), "hail")
+				raw.Partition = part
+				raw.Offset = offset[part]
+				raw.Commit = func(_ context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					committed[raw.Partition] = append(committed[raw.Partition], raw.Offset)
+					return nil
+				}
+				offset[part]++
+				batch = append(batch, raw)
+			}
+		}
+		extracted = append(extracted, batch)
+	}
+
+	ext := &mockBatchExtractor{batches: extracted}
+	loader := &syncBatchLoader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithWorkers(3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.events, partitions*batches*perPartition)
+
+	loadedOrder := make(map[string][]string)
+	for _, ev := range loader.events {
+		var part int
+		_, err := fmt.Sscanf(ev.ID, "evt-%d-", &part)
+		require.NoError(t, err)
+		key := fmt.Sprint(part)
+		loadedOrder[key] = append(loadedOrder[key], ev.ID)
+	}
+	for part := range partitions {
+		want := make([]string, 0, batches*perPartition)
+		wantOffsets := make([]int64, 0, batches*perPartition)
+		for off := range int64(batches * perPartition) {
+			want = append(want, fmt.Sprintf("evt-%d-%d", part, off))
+			wantOffsets = append(wantOffsets, off)
+		}
+		assert.Equal(t, want, loadedOrder[fmt.Sprint(part)], "partition %d loaded in order", part)
+		assert.Equal(t, wantOffsets, committed[part], "partition %d committed in order", part)
+		assert.Equal(t, float64(batches*perPartition-1),
+			testutil.ToFloat64(metrics.CommittedOffset.WithLabelValues(fmt.Sprint(part))))
+	}
+	assert.NoError(t, p.CheckReadiness(context.Background()))
+}
+
+func TestPipeline_Run_Workers_BoundsInFlight(t *testing.T) {
+	raw := makeRawEvent(t, "evt-1", "hail")
+	ext := &retryBatchExtractor{event: raw, max: 100}
+	loader := &syncBatchLoader{release: make(chan struct{})}
+
+	p := pipeline.New(ext, &mockTransformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithWorkers(2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Run(ctx) }()
+
+	// One partition means one busy worker: the batch it is loading, one
+	// queued batch, and one held by the blocked dispatcher.
+	require.Eventually(t, func() bool { return loader.calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.LessOrEqual(t, ext.count.Load(), int64(3))
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,180 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+const (
+	draft = "https://json-schema.org/draft/2020-12/schema"
+	id    = "https://github.com/couchcryptid/storm-data-etl/internal/schema/storm_event.schema.json"
+)
+
+// constraints are the value rules struct tags cannot express, keyed by
+// "<Go type>.<JSON name>".
+var constraints = map[string]genNode{
+	"StormEvent.id":         {Pattern: `^(hail|tornado|wind)-[0-9a-f]{16}$`},
+	"StormEvent.event_type": {Enum: []string{"hail", "tornado", "wind"}},
+	"Measurement.unit":      {Enum: []string{"in", "mph", "f_scale"}},
+	"Measurement.severity":  {Enum: []string{"minor", "moderate", "severe", "extreme"}},
+	"Geo.lat":               {Minimum: ptr(-90.0), Maximum: ptr(90.0)},
+	"Geo.lon":               {Minimum: ptr(-180.0), Maximum: ptr(180.0)},
+	"Location.geo_issue": {Enum: []string{
+		domain.GeoIssueTransposed, domain.GeoIssueLatSignFlipped,
+		domain.GeoIssueLonSignFlipped, domain.GeoIssueTransposedFlips,
+	}},
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// genNode is a schema node as written by Generate, with keywords and
+// properties in a stable, readable order.
+type genNode struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Version is an annotation; validators ignore it.
+	Version string `json:"version,omitempty"`
+
+	Ref                  string     `json:"$ref,omitempty"`
+	Type                 string     `json:"type,omitempty"`
+	Format               string     `json:"format,omitempty"`
+	Pattern              string     `json:"pattern,omitempty"`
+	Enum                 []string   `json:"enum,omitempty"`
+	Minimum              *float64   `json:"minimum,omitempty"`
+	Maximum              *float64   `json:"maximum,omitempty"`
+	Items                *genNode   `json:"items,omitempty"`
+	Properties           namedNodes `json:"properties,omitempty"`
+	Required             []string   `json:"required,omitempty"`
+	AdditionalProperties *bool      `json:"additionalProperties,omitempty"`
+	Defs                 namedNodes `json:"$defs,omitempty"`
+}
+
+type namedNode struct {
+	name string
+	node *genNode
+}
+
+// namedNodes marshals as a JSON object that keeps its order.
+type namedNodes []namedNode
+
+func (n namedNodes) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, nn := range n {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(nn.name)
+		node, err := json.Marshal(nn.node)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(node)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Generate derives the JSON Schema of the sink message from the JSON encoding
+// of domain.StormEvent. Fields encoding/json can omit are optional; nested
+// structs become $defs.
+func Generate() ([]byte, error) {
+	g := &generator{defs: map[reflect.Type]bool{}}
+	root := g.object(reflect.TypeFor[domain.StormEvent]())
+	root.Schema = draft
+	root.ID = id
+	root.Title = "StormEvent"
+	root.Description = "An enriched NOAA storm report, as produced to the sink topic."
+	root.Version = Version
+	root.Defs = g.order
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type generator struct {
+	defs  map[reflect.Type]bool
+	order namedNodes
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// object returns the schema of a struct's JSON encoding.
+func (g *generator) object(t reflect.Type) *genNode {
+	n := &genNode{Type: "object", AdditionalProperties: ptr(false)}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := g.value(f.Type)
+		if c, ok := constraints[t.Name()+"."+name]; ok {
+			prop.Pattern = c.Pattern
+			prop.Enum = c.Enum
+			prop.Minimum = c.Minimum
+			prop.Maximum = c.Maximum
+		}
+		n.Properties = append(n.Properties, namedNode{name, prop})
+		if !omittable(f.Type, opts) {
+			n.Required = append(n.Required, name)
+		}
+	}
+	return n
+}
+
+// value returns the schema of a field's JSON encoding.
+func (g *generator) value(t reflect.Type) *genNode {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &genNode{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		if !g.defs[t] {
+			g.defs[t] = true
+			g.order = append(g.order, namedNode{t.Name(), nil})
+			i := len(g.order) - 1
+			def := g.object(t)
+			g.order[i].node = def
+		}
+		return &genNode{Ref: "#/$defs/" + t.Name()}
+	case t.Kind() == reflect.Slice:
+		return &genNode{Type: "array", Items: g.value(t.Elem())}
+	case t.Kind() == reflect.String:
+		return &genNode{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		return &genNode{Type: "boolean"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return &genNode{Type: "number"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return &genNode{Type: "integer"}
+	}
+	panic(fmt.Sprintf("schema: unsupported field type %s", t))
+}
+
+// omittable reports whether encoding/json can leave the field out. omitempty
+// never omits a struct.
+func omittable(t reflect.Type, opts string) bool {
+	if !strings.Contains(","+opts+",", ",omitempty,") {
+		return false
+	}
+	return t.Kind() != reflect.Struct
+}
@@ -0,0 +1,304 @@
+// Package schema ships the JSON Schema of the enriched storm event message,
+// the contract between this service and storm-data-api, and validates events
+// against it.
+//
+// The schema is generated from domain.StormEvent by Generate and committed as
+// storm_event.schema.json; tests fail when the two drift apart. Run
+// `go test ./internal/schema -update` to regenerate it after changing the
+// message, and bump Version.
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// Version is the version of the message contract, sent in the schema_version
+// header of every produced message. Bump the major version for changes that
+// break existing consumers, such as removing a field or an enum value, and
+// the minor version for additions.
+const Version = "1.0.0"
+
+//go:embed storm_event.schema.json
+var stormEventJSON []byte
+
+// StormEventJSON returns the shipped JSON Schema document.
+func StormEventJSON() []byte {
+	return slices.Clone(stormEventJSON)
+}
+
+// Violation is a place where a message does not conform to the schema. Path
+// is the JSON path of the offending value, e.g. "measurement.unit", or empty
+// for the message itself.
+type Violation struct {
+	Path   string
+	Reason string
+}
+
+func (v Violation) Error() string {
+	if v.Path == "" {
+		return v.Reason
+	}
+	return v.Path + ": " + v.Reason
+}
+
+// Violations is the error form of a failed validation.
+type Violations []Violation
+
+func (v Violations) Error() string {
+	parts := make([]string, len(v))
+	for i, violation := range v {
+		parts[i] = violation.Error()
+	}
+	return "does not match storm event schema " + Version + ": " + strings.Join(parts, "; ")
+}
+
+// node is the subset of JSON Schema the message schema uses.
+type node struct {
+	Ref                  string           `json:"$ref"`
+	Defs                 map[string]*node `json:"$defs"`
+	Type                 string           `json:"type"`
+	Properties           map[string]*node `json:"properties"`
+	Required             []string         `json:"required"`
+	AdditionalProperties *bool            `json:"additionalProperties"`
+	Items                *node            `json:"items"`
+	Enum                 []any            `json:"enum"`
+	Pattern              string           `json:"pattern"`
+	Format               string           `json:"format"`
+	Minimum              *float64         `json:"minimum"`
+	Maximum              *float64         `json:"maximum"`
+
+	re *regexp.Regexp
+}
+
+var (
+	loadOnce sync.Once
+	root     *node
+	loadErr  error
+)
+
+// load parses the shipped schema once.
+func load() (*node, error) {
+	loadOnce.Do(func() {
+		root, loadErr = parse(stormEventJSON)
+	})
+	return root, loadErr
+}
+
+func parse(data []byte) (*node, error) {
+	var n node
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("parse storm event schema: %w", err)
+	}
+	if err := n.compile(); err != nil {
+		return nil, fmt.Errorf("parse storm event schema: %w", err)
+	}
+	return &n, nil
+}
+
+// compile compiles the patterns of n and its children.
+func (n *node) compile() error {
+	if n.Pattern != "" {
+		re, err := regexp.Compile(n.Pattern)
+		if err != nil {
+			return err
+		}
+		n.re = re
+	}
+	for _, children := range []map[string]*node{n.Defs, n.Properties} {
+		for _, child := range children {
+			if err := child.compile(); err != nil {
+				return err
+			}
+		}
+	}
+	if n.Items != nil {
+		return n.Items.compile()
+	}
+	return nil
+}
+
+// CheckSeverityRules reports severity labels in rules that the schema does
+// not allow. Events classified with such labels could never pass
+// ValidateEvent, so a custom rule set must stay within the published enum
+// while sink validation is on.
+func CheckSeverityRules(rules *domain.SeverityRules) error {
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	allowed := s.Defs["Measurement"].Properties["severity"].Enum
+	var unknown []string
+	for _, tiers := range rules.Rules {
+		for _, tier := range tiers {
+			if !slices.Contains(allowed, any(tier.Label)) && !slices.Contains(unknown, tier.Label) {
+				unknown = append(unknown, tier.Label)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("severity labels %s are not in storm event schema %s (allowed: %s)",
+			strings.Join(unknown, ", "), Version, enumList(allowed))
+	}
+	return nil
+}
+
+// ValidateEvent checks the JSON encoding of event against the schema.
+func ValidateEvent(event domain.StormEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("serialize storm event: %w", err)
+	}
+	return Validate(data)
+}
+
+// Validate checks a JSON message against the schema. It returns Violations
+// when the message does not conform.
+func Validate(data []byte) error {
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Violations{{Reason: "invalid JSON: " + err.Error()}}
+	}
+	var out Violations
+	s.validate(s, "", v, &out)
+	if len(out) > 0 {
+		return out
+	}
+	return nil
+}
+
+func (n *node) validate(root *node, path string, v any, out *Violations) {
+	fail := func(format string, args ...any) {
+		*out = append(*out, Violation{Path: path, Reason: fmt.Sprintf(format, args...)})
+	}
+	if n.Ref != "" {
+		def := root.Defs[strings.TrimPrefix(n.Ref, "#/$defs/")]
+		if def == nil {
+			fail("unresolved reference %s", n.Ref)
+			return
+		}
+		def.validate(root, path, v, out)
+		return
+	}
+	if !matchesType(n.Type, v) {
+		fail("expected %s, got %s", n.Type, jsonType(v))
+		return
+	}
+	if len(n.Enum) > 0 && !slices.Contains(n.Enum, v) {
+		fail("%s is not one of %s", quote(v), enumList(n.Enum))
+	}
+
+	switch v := v.(type) {
+	case string:
+		if n.re != nil && !n.re.MatchString(v) {
+			fail("%q does not match %s", v, n.Pattern)
+		}
+		if n.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				fail("%q is not an RFC 3339 date-time", v)
+			}
+		}
+	case float64:
+		if n.Minimum != nil && v < *n.Minimum {
+			fail("%g is less than %g", v, *n.Minimum)
+		}
+		if n.Maximum != nil && v > *n.Maximum {
+			fail("%g is greater than %g", v, *n.Maximum)
+		}
+	case []any:
+		if n.Items != nil {
+			for i, item := range v {
+				n.Items.validate(root, fmt.Sprintf("%s[%d]", path, i), item, out)
+			}
+		}
+	case map[string]any:
+		for _, name := range n.Required {
+			if _, ok := v[name]; !ok {
+				*out = append(*out, Violation{Path: join(path, name), Reason: "required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := n.Properties[name]
+			if !ok {
+				if n.AdditionalProperties != nil && !*n.AdditionalProperties {
+					*out = append(*out, Violation{Path: join(path, name), Reason: "not allowed"})
+				}
+				continue
+			}
+			prop.validate(root, join(path, name), v[name], out)
+		}
+	}
+}
+
+func matchesType(typ string, v any) bool {
+	switch typ {
+	case "":
+		return true
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return jsonType(v) == typ
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func quote(v any) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+func enumList(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,177 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "regenerate storm_event.schema.json from domain.StormEvent")
+
+// versionDigests is the SHA-256 of the shipped schema at each Version. A
+// schema change without a Version bump fails TestVersion_BumpedOnSchemaChange;
+// add the digest of the new version when bumping it.
+var versionDigests = map[string]string{
+	"1.0.0": "75aac010a534308011d2d8793cf38e6745adbe1074806c5f6382a544ffc83fb5",
+}
+
+func TestStormEventSchema_MatchesStruct(t *testing.T) {
+	generated, err := Generate()
+	require.NoError(t, err)
+	if *update {
+		require.NoError(t, os.WriteFile("storm_event.schema.json", generated, 0o644))
+		return
+	}
+	assert.Equal(t, string(generated), string(stormEventJSON),
+		"domain.StormEvent and storm_event.schema.json have drifted: run go test ./internal/schema -update and bump Version")
+}
+
+func TestVersion_BumpedOnSchemaChange(t *testing.T) {
+	sum := sha256.Sum256(stormEventJSON)
+	digest := hex.EncodeToString(sum[:])
+	want, ok := versionDigests[Version]
+	require.True(t, ok, "add version %s with digest %s to versionDigests", Version, digest)
+	assert.Equal(t, want, digest, "storm_event.schema.json changed without a Version bump")
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(stormEventJSON, &doc))
+	assert.Equal(t, Version, doc["version"])
+}
+
+func TestStormEventSchema_SeverityLabels(t *testing.T) {
+	s, err := load()
+	require.NoError(t, err)
+	severity := s.Defs["Measurement"].Properties["severity"]
+	for eventType, tiers := range domain.DefaultSeverityRules().Rules {
+		for _, tier := range tiers {
+			assert.Contains(t, severity.Enum, tier.Label, "%s severity label", eventType)
+		}
+	}
+}
+
+func TestCheckSeverityRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr string
+	}{
+		{"default rules", "", ""},
+		{"custom thresholds", `{"version":"custom","rules":{"hail":[{"below":2,"label":"minor"},{"label":"extreme"}]}}`, ""},
+		{"custom labels", `{"version":"custom","rules":{"hail":[{"below":2,"label":"low"},{"below":4,"label":"extreme"},{"label":"catastrophic"}]}}`, "severity labels catastrophic, low are not in storm event schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := domain.DefaultSeverityRules()
+			if tt.rules != "" {
+				var err error
+				rules, err = domain.ParseSeverityRules([]byte(tt.rules))
+				require.NoError(t, err)
+			}
+
+			// Events classified with the rules pass validation exactly when
+			// the rules pass the check.
+			event := validEvent()
+			event.Measurement.Severity = rules.Classify("hail", 1.25)
+			if tt.wantErr == "" {
+				require.NoError(t, CheckSeverityRules(rules))
+				assert.NoError(t, ValidateEvent(event))
+				return
+			}
+			assert.ErrorContains(t, CheckSeverityRules(rules), tt.wantErr)
+			assert.Error(t, ValidateEvent(event))
+		})
+	}
+}
+
+func validEvent() domain.StormEvent {
+	severity := "moderate"
+	return domain.StormEvent{
+		ID:          "hail-0123456789abcdef",
+		EventType:   "hail",
+		Geo:         domain.Geo{Lat: 31.02, Lon: -98.44},
+		Measurement: domain.Measurement{Magnitude: 1.25, Unit: "in", Severity: &severity},
+		EventTime:   time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC),
+		Location:    domain.Location{Raw: "8 ESE Chappel", Name: "Chappel", State: "TX"},
+		TimeBucket:  time.Date(2024, 4, 26, 15, 0, 0, 0, time.UTC),
+		ProcessedAt: time.Date(2024, 4, 27, 6, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestValidateEvent(t *testing.T) {
+	require.NoError(t, ValidateEvent(validEvent()))
+
+	bad := validEvent()
+	bad.EventType = "sleet"
+	bad.ID = "sleet-1"
+	bad.Measurement.Unit = "knots"
+	bad.Geo.Lat = 95
+	bad.Location.GeoIssue = "swapped"
+
+	err := ValidateEvent(bad)
+	var violations Violations
+	require.ErrorAs(t, err, &violations)
+	paths := map[string]string{}
+	for _, v := range violations {
+		paths[v.Path] = v.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"event_type":         `"sleet" is not one of hail, tornado, wind`,
+		"id":                 `"sleet-1" does not match ^(hail|tornado|wind)-[0-9a-f]{16}$`,
+		"measurement.unit":   `"knots" is not one of in, mph, f_scale`,
+		"geo.lat":            "95 is greater than 90",
+		"location.geo_issue": `"swapped" is not one of transposed, lat_sign_flipped, lon_sign_flipped, transposed_sign_flipped`,
+	}, paths)
+	assert.ErrorContains(t, err, "storm event schema "+Version)
+}
+
+func TestValidate_Structure(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		path    string
+		reason  string
+	}{
+		{"missing required", `{"id":"hail-0123456789abcdef","event_type":"hail"}`, "measurement", "required"},
+		{"unknown field", `{"geo":{"lat":1,"alt":2}}`, "geo.alt", "not allowed"},
+		{"wrong type", `{"revision":"2"}`, "revision", "expected integer, got string"},
+		{"fractional integer", `{"revision":1.5}`, "revision", "expected integer, got number"},
+		{"bad date-time", `{"event_time":"2024-04-26 15:10"}`, "event_time", `"2024-04-26 15:10" is not an RFC 3339 date-time`},
+		{"array item", `{"quality":{"defaulted":["geo.lat",3]}}`, "quality.defaulted[1]", "expected string, got number"},
+		{"not an object", `[]`, "", "expected object, got array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate([]byte(tt.message))
+			var violations Violations
+			require.ErrorAs(t, err, &violations)
+			assert.Contains(t, violations, Violation{Path: tt.path, Reason: tt.reason})
+		})
+	}
+}
+
+// Every event the transform produces from the mock data conforms.
+func TestValidateEvent_MockData(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "data", "mock", "storm_reports_240426_combined.json"))
+	require.NoError(t, err)
+	var records []domain.RawCSVRecord
+	require.NoError(t, json.Unmarshal(data, &records))
+	require.NotEmpty(t, records)
+
+	baseDate := time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
+	for i, rec := range records {
+		value, err := json.Marshal(rec)
+		require.NoError(t, err)
+		parsed, err := domain.ParseRawEvent(domain.RawEvent{Value: value, Timestamp: baseDate})
+		require.NoError(t, err)
+		assert.NoError(t, ValidateEvent(domain.EnrichStormEvent(parsed)), "record %d", i)
+	}
+}
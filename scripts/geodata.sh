@@ -0,0 +1,28 @@
+#!/bin/sh
+# Downloads the Census county boundaries, NWS public forecast zones, and
+# Census Gazetteer places, simplifies them, and writes the gzipped files
+# embedded by internal/geo. Needs ogr2ogr (GDAL), curl, and network access.
+set -eu
+
+out=${1:-internal/geo/data}
+counties=${COUNTIES_URL:-https://www2.census.gov/geo/tiger/GENZ2023/shp/cb_2023_us_county_20m.zip}
+zones=${ZONES_URL:-https://www.weather.gov/source/gis/Shapefiles/WSOM/z_05mr24.zip}
+places=${PLACES_URL:-https://www2.census.gov/geo/docs/maps-data/data/gazetteer/2023_Gazetteer/2023_Gaz_place_national.zip}
+# Simplification tolerance in degrees (about 500 m).
+tolerance=${SIMPLIFY:-0.005}
+
+mkdir -p "$out"
+
+geojson() {
+	ogr2ogr -f GeoJSON -t_srs EPSG:4326 -simplify "$tolerance" \
+		-lco COORDINATE_PRECISION=4 -select "$2" /vsistdout/ "/vsizip//vsicurl/$1"
+}
+
+geojson "$counties" GEOID,STATEFP,NAME,STUSPS | gzip -9 >"$out/counties.geojson.gz"
+geojson "$zones" STATE,ZONE | gzip -9 >"$out/zones.geojson.gz"
+
+# Keep only the USPS, NAME, INTPTLAT, and INTPTLONG columns.
+tmp=$(mktemp)
+trap 'rm -f "$tmp"' EXIT
+curl -fsSL -o "$tmp" "$places"
+unzip -p "$tmp" | cut -f1,4,11,12 | gzip -9 >"$out/places.txt.gz"